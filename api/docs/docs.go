@@ -15,6 +15,427 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/audit/logs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists audit log entries matching the filters, newest first. Pass next_cursor as cursor to fetch the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Search audit logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id, shared with the upstream calls of the request",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "AppID of the calling channel",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "route as registered, e.g. /api/v1/user/:external_id",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "path as requested, e.g. /api/v1/user/25052300047",
+                        "name": "request_path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "response status code",
+                        "name": "status_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "inclusive lower bound of the entry time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exclusive upper bound of the entry time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only requests that took at least this long",
+                        "name": "min_latency_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default and at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "audit logs found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AuditLogsSuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit/logs/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams every audit log entry matching the filters, newest first, as CSV or JSON Lines. Takes the same filters as /admin/audit/logs; limit is ignored.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export audit logs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "description": "export format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "request id, shared with the upstream calls of the request",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "AppID of the calling channel",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "route as registered, e.g. /api/v1/user/:external_id",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "path as requested, e.g. /api/v1/user/25052300047",
+                        "name": "request_path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "response status code",
+                        "name": "status_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "inclusive lower bound of the entry time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exclusive upper bound of the entry time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only requests that took at least this long",
+                        "name": "min_latency_ms",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start below the entry this cursor points at",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "audit log export",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports the audit log queue depth and the entries written, dropped or lost to database errors since startup, for alerting when audit persistence falls behind.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Audit log writer statistics",
+                "responses": {
+                    "200": {
+                        "description": "audit stats",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AuditStatsSuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit/upstream-calls": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the calls to CIAM, RLP, CMS and ACS made while serving the request with the given request id, in the order they were made. The request id is on the request's audit log entry.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Upstream calls of a request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id of the audit log entry",
+                        "name": "request_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "upstream calls found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UpstreamCallsSuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/registrations/{rlp_id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Re-drives a failed or stuck registration attempt from its first unfinished step.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry a stuck registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RLP ID of the registration attempt",
+                        "name": "rlp_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "registration completed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreateSuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "registration attempt not found or not resumable, request in progress",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/upstreams": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports the circuit breaker of every upstream (CIAM, RLP core, RLP offers, CMS, ACS, member service): closed, open while failing fast, or half_open while a probe call is out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Upstream circuit breakers",
+                "responses": {
+                    "200": {
+                        "description": "upstream states",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UpstreamStatesSuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/auth": {
             "post": {
                 "description": "Validates AppID header and HMAC signature, then returns a JWT access token.",
@@ -131,7 +552,7 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "existing user not found",
+                        "description": "existing user not found, member suspended, request in progress",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -141,6 +562,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -193,11 +620,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "gr profile already linked or gr member not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "otp resend cooldown or daily limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -261,6 +706,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -374,23 +825,116 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
+                    "429": {
+                        "description": "otp resend cooldown or daily limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/lookup": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Resolves a member identifier (rlp_id, rlp_no, gr_id, email or ciam_id) to the RLP_ID and retrieves the profile.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Look up user profile",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rlp_id",
+                            "rlp_no",
+                            "gr_id",
+                            "email",
+                            "ciam_id"
+                        ],
+                        "type": "string",
+                        "description": "identifier type",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "identifier value",
+                        "name": "value",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetUserSuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or missing query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized – API key missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "existing user not found, member suspended",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/user/pin": {
-            "put": {
+        "/user/otp/verify": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates the burn PIN for a given email address.",
+                "description": "Validates an OTP sent for registration or login and returns a short-lived verification token, required by /user/register for NEW and GR sign-ups.",
                 "consumes": [
                     "application/json"
                 ],
@@ -400,23 +944,23 @@ const docTemplate = `{
                 "tags": [
                     "user"
                 ],
-                "summary": "Update user burn PIN",
+                "summary": "Verify OTP",
                 "parameters": [
                     {
-                        "description": "Email + new burn PIN",
+                        "description": "OTP verification payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.UpdateBurnPin"
+                            "$ref": "#/definitions/requests.VerifyOtp"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "update successful",
+                        "description": "otp verified",
                         "schema": {
-                            "$ref": "#/definitions/responses.UpdateUserSuccessResponse"
+                            "$ref": "#/definitions/responses.VerifyOtpSuccessResponse"
                         }
                     },
                     "400": {
@@ -431,6 +975,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid otp, otp not found or expired, or too many invalid attempts",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -488,11 +1038,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid verification token, existing user found, request in progress",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -551,11 +1113,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
+                    "429": {
+                        "description": "otp resend cooldown or daily limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -616,7 +1190,7 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "existing user not found",
+                        "description": "existing user not found, member suspended",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -626,6 +1200,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -677,7 +1257,7 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "existing user not found",
+                        "description": "existing user not found, member suspended",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -687,19 +1267,114 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Upstream service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "audit.Stats": {
+            "type": "object",
+            "properties": {
+                "dropped": {
+                    "description": "Dropped entries found the queue full for longer than the enqueue timeout",
+                    "type": "integer"
+                },
+                "enqueued": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed entries were part of a batch the database rejected",
+                    "type": "integer"
+                },
+                "failed_batches": {
+                    "type": "integer"
+                },
+                "queue_capacity": {
+                    "type": "integer"
+                },
+                "queue_depth": {
+                    "type": "integer"
+                },
+                "written": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AuditLog": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string"
+                },
+                "chain_seq": {
+                    "description": "ChainSeq orders the entry in the hash chain, nil for entries written before it existed",
+                    "type": "integer"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "Hash covers the entry's contents and the hash of the entry before it, see audit.Hash",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "route pattern, e.g. /api/v1/user/:external_id",
+                    "type": "string"
+                },
+                "request_body": {
+                    "description": "redacted, see middleware.AuditLogger",
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "shared with the request's UpstreamCallLog rows",
+                    "type": "string"
+                },
+                "request_path": {
+                    "description": "path as requested, e.g. /api/v1/user/25052300047",
+                    "type": "string"
+                },
+                "response_body": {
+                    "description": "redacted, see middleware.AuditLogger",
+                    "type": "string"
+                },
+                "restored_from": {
+                    "description": "RestoredFrom names the archive a restored entry was loaded from; restored\nentries are outside the hash chain and retention",
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
         "model.GrProfile": {
             "type": "object",
             "properties": {
                 "class": {
-                    "description": "User’s membership class\nexample: premium",
+                    "description": "User’s membership class\nexample: 1",
                     "type": "string",
-                    "example": "premium"
+                    "example": "1"
                 },
                 "id": {
                     "description": "Unique identifier for the profile\nexample: 123e4567-e89b-12d3-a456-426614174000",
@@ -717,14 +1392,14 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "external_id": {
-                    "description": "The external identifier value\nexample: ABC123",
+                    "description": "The external identifier value\nexample: 25052300047",
                     "type": "string",
-                    "example": "ABC123"
+                    "example": "25052300047"
                 },
                 "external_id_type": {
-                    "description": "Type of the external identifier\nexample: loyalty",
+                    "description": "Type of the external identifier\nexample: RLP_ID",
                     "type": "string",
-                    "example": "loyalty"
+                    "example": "RLP_ID"
                 }
             }
         },
@@ -763,12 +1438,54 @@ const docTemplate = `{
                 }
             }
         },
+        "model.UpstreamCallLog": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "request_body": {
+                    "description": "redacted like AuditLog bodies",
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "response_body": {
+                    "description": "redacted like AuditLog bodies",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "0 when no response arrived",
+                    "type": "integer"
+                },
+                "upstream": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "secrets stripped and personal data masked, see middleware.OutboundAudit",
+                    "type": "string"
+                }
+            }
+        },
         "model.User": {
             "type": "object",
             "properties": {
                 "available_points": {
                     "description": "Loyalty points available\nexample: 1200",
-                    "type": "integer",
+                    "type": "number",
                     "example": 1200
                 },
                 "country": {
@@ -805,7 +1522,7 @@ const docTemplate = `{
                     ]
                 },
                 "identifiers": {
-                    "description": "List of external identifiers for the user\nexample: [{\"external_id\":\"ABC123\",\"external_id_type\":\"loyalty\"}]",
+                    "description": "List of external identifiers for the user\nexample: [{\"external_id\":\"25052300047\",\"external_id_type\":\"rlp_id\"}]",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/model.Identifier"
@@ -858,8 +1575,8 @@ const docTemplate = `{
             "properties": {
                 "active_status": {
                     "description": "Active status code (e.g., 1=active, 0=inactive)\nexample: 1",
-                    "type": "integer",
-                    "example": 1
+                    "type": "string",
+                    "example": "1"
                 },
                 "burn_pin": {
                     "description": "Secret Key for burn transaction\nexample: 1111",
@@ -891,13 +1608,13 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": false
                 },
-                "market_pref_mobile": {
-                    "description": "Whether the user opts in to SMS/mobile marketing\nexample: true",
+                "market_pref_push": {
+                    "description": "Whether the user opts in to push notifications\nexample: true",
                     "type": "boolean",
                     "example": true
                 },
-                "market_pref_push": {
-                    "description": "Whether the user opts in to push notifications\nexample: true",
+                "market_pref_sms": {
+                    "description": "Whether the user opts in to SMS/mobile marketing\nexample: true",
                     "type": "boolean",
                     "example": true
                 },
@@ -950,35 +1667,20 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "reg_id": {
-                    "type": "integer",
-                    "example": 123456
+                    "type": "string",
+                    "example": "123456"
                 },
                 "sign_up_type": {
-                    "description": "GrProfile  model.GrProfile ` + "`" + `json:\"gr_profile\"` + "`" + `",
                     "type": "string",
                     "example": "NEW"
                 },
                 "user": {
                     "$ref": "#/definitions/model.User"
-                }
-            }
-        },
-        "requests.UpdateBurnPin": {
-            "type": "object",
-            "required": [
-                "burn_pin",
-                "email"
-            ],
-            "properties": {
-                "burn_pin": {
-                    "description": "BurnPin is the new numeric PIN to set.",
-                    "type": "integer",
-                    "example": 4321
                 },
-                "email": {
-                    "description": "Email of the user whose burn PIN is being updated.",
+                "verification_token": {
+                    "description": "VerificationToken returned by /user/otp/verify, required for NEW and GR sign-ups.",
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "2f1c7a4e-8d6b-4b6f-9a51-0c3e7d2b9f10"
                 }
             }
         },
@@ -1012,6 +1714,31 @@ const docTemplate = `{
                 }
             }
         },
+        "requests.VerifyOtp": {
+            "type": "object",
+            "required": [
+                "identifier",
+                "otp",
+                "purpose"
+            ],
+            "properties": {
+                "identifier": {
+                    "description": "Identifier the OTP was issued for: the email, or the GR member id for GR_REGISTRATION.",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "otp": {
+                    "description": "Otp entered by the user.",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "purpose": {
+                    "description": "Purpose the OTP was issued for: REGISTRATION, GR_REGISTRATION or LOGIN.",
+                    "type": "string",
+                    "example": "REGISTRATION"
+                }
+            }
+        },
         "requests.VerifyUserExistence": {
             "type": "object",
             "required": [
@@ -1025,6 +1752,55 @@ const docTemplate = `{
                 }
             }
         },
+        "responses.AuditLogsResponseData": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditLog"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor fetches the following page, absent on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "responses.AuditLogsSuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "in: body",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "data": {
+                    "$ref": "#/definitions/responses.AuditLogsResponseData"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "audit logs found"
+                }
+            }
+        },
+        "responses.AuditStatsSuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "in: body",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "data": {
+                    "$ref": "#/definitions/audit.Stats"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "audit stats"
+                }
+            }
+        },
         "responses.AuthResponseData": {
             "type": "object",
             "properties": {
@@ -1236,6 +2012,46 @@ const docTemplate = `{
                 }
             }
         },
+        "responses.UpstreamCallsSuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "in: body",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UpstreamCallLog"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "upstream calls found"
+                }
+            }
+        },
+        "responses.UpstreamStatesSuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "in: body",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/upstream.BreakerState"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "upstream states"
+                }
+            }
+        },
         "responses.VerifyGrCmsUserResponseData": {
             "type": "object",
             "properties": {
@@ -1269,6 +2085,58 @@ const docTemplate = `{
                     ]
                 }
             }
+        },
+        "responses.VerifyOtpResponseData": {
+            "type": "object",
+            "properties": {
+                "verification_token": {
+                    "description": "VerificationToken is handed to follow-up requests to prove the OTP was entered.\nexample: \"2b1f0c6e-8a4e-4d8e-9a51-3f4b0f6c9d2a\"",
+                    "type": "string",
+                    "example": "2b1f0c6e-8a4e-4d8e-9a51-3f4b0f6c9d2a"
+                },
+                "verification_token_expiry": {
+                    "description": "VerificationTokenExpiry is the Unix timestamp (seconds since epoch) when the token expires.\nexample: 1744176000",
+                    "type": "integer",
+                    "example": 1744176000
+                }
+            }
+        },
+        "responses.VerifyOtpSuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "in: body",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "data": {
+                    "$ref": "#/definitions/responses.VerifyOtpResponseData"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "otp verified"
+                }
+            }
+        },
+        "upstream.BreakerState": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "closed"
+                },
+                "upstream": {
+                    "type": "string",
+                    "example": "rlp_core"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -1288,7 +2156,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "LBE API",
-	Description:      "Endpoints for authentication, login and register\n\n<details open>\n<summary><a href=\"javascript:void(0)\" style=\"cursor: pointer !important;\">📋\u00a0Message Codes</a></summary>\n\n| Code   | Description                   |\n| ------ | ------------------------------|\n| 1000   | successful                    |\n| 1001   | unsuccessful                  |\n| 1002   | found                         |\n| 1003   | not found                     |\n| 4000   | internal error                |\n| 4001   | invalid request body          |\n| 4002   | invalid authentication token  |\n| 4003   | missing authentication token  |\n| 4004   | invalid signature             |\n| 4005   | missing signature             |\n| 4006   | invalid appid                 |\n| 4007   | missing appid                 |\n| 4008   | invalid query parameters      |\n| 4009   | existing user not found       |\n| 4010   | existing user found           |\n| 4011   | cached profile not found      |\n| 4012   | gr member linked              |\n| 4013   | gr member not found           |\n\n</details>",
+	Description:      "Endpoints for authentication, login and register\n\n<details open>\n<summary><a href=\"javascript:void(0)\" style=\"cursor: pointer !important;\">📋\u00a0Message Codes</a></summary>\n\n| Code   | Description                   |\n| ------ | ------------------------------|\n| 1000   | successful                    |\n| 1001   | unsuccessful                  |\n| 1002   | found                         |\n| 1003   | not found                     |\n| 4000   | internal error                |\n| 4001   | invalid request body          |\n| 4002   | invalid authentication token  |\n| 4003   | missing authentication token  |\n| 4004   | invalid signature             |\n| 4005   | missing signature             |\n| 4006   | invalid appid                 |\n| 4007   | missing appid                 |\n| 4008   | invalid query parameters      |\n| 4009   | existing user not found       |\n| 4010   | existing user found           |\n| 4011   | cached profile not found      |\n| 4012   | gr member linked              |\n| 4013   | gr member not found           |\n| 4014   | invalid gr member class       |\n| 4015   | access denied                 |\n| 4016   | registration attempt not found|\n| 4017   | registration not resumable    |\n| 4018   | invalid otp                   |\n| 4019   | otp not found or expired      |\n| 4020   | otp attempts exceeded         |\n| 4021   | otp resend cooldown           |\n| 4022   | otp daily limit reached       |\n| 4023   | invalid verification token    |\n| 4024   | request in progress           |\n| 4025   | member suspended              |\n| 4026   | upstream conflict             |\n| 4027   | upstream validation failed    |\n| 4028   | upstream unavailable          |\n\n</details>\n\nThe same endpoints are served under /api/v2, where failed requests are answered with\nRFC 7807 problem details (application/problem+json, see responses.Problem) instead of the\nmessage code envelope. The type of a problem is urn:lbe:problem: followed by a slug of its code.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
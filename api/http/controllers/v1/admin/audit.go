@@ -0,0 +1,246 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/audit"
+	"lbe/codes"
+	"lbe/model"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditStats godoc
+// @Summary      Audit log writer statistics
+// @Description  Reports the audit log queue depth and the entries written, dropped or lost to database errors since startup, for alerting when audit persistence falls behind.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  responses.AuditStatsSuccessResponse  "audit stats"
+// @Failure      401  {object}  responses.ErrorResponse              "Unauthorized – API key missing or invalid"
+// @Failure      403  {object}  responses.ErrorResponse              "access denied"
+// @Security     ApiKeyAuth
+// @Router       /admin/audit/stats [get]
+func GetAuditStats(c *gin.Context) {
+	// without a database no writer runs and every counter stays zero
+	var stats audit.Stats
+	if writer := audit.DefaultWriter(); writer != nil {
+		stats = writer.Stats()
+	}
+
+	c.JSON(http.StatusOK, responses.ApiResponse[audit.Stats]{
+		Code:    codes.SUCCESSFUL,
+		Message: "audit stats",
+		Data:    stats,
+	})
+}
+
+// SearchAuditLogs godoc
+// @Summary      Search audit logs
+// @Description  Lists audit log entries matching the filters, newest first. Pass next_cursor as cursor to fetch the following page.
+// @Tags         admin
+// @Produce      json
+// @Param        request_id      query     string  false  "request id, shared with the upstream calls of the request"
+// @Param        actor           query     string  false  "AppID of the calling channel"
+// @Param        path            query     string  false  "route as registered, e.g. /api/v1/user/:external_id"
+// @Param        request_path    query     string  false  "path as requested, e.g. /api/v1/user/25052300047"
+// @Param        status_code     query     int     false  "response status code"
+// @Param        from            query     string  false  "inclusive lower bound of the entry time (RFC3339)"
+// @Param        to              query     string  false  "exclusive upper bound of the entry time (RFC3339)"
+// @Param        min_latency_ms  query     int     false  "only requests that took at least this long"
+// @Param        cursor          query     string  false  "next_cursor of the previous page"
+// @Param        limit           query     int     false  "page size, 50 by default and at most 500"
+// @Success      200  {object}  responses.AuditLogsSuccessResponse  "audit logs found"
+// @Failure      400  {object}  responses.ErrorResponse             "invalid query parameters"
+// @Failure      401  {object}  responses.ErrorResponse             "Unauthorized – API key missing or invalid"
+// @Failure      403  {object}  responses.ErrorResponse             "access denied"
+// @Failure      500  {object}  responses.ErrorResponse             "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /admin/audit/logs [get]
+func SearchAuditLogs(c *gin.Context) {
+	req, ok := bindAuditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	entries, nextCursor, err := services.SearchAuditLogs(c, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditCursor) {
+			c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+			return
+		}
+		utils.Logf(c, "error searching audit logs: %v", err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.ApiResponse[responses.AuditLogsResponseData]{
+		Code:    codes.SUCCESSFUL,
+		Message: "audit logs found",
+		Data: responses.AuditLogsResponseData{
+			Entries:    entries,
+			NextCursor: nextCursor,
+		},
+	})
+}
+
+// ExportAuditLogs godoc
+// @Summary      Export audit logs
+// @Description  Streams every audit log entry matching the filters, newest first, as CSV or JSON Lines. Takes the same filters as /admin/audit/logs; limit is ignored.
+// @Tags         admin
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format          query     string  true   "export format" Enums(csv, jsonl)
+// @Param        request_id      query     string  false  "request id, shared with the upstream calls of the request"
+// @Param        actor           query     string  false  "AppID of the calling channel"
+// @Param        path            query     string  false  "route as registered, e.g. /api/v1/user/:external_id"
+// @Param        request_path    query     string  false  "path as requested, e.g. /api/v1/user/25052300047"
+// @Param        status_code     query     int     false  "response status code"
+// @Param        from            query     string  false  "inclusive lower bound of the entry time (RFC3339)"
+// @Param        to              query     string  false  "exclusive upper bound of the entry time (RFC3339)"
+// @Param        min_latency_ms  query     int     false  "only requests that took at least this long"
+// @Param        cursor          query     string  false  "start below the entry this cursor points at"
+// @Success      200  {file}    file                     "audit log export"
+// @Failure      400  {object}  responses.ErrorResponse  "invalid query parameters"
+// @Failure      401  {object}  responses.ErrorResponse  "Unauthorized – API key missing or invalid"
+// @Failure      403  {object}  responses.ErrorResponse  "access denied"
+// @Security     ApiKeyAuth
+// @Router       /admin/audit/logs/export [get]
+func ExportAuditLogs(c *gin.Context) {
+	req, ok := bindAuditLogQuery(c)
+	if !ok {
+		return
+	}
+	if req.Format == "" {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return
+	}
+
+	var write func(model.AuditLog) error
+	var flush func() error
+
+	switch req.Format {
+	case codes.AuditExportFormatCsv:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(auditCsvHeader); err != nil {
+			utils.Logf(c, "error exporting audit logs: %v", err)
+			return
+		}
+		write = func(entry model.AuditLog) error { return w.Write(auditCsvRecord(entry)) }
+		flush = func() error { w.Flush(); return w.Error() }
+
+	case codes.AuditExportFormatJsonl:
+		c.Header("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(c.Writer)
+		write = func(entry model.AuditLog) error { return enc.Encode(entry) }
+		flush = func() error { return nil }
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit_logs_%s.%s"`, time.Now().Format("20060102150405"), req.Format))
+	c.Status(http.StatusOK)
+
+	// the status is sent with the first row, a failure after that can only cut the stream short
+	err := services.ExportAuditLogs(c, req, write)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		utils.Logf(c, "error exporting audit logs: %v", err)
+	}
+}
+
+// ListUpstreamCalls godoc
+// @Summary      Upstream calls of a request
+// @Description  Lists the calls to CIAM, RLP, CMS and ACS made while serving the request with the given request id, in the order they were made. The request id is on the request's audit log entry.
+// @Tags         admin
+// @Produce      json
+// @Param        request_id  query     string  true  "request id of the audit log entry"
+// @Success      200  {object}  responses.UpstreamCallsSuccessResponse  "upstream calls found"
+// @Failure      400  {object}  responses.ErrorResponse                 "invalid query parameters"
+// @Failure      401  {object}  responses.ErrorResponse                 "Unauthorized – API key missing or invalid"
+// @Failure      403  {object}  responses.ErrorResponse                 "access denied"
+// @Failure      500  {object}  responses.ErrorResponse                 "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /admin/audit/upstream-calls [get]
+func ListUpstreamCalls(c *gin.Context) {
+	var req requests.UpstreamCallQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return
+	}
+
+	calls, err := services.ListUpstreamCalls(c, req)
+	if err != nil {
+		utils.Logf(c, "error listing upstream calls: %v", err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.ApiResponse[[]model.UpstreamCallLog]{
+		Code:    codes.SUCCESSFUL,
+		Message: "upstream calls found",
+		Data:    calls,
+	})
+}
+
+var auditCsvHeader = []string{
+	"id", "created_at", "request_id", "actor_id", "method", "path", "request_path", "status_code",
+	"client_ip", "user_agent", "latency_ms", "request_body", "response_body",
+	"restored_from",
+}
+
+func auditCsvRecord(entry model.AuditLog) []string {
+	record := []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		entry.CreatedAt.Format(time.RFC3339Nano),
+		entry.RequestID,
+		entry.ActorID,
+		entry.Method,
+		entry.Path,
+		entry.RequestPath,
+		strconv.Itoa(entry.StatusCode),
+		entry.ClientIP,
+		entry.UserAgent,
+		strconv.FormatInt(entry.LatencyMs, 10),
+		entry.RequestBody,
+		entry.ResponseBody,
+		entry.RestoredFrom,
+	}
+	for i, field := range record {
+		record[i] = csvSafe(field)
+	}
+	return record
+}
+
+// csvSafe stops spreadsheets from evaluating caller supplied values such as the
+// user agent as formulas.
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+func bindAuditLogQuery(c *gin.Context) (requests.AuditLogQuery, bool) {
+	var req requests.AuditLogQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return req, false
+	}
+	return req, true
+}
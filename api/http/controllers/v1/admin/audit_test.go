@@ -0,0 +1,196 @@
+package admin_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lbe/api/http/controllers/v1/admin"
+	"lbe/api/http/responses"
+	"lbe/model"
+	"lbe/system"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func seedAuditLogs(t *testing.T, actor string, n int) []model.AuditLog {
+	t.Helper()
+
+	db := system.GetDb()
+	if db == nil {
+		t.Skip("database not configured")
+	}
+	assert.NoError(t, model.MigrateAuditLog(db))
+
+	entries := make([]model.AuditLog, n)
+	for i := range entries {
+		entries[i] = model.AuditLog{
+			ActorID:     actor,
+			Method:      http.MethodGet,
+			Path:        "/api/v1/user/:external_id",
+			RequestPath: fmt.Sprintf("/api/v1/user/2505230004%d", i),
+			StatusCode:  http.StatusOK,
+			LatencyMs:   int64(i * 100),
+		}
+	}
+	assert.NoError(t, db.Create(&entries).Error)
+	t.Cleanup(func() {
+		db.Where("actor_id = ?", actor).Delete(&model.AuditLog{})
+	})
+	return entries
+}
+
+func Test_SearchAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/audit/logs", admin.SearchAuditLogs)
+
+	actor := fmt.Sprintf("audit-test-%d", time.Now().UnixNano())
+	seeded := seedAuditLogs(t, actor, 5)
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedHTTPCode int
+		expectedIds      []uint
+	}{
+		{
+			name:             "SUCCESS - filter by request path",
+			query:            "actor=" + actor + "&request_path=" + seeded[3].RequestPath,
+			expectedHTTPCode: http.StatusOK,
+			expectedIds:      []uint{seeded[3].ID},
+		},
+		{
+			name:             "SUCCESS - latency threshold",
+			query:            "actor=" + actor + "&min_latency_ms=300",
+			expectedHTTPCode: http.StatusOK,
+			expectedIds:      []uint{seeded[4].ID, seeded[3].ID},
+		},
+		{
+			name:             "SUCCESS - no match",
+			query:            "actor=" + actor + "&status_code=500",
+			expectedHTTPCode: http.StatusOK,
+			expectedIds:      []uint{},
+		},
+		{
+			name:             "ERROR - Invalid cursor",
+			query:            "actor=" + actor + "&cursor=%25%25",
+			expectedHTTPCode: http.StatusBadRequest,
+		},
+		{
+			name:             "ERROR - Limit out of range",
+			query:            "limit=501",
+			expectedHTTPCode: http.StatusBadRequest,
+		},
+		{
+			name:             "ERROR - Inverted time range",
+			query:            "from=2025-06-01T00:00:00Z&to=2025-05-01T00:00:00Z",
+			expectedHTTPCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit/logs?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
+			if tt.expectedIds == nil {
+				return
+			}
+
+			var resp responses.ApiResponse[responses.AuditLogsResponseData]
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			ids := []uint{}
+			for _, entry := range resp.Data.Entries {
+				ids = append(ids, entry.ID)
+			}
+			assert.Equal(t, tt.expectedIds, ids)
+		})
+	}
+
+	t.Run("SUCCESS - cursor pagination walks every entry once", func(t *testing.T) {
+		var ids []uint
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit/logs?limit=2&actor="+actor+"&cursor="+cursor, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var resp responses.ApiResponse[responses.AuditLogsResponseData]
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			for _, entry := range resp.Data.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if cursor = resp.Data.NextCursor; cursor == "" {
+				break
+			}
+		}
+
+		assert.Equal(t, []uint{seeded[4].ID, seeded[3].ID, seeded[2].ID, seeded[1].ID, seeded[0].ID}, ids)
+	})
+}
+
+func Test_ExportAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/audit/logs/export", admin.ExportAuditLogs)
+
+	actor := fmt.Sprintf("audit-test-%d", time.Now().UnixNano())
+	seedAuditLogs(t, actor, 3)
+
+	tests := []struct {
+		name             string
+		format           string
+		expectedHTTPCode int
+		expectedType     string
+		expectedRows     int
+	}{
+		{name: "SUCCESS - csv", format: "csv", expectedHTTPCode: http.StatusOK, expectedType: "text/csv; charset=utf-8", expectedRows: 4},
+		{name: "SUCCESS - jsonl", format: "jsonl", expectedHTTPCode: http.StatusOK, expectedType: "application/x-ndjson", expectedRows: 3},
+		{name: "ERROR - Missing format", format: "", expectedHTTPCode: http.StatusBadRequest},
+		{name: "ERROR - Unknown format", format: "xml", expectedHTTPCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit/logs/export?actor="+actor+"&format="+tt.format, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
+			if tt.expectedHTTPCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedType, rec.Header().Get("Content-Type"))
+
+			switch tt.format {
+			case "csv":
+				records, err := csv.NewReader(rec.Body).ReadAll()
+				assert.NoError(t, err)
+				assert.Len(t, records, tt.expectedRows)
+			case "jsonl":
+				rows := 0
+				scanner := bufio.NewScanner(strings.NewReader(rec.Body.String()))
+				for scanner.Scan() {
+					var entry model.AuditLog
+					assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+					assert.Equal(t, actor, entry.ActorID)
+					rows++
+				}
+				assert.Equal(t, tt.expectedRows, rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package admin
+
+import "lbe/api/http/services"
+
+// Handler serves the admin endpoints that call upstreams, through the clients of app.
+type Handler struct {
+	app *services.App
+}
+
+func NewHandler(app *services.App) *Handler {
+	return &Handler{app: app}
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	mycache "lbe/cache"
+	"lbe/codes"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetryRegistration godoc
+// @Summary      Retry a stuck registration
+// @Description  Re-drives a failed or stuck registration attempt from its first unfinished step.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        rlp_id  path      string                          true  "RLP ID of the registration attempt"
+// @Success      200     {object}  responses.CreateSuccessResponse  "registration completed"
+// @Failure      401     {object}  responses.ErrorResponse          "Unauthorized – API key missing or invalid"
+// @Failure      403     {object}  responses.ErrorResponse          "access denied"
+// @Failure      409     {object}  responses.ErrorResponse          "registration attempt not found or not resumable, request in progress"
+// @Failure      500     {object}  responses.ErrorResponse          "Internal server error"
+// @Failure      503     {object}  responses.ErrorResponse          "Upstream service unavailable"
+// @Security     ApiKeyAuth
+// @Router       /admin/registrations/{rlp_id}/retry [post]
+func (h *Handler) RetryRegistration(c *gin.Context) {
+	rlpId := c.Param("rlp_id")
+
+	profileResp, err := services.ResumeRegistration(c, h.app, rlpId)
+	if err != nil {
+		utils.Logf(c, "Resume registration %s failed: %v", rlpId, err)
+
+		switch {
+		case errors.Is(err, services.ErrRegistrationAttemptNotFound):
+			c.JSON(http.StatusConflict, responses.RegistrationAttemptNotFoundErrorResponse())
+		case errors.Is(err, services.ErrRegistrationNotResumable):
+			c.JSON(http.StatusConflict, responses.RegistrationNotResumableErrorResponse())
+		case errors.Is(err, mycache.ErrLockNotObtained):
+			c.JSON(http.StatusConflict, responses.RequestInProgressErrorResponse())
+		default:
+			c.Error(err)
+		}
+		return
+	}
+
+	resp := responses.ApiResponse[responses.CreateUserResponseData]{
+		Code:    codes.SUCCESSFUL,
+		Message: "registration completed",
+		Data: responses.CreateUserResponseData{
+			User: profileResp.User.MapRlpToLbeUser(),
+		},
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,120 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lbe/api/http/controllers/v1/admin"
+	"lbe/api/http/middleware"
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services/fakes"
+	"lbe/codes"
+	"lbe/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func registrationAttempt(t *testing.T, rlpId, status string) model.RegistrationAttempt {
+	t.Helper()
+
+	payload, err := json.Marshal(requests.RegisterUser{
+		SignUpType: codes.SignUpTypeNew,
+		User:       model.User{Email: "retry@example.com", Tier: "Tier A"},
+	})
+	assert.NoError(t, err)
+
+	return model.RegistrationAttempt{
+		RlpID:      rlpId,
+		RlpNo:      "10000000001",
+		SignUpType: codes.SignUpTypeNew,
+		Payload:    string(payload),
+		Status:     status,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+func Test_RetryRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	const rlpId = "2505230001"
+
+	tests := []struct {
+		name                 string
+		rlpId                string
+		setupFakes           func(t *testing.T, u *fakes.Upstreams)
+		expectedHTTPCode     int
+		expectedResponseBody any
+	}{
+		{
+			name:  "SUCCESS - registration completed",
+			rlpId: rlpId,
+			setupFakes: func(t *testing.T, u *fakes.Upstreams) {
+				u.Registrations.Add(registrationAttempt(t, rlpId, model.RegistrationStatusPendingRetry))
+			},
+			expectedHTTPCode: http.StatusOK,
+		},
+		{
+			name:                 "CONFLICT - attempt not found",
+			rlpId:                "2505230002",
+			setupFakes:           func(t *testing.T, u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.RegistrationAttemptNotFoundErrorResponse(),
+		},
+		{
+			name:  "CONFLICT - attempt not resumable",
+			rlpId: rlpId,
+			setupFakes: func(t *testing.T, u *fakes.Upstreams) {
+				u.Registrations.Add(registrationAttempt(t, rlpId, model.RegistrationStatusRolledBack))
+			},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.RegistrationNotResumableErrorResponse(),
+		},
+		{
+			name:  "ERROR - RLP unavailable",
+			rlpId: rlpId,
+			setupFakes: func(t *testing.T, u *fakes.Upstreams) {
+				u.Registrations.Add(registrationAttempt(t, rlpId, model.RegistrationStatusPendingRetry))
+				u.Rlp.CreateErr = fakes.RlpError(http.StatusServiceUnavailable, "", "")
+			},
+			expectedHTTPCode:     http.StatusServiceUnavailable,
+			expectedResponseBody: responses.UpstreamUnavailableErrorResponse(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstreams := fakes.New()
+			tt.setupFakes(t, upstreams)
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/admin/registrations/:rlp_id/retry", admin.NewHandler(upstreams.App()).RetryRegistration)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/registrations/"+tt.rlpId+"/retry", nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
+
+			if tt.expectedResponseBody != nil {
+				expected, _ := json.Marshal(tt.expectedResponseBody)
+				assert.JSONEq(t, string(expected), rec.Body.String())
+				return
+			}
+
+			var resp responses.ApiResponse[responses.CreateUserResponseData]
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, codes.SUCCESSFUL, resp.Code)
+			attempt, _ := upstreams.Registrations.Attempt(rlpId)
+			assert.Equal(t, model.RegistrationStatusCompleted, attempt.Status)
+		})
+	}
+}
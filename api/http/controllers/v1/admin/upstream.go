@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"net/http"
+
+	"lbe/api/http/responses"
+	"lbe/codes"
+	"lbe/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUpstreamStates godoc
+// @Summary      Upstream circuit breakers
+// @Description  Reports the circuit breaker of every upstream (CIAM, RLP core, RLP offers, CMS, ACS, member service): closed, open while failing fast, or half_open while a probe call is out.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  responses.UpstreamStatesSuccessResponse  "upstream states"
+// @Failure      401  {object}  responses.ErrorResponse                  "Unauthorized – API key missing or invalid"
+// @Failure      403  {object}  responses.ErrorResponse                  "access denied"
+// @Security     ApiKeyAuth
+// @Router       /admin/upstreams [get]
+func GetUpstreamStates(c *gin.Context) {
+	c.JSON(http.StatusOK, responses.ApiResponse[[]upstream.BreakerState]{
+		Code:    codes.SUCCESSFUL,
+		Message: "upstream states",
+		Data:    upstream.States(),
+	})
+}
@@ -6,7 +6,7 @@ import (
 	"lbe/codes"
 	"lbe/model"
 	"lbe/system"
-	"log"
+	"lbe/utils"
 	"net/http"
 
 	"lbe/api/http/requests"
@@ -54,6 +54,7 @@ func AuthHandler(c *gin.Context) {
 	// Decode the JSON body.
 	var req requests.AuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
@@ -70,7 +71,7 @@ func AuthHandler(c *gin.Context) {
 	authReq, err := services.GenerateSignatureWithParams(appID, req.Nonce, req.Timestamp, secretKey)
 
 	if err != nil {
-		log.Printf("error encountered generating auth signature: %v", err)
+		utils.Logf(c, "error encountered generating auth signature: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
@@ -84,7 +85,7 @@ func AuthHandler(c *gin.Context) {
 	// Call the exported GenerateToken function from the middleware package.
 	token, err := interceptor.GenerateToken(appID)
 	if err != nil {
-		log.Printf("error encountered generating token: %v", err)
+		utils.Logf(c, "error encountered generating token: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
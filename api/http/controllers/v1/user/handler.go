@@ -0,0 +1,12 @@
+package user
+
+import "lbe/api/http/services"
+
+// Handler serves the user endpoints, calling upstreams through the clients of app.
+type Handler struct {
+	app *services.App
+}
+
+func NewHandler(app *services.App) *Handler {
+	return &Handler{app: app}
+}
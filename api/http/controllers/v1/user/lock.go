@@ -0,0 +1,37 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"lbe/api/http/responses"
+	mycache "lbe/cache"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lockUser takes the distributed lock on key, waiting for a concurrent request on
+// the same user to finish. When it cannot, the error response is written and false
+// returned; otherwise the caller must hand the lock to releaseUserLock.
+func (h *Handler) lockUser(c *gin.Context, key string) (*mycache.Lock, bool) {
+	lock, err := mycache.Obtain(c, h.app.Locker, key)
+	if err != nil {
+		if errors.Is(err, mycache.ErrLockNotObtained) {
+			c.JSON(http.StatusConflict, responses.RequestInProgressErrorResponse())
+			return nil, false
+		}
+		utils.Logf(c, "error obtaining lock %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return nil, false
+	}
+	return lock, true
+}
+
+func releaseUserLock(c *gin.Context, lock *mycache.Lock) {
+	// released even when the client went away, otherwise the key stays blocked until the ttl
+	if err := lock.Release(context.WithoutCancel(c.Request.Context())); err != nil {
+		utils.Logf(c, "error releasing lock %s (token %d): %v", lock.Key, lock.Token, err)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"lbe/api/http/services"
 	"lbe/codes"
 	"lbe/config"
-	"log"
+	"lbe/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -24,32 +24,33 @@ import (
 // @Failure      401      {object}  responses.ErrorResponse                             "Unauthorized – API key missing or invalid"
 // @Failure      409      {object}  responses.ErrorResponse                             "existing user not found"
 // @Failure      500      {object}  responses.ErrorResponse                     "Internal server error"
+// @Failure      503      {object}  responses.ErrorResponse                     "Upstream service unavailable"
+// @Failure      429      {object}  responses.ErrorResponse                      "otp resend cooldown or daily limit reached"
 // @Security     ApiKeyAuth
 // @Router       /user/login [post]
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 
 	var req requests.Login
 
 	// Bind the incoming JSON payload to the req struct.
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
 
-	respData, err := services.VerifyMemberExistence(req.Email, true)
+	respData, err := h.app.Member.VerifyMemberExistence(c, req.Email, true)
 	if err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
+		c.Error(err)
 		return
 	}
 
 	switch respData.Code {
 	case codes.FOUND:
-		otpService := services.NewOTPService()
-		otpResp, err := otpService.GenerateOTP(c, req.Email)
+		otpResp, err := h.app.Otp.GenerateOTP(c, codes.OtpPurposeLogin, req.Email)
 		if err != nil {
-			log.Printf("error encountered generating otp: %v", err)
-			c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+			abortOtpGenerationError(c, err)
 			return
 		}
 
@@ -62,7 +63,8 @@ func Login(c *gin.Context) {
 		cfg := config.GetConfig()
 		emailService := services.NewEmailService(&cfg.Smtp)
 		if err := emailService.SendOtpEmail(req.Email, emailData); err != nil {
-			log.Printf("failed to send email otp: %v", err)
+			utils.Logf(c, "failed to send email otp: %v", err)
+			h.releaseOtp(c, codes.OtpPurposeLogin, req.Email)
 			c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 			return
 		}
@@ -71,7 +73,7 @@ func Login(c *gin.Context) {
 			Code:    codes.SUCCESSFUL,
 			Message: "login successful",
 			Data: responses.LoginResponseData{
-				Otp:               otpResp,
+				Otp:               services.ResponseOTP(otpResp),
 				LoginSessionToken: respData.Data,
 			},
 		}
@@ -83,7 +85,7 @@ func Login(c *gin.Context) {
 		return
 
 	default:
-		log.Printf("error encountered getting login user: %v", respData.Message)
+		utils.Logf(c, "error encountered getting login user: %v", respData.Message)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
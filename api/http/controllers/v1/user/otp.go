@@ -0,0 +1,108 @@
+package user
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/codes"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyOtp godoc
+// @Summary      Verify OTP
+// @Description  Validates an OTP sent for registration or login and returns a short-lived verification token, required by /user/register for NEW and GR sign-ups.
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        request  body      requests.VerifyOtp                  true  "OTP verification payload"
+// @Success      200      {object}  responses.VerifyOtpSuccessResponse  "otp verified"
+// @Failure      400      {object}  responses.ErrorResponse             "Invalid JSON request body"
+// @Failure      401      {object}  responses.ErrorResponse             "Unauthorized – API key missing or invalid"
+// @Failure      409      {object}  responses.ErrorResponse             "invalid otp, otp not found or expired, or too many invalid attempts"
+// @Failure      500      {object}  responses.ErrorResponse             "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /user/otp/verify [post]
+func (h *Handler) VerifyOtp(c *gin.Context) {
+	var req requests.VerifyOtp
+
+	// Bind the incoming JSON payload.
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodySpecificErrorResponse(err.Error()))
+		return
+	}
+
+	validation, err := h.app.Otp.ValidateOTP(c, req.Purpose, req.Identifier, req.Otp)
+	if err != nil {
+		if errors.Is(err, services.ErrOtpNotFound) {
+			c.JSON(http.StatusConflict, responses.OtpNotFoundErrorResponse())
+			return
+		}
+		if errors.Is(err, services.ErrOtpAttemptsExceeded) {
+			c.JSON(http.StatusConflict, responses.OtpAttemptsExceededErrorResponse())
+			return
+		}
+		utils.Logf(c, "error encountered validating otp: %v", err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return
+	}
+	if !validation.Valid {
+		c.JSON(http.StatusConflict, responses.InvalidOtpErrorResponse(validation.RemainingAttempts))
+		return
+	}
+
+	verification, err := h.app.Otp.IssueVerificationToken(c, req.Purpose, req.Identifier)
+	if err != nil {
+		utils.Logf(c, "error encountered issuing verification token: %v", err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return
+	}
+
+	resp := responses.ApiResponse[responses.VerifyOtpResponseData]{
+		Code:    codes.SUCCESSFUL,
+		Message: "otp verified",
+		Data:    responses.VerifyOtpResponseData{OtpVerification: verification},
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// releaseOtp withdraws an OTP the member never received, so the failed delivery
+// does not count against the resend cooldown and the daily cap.
+func (h *Handler) releaseOtp(c *gin.Context, purpose, identifier string) {
+	if err := h.app.Otp.ReleaseOTP(c, purpose, identifier); err != nil {
+		utils.Logf(c, "failed to release otp: %v", err)
+	}
+}
+
+// abortOtpGenerationError writes the response for a failed GenerateOTP call.
+// Throttled sends are answered with 429 and a Retry-After header.
+func abortOtpGenerationError(c *gin.Context, err error) {
+	var limitErr *services.OtpLimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := int64(math.Ceil(limitErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+
+		if errors.Is(err, services.ErrOtpDailyLimitReached) {
+			c.JSON(http.StatusTooManyRequests, responses.OtpDailyLimitReachedErrorResponse(retryAfter))
+			return
+		}
+		c.JSON(http.StatusTooManyRequests, responses.OtpResendCooldownErrorResponse(retryAfter))
+		return
+	}
+
+	utils.Logf(c, "error encountered generating otp: %v", err)
+	c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+}
@@ -0,0 +1,125 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"lbe/api/http/controllers/v1/user"
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services/fakes"
+	"lbe/codes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VerifyOtp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	email := "otp.verify@example.com"
+
+	tests := []struct {
+		name                 string
+		requestBody          any
+		storedOtp            string
+		failedAttempts       int
+		expectedHTTPCode     int
+		expectedResponseBody any
+	}{
+		{
+			name:             "SUCCESS - otp verified",
+			requestBody:      requests.VerifyOtp{Purpose: codes.OtpPurposeRegistration, Identifier: email, Otp: "123456"},
+			storedOtp:        "123456",
+			expectedHTTPCode: http.StatusOK,
+		},
+		{
+			name:                 "CONFLICT - otp mismatch",
+			requestBody:          requests.VerifyOtp{Purpose: codes.OtpPurposeRegistration, Identifier: email, Otp: "654321"},
+			storedOtp:            "123456",
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.InvalidOtpErrorResponse(4),
+		},
+		{
+			name:                 "CONFLICT - otp attempts exceeded",
+			requestBody:          requests.VerifyOtp{Purpose: codes.OtpPurposeRegistration, Identifier: email, Otp: "654321"},
+			storedOtp:            "123456",
+			failedAttempts:       4,
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.OtpAttemptsExceededErrorResponse(),
+		},
+		{
+			name:                 "CONFLICT - otp not found",
+			requestBody:          requests.VerifyOtp{Purpose: codes.OtpPurposeRegistration, Identifier: email, Otp: "123456"},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.OtpNotFoundErrorResponse(),
+		},
+		{
+			name:                 "ERROR - Invalid purpose",
+			requestBody:          requests.VerifyOtp{Purpose: "UNKNOWN", Identifier: email, Otp: "123456"},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidRequestBodySpecificErrorResponse("invalid purpose provided"),
+		},
+		{
+			name:                 "ERROR - Invalid request body",
+			requestBody:          `{"purpose": "REGISTRATION"}`,
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstreams := fakes.New()
+			if tt.storedOtp != "" {
+				upstreams.Otp.Code = tt.storedOtp
+				_, err := upstreams.Otp.GenerateOTP(context.Background(), codes.OtpPurposeRegistration, email)
+				assert.NoError(t, err)
+			}
+			for i := 0; i < tt.failedAttempts; i++ {
+				_, err := upstreams.Otp.ValidateOTP(context.Background(), codes.OtpPurposeRegistration, email, "000000")
+				assert.NoError(t, err)
+			}
+
+			router := gin.New()
+			router.POST("/user/otp/verify", user.NewHandler(upstreams.App()).VerifyOtp)
+
+			var bodyBytes []byte
+			switch b := tt.requestBody.(type) {
+			case string:
+				bodyBytes = []byte(b)
+			default:
+				bodyBytes, _ = json.Marshal(b)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/user/otp/verify", bytes.NewBuffer(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
+
+			if tt.expectedResponseBody != nil {
+				assert.JSONEq(t, mustMarshal(tt.expectedResponseBody), rec.Body.String())
+				return
+			}
+
+			var resp responses.ApiResponse[responses.VerifyOtpResponseData]
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, codes.SUCCESSFUL, resp.Code)
+			assert.NotEmpty(t, resp.Data.VerificationToken)
+		})
+	}
+}
+
+func mustMarshal(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -1,15 +1,12 @@
 package user
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/api/http/services"
 	"lbe/codes"
-	"lbe/model"
 	"lbe/utils"
-	"log"
 	"net/http"
 	"time"
 
@@ -26,31 +23,71 @@ import (
 // @Success      200          {object}  responses.GetUserSuccessResponse       "user found"
 // @Failure      400          {object}  responses.ErrorResponse  "Invalid or missing external_id path parameter"
 // @Failure      401          {object}  responses.ErrorResponse                          "Unauthorized – API key missing or invalid"
-// @Failure      409          {object}  responses.ErrorResponse                       "existing user not found"
+// @Failure      409          {object}  responses.ErrorResponse                       "existing user not found, member suspended"
 // @Failure      500          {object}  responses.ErrorResponse              "Internal server error"
+// @Failure      503          {object}  responses.ErrorResponse              "Upstream service unavailable"
 // @Security     ApiKeyAuth
 // @Router       /user/{external_id} [get]
-func GetUserProfile(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) GetUserProfile(c *gin.Context) {
 	external_id := c.Param("external_id")
 
-	// TODO - RLP : Test Actual RLP End Points
-	profileResp, raw, err := services.GetProfile(c, httpClient, external_id)
+	h.respondUserProfile(c, external_id)
+}
+
+// LookupUserProfile godoc
+// @Summary      Look up user profile
+// @Description  Resolves a member identifier (rlp_id, rlp_no, gr_id, email or ciam_id) to the RLP_ID and retrieves the profile.
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        type   query     string                             true  "identifier type" Enums(rlp_id, rlp_no, gr_id, email, ciam_id)
+// @Param        value  query     string                             true  "identifier value"
+// @Success      200    {object}  responses.GetUserSuccessResponse   "user found"
+// @Failure      400    {object}  responses.ErrorResponse            "Invalid or missing query parameters"
+// @Failure      401    {object}  responses.ErrorResponse            "Unauthorized – API key missing or invalid"
+// @Failure      409    {object}  responses.ErrorResponse            "existing user not found, member suspended"
+// @Failure      500    {object}  responses.ErrorResponse            "Internal server error"
+// @Failure      503    {object}  responses.ErrorResponse            "Upstream service unavailable"
+// @Security     ApiKeyAuth
+// @Router       /user/lookup [get]
+func (h *Handler) LookupUserProfile(c *gin.Context) {
+	var req requests.LookupUser
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, responses.InvalidQueryParametersErrorResponse())
+		return
+	}
+
+	rlpId, err := services.ResolveRlpId(c, h.app, req.Type, req.Value)
 	if err != nil {
-		// Log the error
-		log.Printf("GET User Profile failed: %v", err)
-
-		var errResp responses.UserProfileErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Errors.Code == responses.RlpErrorCodeUserNotFound {
-				c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
-				return
-			}
+		if errors.Is(err, services.ErrMemberNotFound) {
+			c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
+			return
 		}
+		utils.Logf(c, "error encountered resolving %s to rlp id: %v", req.Type, err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
 
+	h.respondUserProfile(c, rlpId)
+}
+
+// respondUserProfile fetches the RLP profile of external_id and writes it as the response.
+func (h *Handler) respondUserProfile(c *gin.Context, external_id string) {
+	// TODO - RLP : Test Actual RLP End Points
+	profileResp, err := h.app.Rlp.GetProfile(c, external_id)
+	if err != nil {
+		// Log the error
+		utils.Logf(c, "GET User Profile failed: %v", err)
+		c.Error(err)
+		return
+	}
+
 	resp := responses.ApiResponse[*responses.GetUserProfileResponse]{
 		Code:    codes.SUCCESSFUL,
 		Message: "user found",
@@ -72,16 +109,17 @@ func GetUserProfile(c *gin.Context) {
 // @Success      200          {object}  responses.UpdateUserSuccessResponse      "Update successful"
 // @Failure      400          {object}  responses.ErrorResponse    "Invalid JSON request body"
 // @Failure      401          {object}  responses.ErrorResponse                         "Unauthorized – API key missing or invalid"
-// @Failure      409          {object}  responses.ErrorResponse                          "existing user not found"
+// @Failure      409          {object}  responses.ErrorResponse                          "existing user not found, member suspended"
 // @Failure      500          {object}  responses.ErrorResponse                "Internal server error"
+// @Failure      503          {object}  responses.ErrorResponse                "Upstream service unavailable"
 // @Security     ApiKeyAuth
 // @Router       /user/update/{external_id} [put]
-func UpdateUserProfile(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) UpdateUserProfile(c *gin.Context) {
 	var req requests.UpdateUserProfile
 	// Bind the incoming JSON payload to the user struct.
 	if err := c.ShouldBindJSON(&req); err != nil {
-		fmt.Println("BindJSON error:", err)
+		utils.Logf(c, "BindJSON error: %v", err)
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
@@ -92,19 +130,11 @@ func UpdateUserProfile(c *gin.Context) {
 	rlpUpdateUserReq := requests.UserProfileRequest{
 		User: req.User.MapLbeToRlpUser(),
 	}
-	profileResp, raw, err := services.UpdateProfile(c, httpClient, external_id, rlpUpdateUserReq)
+	profileResp, err := h.app.Rlp.UpdateProfile(c, external_id, rlpUpdateUserReq)
 	if err != nil {
 		// Log the error
-		log.Printf("Update User Profile failed: %v", err)
-
-		var errResp responses.UserProfileErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Errors.Code == responses.RlpErrorCodeUserNotFound {
-				c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		utils.Logf(c, "Update User Profile failed: %v", err)
+		c.Error(err)
 		return
 	}
 
@@ -132,7 +162,7 @@ func UpdateUserProfile(c *gin.Context) {
 // @Failure      500          {object}  responses.ErrorResponse             "Internal server error"
 // @Security     ApiKeyAuth
 // @Router       /user/pin [put]
-// func UpdateBurnPin(c *gin.Context) {
+// func (h *Handler) UpdateBurnPin(c *gin.Context) {
 // 	var req requests.UpdateBurnPin
 
 // 	// Bind the incoming JSON payload to the req struct.
@@ -141,7 +171,7 @@ func UpdateUserProfile(c *gin.Context) {
 // 		return
 // 	}
 
-// 	err := services.UpdateBurnPin(req)
+// 	err := h.app.Member.UpdateBurnPin(c, req)
 // 	if err != nil {
 // 		log.Printf("error encountered updating burn pin: %v", err)
 // 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
@@ -162,78 +192,52 @@ func UpdateUserProfile(c *gin.Context) {
 // @Success      200          {object}  responses.UpdateUserSuccessResponse      "Update successful"
 // @Failure      400          {object}  responses.ErrorResponse    "Invalid JSON request body"
 // @Failure      401          {object}  responses.ErrorResponse                         "Unauthorized – API key missing or invalid"
-// @Failure      409          {object}  responses.ErrorResponse                          "existing user not found"
+// @Failure      409          {object}  responses.ErrorResponse                          "existing user not found, member suspended, request in progress"
 // @Failure      500          {object}  responses.ErrorResponse                "Internal server error"
+// @Failure      503          {object}  responses.ErrorResponse                "Upstream service unavailable"
 // @Security     ApiKeyAuth
 // @Router       /user/archive/{external_id} [put]
-func WithdrawUserProfile(c *gin.Context) {
+func (h *Handler) WithdrawUserProfile(c *gin.Context) {
 	//TODO: add rollback
-	httpClient := utils.GetHttpClient(c.Request.Context())
 	external_id := c.Param("external_id")
 
+	lock, ok := h.lockUser(c, "withdraw:"+external_id)
+	if !ok {
+		return
+	}
+	defer releaseUserLock(c, lock)
+
 	// Retrieve user profile from RLP
-	rlpResp, raw, err := services.GetProfile(c, httpClient, external_id)
+	rlpResp, err := h.app.Rlp.GetProfile(c, external_id)
 	if err != nil {
 		// Log the error
-		log.Printf("GET User Profile failed: %v", err)
-
-		var errResp responses.UserProfileErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Errors.Code == responses.RlpErrorCodeUserNotFound {
-				c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		utils.Logf(c, "GET User Profile failed: %v", err)
+		c.Error(err)
 		return
 	}
 
 	// Retrieve CIAM id
 	ciamUserId := ""
 
-	if respData, _, err := services.GetCIAMUserByEmail(c, httpClient, rlpResp.User.Email); err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
+	if ciamUsers, err := h.app.Ciam.FindUsersByEmail(c, rlpResp.User.Email); err != nil {
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
-	} else if len(respData.Value) == 0 {
+	} else if len(ciamUsers) == 0 {
 		c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
 		return
 	} else {
-		ciamUserId = respData.Value[0].ID
+		ciamUserId = ciamUsers[0].ID
 	}
 
 	// Update user profile to withdraw status
-	rlpUserProfile := rlpResp.User
-	now := time.Now()
-	timestamp := now.Format("060102150405") // yyMMddHHmmss
+	rlpUpdateUserReq := requests.GenerateArchiveProfileRequest(rlpResp.User.Email, time.Now())
 
-	rlpUpdateUserReq := requests.UserProfileRequest{
-		User: model.RlpUserReq{
-			Email: fmt.Sprintf("%s.delete_%v", rlpUserProfile.Email, timestamp),
-			UserProfile: model.UserProfile{
-				ActiveStatus: "0",
-				MarketingPreference: model.MarketingPreference{
-					Push:   model.BoolPtr(false),
-					Email:  model.BoolPtr(false),
-					Mobile: model.BoolPtr(false),
-				},
-			},
-		},
-	}
-
-	profileResp, raw, err := services.UpdateProfile(c, httpClient, external_id, rlpUpdateUserReq)
+	profileResp, err := h.app.Rlp.UpdateProfile(c, external_id, rlpUpdateUserReq)
 	if err != nil {
 		// Log the error
-		log.Printf("Update User Profile to withdraw failed: %v", err)
-
-		var errResp responses.UserProfileErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Errors.Code == responses.RlpErrorCodeUserNotFound {
-				c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		utils.Logf(c, "Update User Profile to withdraw failed: %v", err)
+		c.Error(err)
 		return
 	}
 
@@ -241,9 +245,9 @@ func WithdrawUserProfile(c *gin.Context) {
 	ciamPayload := requests.GraphDisableAccountRequest{
 		AccountEnabled: false,
 	}
-	if _, err := services.PatchCIAMUpdateUser(c, httpClient, ciamUserId, ciamPayload); err != nil {
+	if err := h.app.Ciam.UpdateUser(c, ciamUserId, ciamPayload); err != nil {
 		// Log the error
-		log.Printf("Update CIAM User AccountEnabled to false failed: %v", err)
+		utils.Logf(c, "Update CIAM User AccountEnabled to false failed: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
@@ -259,9 +263,9 @@ func WithdrawUserProfile(c *gin.Context) {
 	}
 
 	//TODO: update template
-	if err := services.PostAcsSendEmailByTemplate(c, httpClient, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
-		log.Printf("failed to send withdrawal email: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+	if err := h.app.Acs.SendEmailByTemplate(c, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
+		utils.Logf(c, "failed to send withdrawal email: %v", err)
+		c.Error(err)
 		return
 	}
 
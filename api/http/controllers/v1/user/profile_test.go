@@ -3,101 +3,95 @@ package user_test
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"lbe/api/http/controllers/v1/user"
+	"lbe/api/http/middleware"
 	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/api/http/services"
-	"lbe/config"
+	"lbe/api/http/services/fakes"
+	"lbe/ciam"
 	"lbe/utils"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
 // LBE 9 Unit Test
 func Test_LBE_9_GetUserProfile(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.GET("/user/:external_id", user.GetUserProfile)
+	t.Parallel()
 
 	rlpGetProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_res.json")
-	rlpUserProfileNotFoundRes := utils.LoadTestData[responses.UserProfileErrorResponse]("rlp_user_not_found_err_res.json")
 	expectedRes := utils.LoadTestData[responses.ApiResponse[any]]("lbe9_getUser_res.json")
 
-	rlpProfileUrl := strings.ReplaceAll(services.ProfileURL, ":api_key", config.GetConfig().Api.Rlp.Core.ApiKey)
-
 	tests := []struct {
 		name                 string
 		externalId           string
-		requestBody          any
-		setupMocks           func()
+		setupFakes           func(u *fakes.Upstreams)
 		expectedHTTPCode     int
 		expectedResponseBody any
 	}{
 		{
 			name:       "SUCCESS - User found",
 			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.AddProfile("25052300047", rlpGetProfileRes)
 			},
 			expectedHTTPCode:     http.StatusOK,
 			expectedResponseBody: expectedRes,
 		},
 		{
-			name:       "CONFLICT - User not found",
+			name:                 "CONFLICT - User not found",
+			externalId:           "25052300047",
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
+		},
+		{
+			name:       "CONFLICT - Member suspended",
 			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(400).
-					JSON(rlpUserProfileNotFoundRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.GetErr = fakes.RlpError(http.StatusBadRequest, responses.RlpErrorCodeUserSuspended, "user is suspended")
 			},
 			expectedHTTPCode:     http.StatusConflict,
-			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
+			expectedResponseBody: responses.MemberSuspendedErrorResponse(),
 		},
 		{
 			name:       "ERROR - RLP Get user fail",
 			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.GetErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
+		{
+			name:       "UNAVAILABLE - RLP unavailable",
+			externalId: "25052300047",
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.GetErr = fakes.RlpError(http.StatusServiceUnavailable, "", "")
+			},
+			expectedHTTPCode:     http.StatusServiceUnavailable,
+			expectedResponseBody: responses.UpstreamUnavailableErrorResponse(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			// Setup mocks
-			tt.setupMocks()
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
 
-			var bodyBytes []byte
-			switch b := tt.requestBody.(type) {
-			case string:
-				bodyBytes = []byte(b)
-			default:
-				bodyBytes, _ = json.Marshal(b)
-			}
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/user/:external_id", user.NewHandler(upstreams.App()).GetUserProfile)
 
-			req := httptest.NewRequest(http.MethodGet, "/user/"+tt.externalId, bytes.NewBuffer(bodyBytes))
+			req := httptest.NewRequest(http.MethodGet, "/user/"+tt.externalId, nil)
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
@@ -105,42 +99,168 @@ func Test_LBE_9_GetUserProfile(t *testing.T) {
 
 			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
 
-			if rec.Code == http.StatusOK || rec.Code == http.StatusConflict {
-				var resp responses.ApiResponse[any]
-				err := json.Unmarshal(rec.Body.Bytes(), &resp)
-				assert.NoError(t, err)
+			var resp responses.ApiResponse[any]
+			err := json.Unmarshal(rec.Body.Bytes(), &resp)
+			assert.NoError(t, err)
 
-				if tt.expectedResponseBody != nil {
-					expected, _ := json.Marshal(tt.expectedResponseBody)
-					actual, _ := json.Marshal(resp)
-					assert.JSONEq(t, string(expected), string(actual))
-				}
-			}
+			expected, _ := json.Marshal(tt.expectedResponseBody)
+			actual, _ := json.Marshal(resp)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func Test_LookupUserProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	rlpGetProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_res.json")
+	expectedRes := utils.LoadTestData[responses.ApiResponse[any]]("lbe9_getUser_res.json")
+
+	addRlpProfile := func(u *fakes.Upstreams) {
+		u.Rlp.AddProfile("25052300047", rlpGetProfileRes)
+	}
+
+	tests := []struct {
+		name                 string
+		query                string
+		setupFakes           func(u *fakes.Upstreams)
+		expectedHTTPCode     int
+		expectedResponseBody any
+	}{
+		{
+			name:                 "SUCCESS - Lookup by rlp_id",
+			query:                "type=rlp_id&value=25052300047",
+			setupFakes:           addRlpProfile,
+			expectedHTTPCode:     http.StatusOK,
+			expectedResponseBody: expectedRes,
+		},
+		{
+			name:  "SUCCESS - Lookup by email",
+			query: "type=email&value=new@example.com",
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{
+					Mail:       "new@example.com",
+					UserIdLink: &ciam.UserIdLink{RlpId: "25052300047", RlpNo: "70000000047"},
+				})
+				addRlpProfile(u)
+			},
+			expectedHTTPCode:     http.StatusOK,
+			expectedResponseBody: expectedRes,
+		},
+		{
+			name:  "SUCCESS - Lookup by gr_id",
+			query: "type=gr_id&value=1234567",
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{
+					Mail:       "new@example.com",
+					UserIdLink: &ciam.UserIdLink{RlpId: "25052300047", RlpNo: "70000000047", GrId: "1234567"},
+				})
+				addRlpProfile(u)
+			},
+			expectedHTTPCode:     http.StatusOK,
+			expectedResponseBody: expectedRes,
+		},
+		{
+			name:  "CONFLICT - Email not linked to a member",
+			query: "type=email&value=unlinked@example.com",
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{Mail: "unlinked@example.com"})
+			},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
+		},
+		{
+			name:                 "CONFLICT - Unknown ciam_id",
+			query:                "type=ciam_id&value=03f1e0b8-df1a-4349-bbb7-2f641676f095",
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
+		},
+		{
+			name:  "ERROR - CIAM get user by email fail",
+			query: "type=email&value=new@example.com",
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.FindByEmailErr = fakes.ServerError()
+			},
+			expectedHTTPCode:     http.StatusInternalServerError,
+			expectedResponseBody: responses.InternalErrorResponse(),
+		},
+		{
+			name:                 "ERROR - Invalid type",
+			query:                "type=phone&value=12345678",
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidQueryParametersErrorResponse(),
+		},
+		{
+			name:                 "ERROR - Mistyped rlp_no check digit",
+			query:                "type=rlp_no&value=700000000014",
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidQueryParametersErrorResponse(),
+		},
+		{
+			name:                 "ERROR - ciam_id not a GUID",
+			query:                "type=ciam_id&value=" + url.QueryEscape("03f1e0b8-df1a-4349-bbb7-2f641676f095/memberOf"),
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidQueryParametersErrorResponse(),
+		},
+		{
+			name:                 "ERROR - Missing value",
+			query:                "type=rlp_no",
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidQueryParametersErrorResponse(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/user/lookup", user.NewHandler(upstreams.App()).LookupUserProfile)
+
+			req := httptest.NewRequest(http.MethodGet, "/user/lookup?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
+
+			var resp responses.ApiResponse[any]
+			err := json.Unmarshal(rec.Body.Bytes(), &resp)
+			assert.NoError(t, err)
+
+			expected, _ := json.Marshal(tt.expectedResponseBody)
+			actual, _ := json.Marshal(resp)
+			assert.JSONEq(t, string(expected), string(actual))
 		})
 	}
 }
 
 // LBE 10 Unit Test
 func Test_LBE_10_UpdateUserProfile(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.PUT("/user/update/:external_id", user.UpdateUserProfile)
+	t.Parallel()
 
+	rlpGetProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_res.json")
 	rlpUpdateProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_update_res.json")
-	rlpUserProfileNotFoundRes := utils.LoadTestData[responses.UserProfileErrorResponse]("rlp_user_not_found_err_res.json")
 
 	validSampleReq := utils.LoadTestData[requests.UpdateUserProfile]("lbe10_updateUser_req.json")
 	expectedRes := utils.LoadTestData[responses.ApiResponse[any]]("lbe10_updateUser_res.json")
 
-	rlpProfileUrl := strings.ReplaceAll(services.ProfileURL, ":api_key", config.GetConfig().Api.Rlp.Core.ApiKey)
-
 	tests := []struct {
 		name                 string
 		externalId           string
 		requestBody          any
-		setupMocks           func()
+		setupFakes           func(u *fakes.Upstreams)
 		expectedHTTPCode     int
 		expectedResponseBody any
 	}{
@@ -148,27 +268,18 @@ func Test_LBE_10_UpdateUserProfile(t *testing.T) {
 			name:        "SUCCESS - User updated",
 			externalId:  "25052300047",
 			requestBody: validSampleReq,
-			setupMocks: func() {
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.AddProfile("25052300047", rlpGetProfileRes)
+				u.Rlp.UpdateResult = &rlpUpdateProfileRes
 			},
 			expectedHTTPCode:     http.StatusOK,
 			expectedResponseBody: expectedRes,
 		},
 		{
-			name:        "CONFLICT - User not found",
-			externalId:  "25052300047",
-			requestBody: validSampleReq,
-			setupMocks: func() {
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(400).
-					JSON(rlpUserProfileNotFoundRes)
-			},
+			name:                 "CONFLICT - User not found",
+			externalId:           "25052300047",
+			requestBody:          validSampleReq,
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
 		},
@@ -176,20 +287,29 @@ func Test_LBE_10_UpdateUserProfile(t *testing.T) {
 			name:        "ERROR - RLP put user fail",
 			externalId:  "25052300047",
 			requestBody: validSampleReq,
-			setupMocks: func() {
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.AddProfile("25052300047", rlpGetProfileRes)
+				u.Rlp.UpdateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
+		{
+			name:        "BAD REQUEST - RLP rejects profile",
+			externalId:  "25052300047",
+			requestBody: validSampleReq,
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.AddProfile("25052300047", rlpGetProfileRes)
+				u.Rlp.UpdateErr = fakes.RlpError(http.StatusUnprocessableEntity, responses.RlpErrorCodeValidation, "invalid date of birth")
+			},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.UpstreamValidationErrorResponse(),
+		},
 		{
 			name:                 "ERROR - Invalid JSON ShouldBindJSON",
 			externalId:           "25052300047",
 			requestBody:          `{"user": "invalid-json}`, // malformed JSON (missing closing quote)
-			setupMocks:           func() {},                 // No mocks needed
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
@@ -197,10 +317,14 @@ func Test_LBE_10_UpdateUserProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			// Setup mocks
-			tt.setupMocks()
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/user/update/:external_id", user.NewHandler(upstreams.App()).UpdateUserProfile)
 
 			var bodyBytes []byte
 			switch b := tt.requestBody.(type) {
@@ -218,343 +342,119 @@ func Test_LBE_10_UpdateUserProfile(t *testing.T) {
 
 			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
 
-			if rec.Code == http.StatusOK || rec.Code == http.StatusConflict {
-				var resp responses.ApiResponse[any]
-				err := json.Unmarshal(rec.Body.Bytes(), &resp)
-				assert.NoError(t, err)
+			var resp responses.ApiResponse[any]
+			err := json.Unmarshal(rec.Body.Bytes(), &resp)
+			assert.NoError(t, err)
 
-				if tt.expectedResponseBody != nil {
-					expected, _ := json.Marshal(tt.expectedResponseBody)
-					actual, _ := json.Marshal(resp)
-					assert.JSONEq(t, string(expected), string(actual))
-				}
-			}
+			expected, _ := json.Marshal(tt.expectedResponseBody)
+			actual, _ := json.Marshal(resp)
+			assert.JSONEq(t, string(expected), string(actual))
 		})
 	}
 }
 
 // LBE 11 Unit Test
 func Test_LBE_11_WithdrawUserProfile(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.PUT("/user/archive/:external_id", user.WithdrawUserProfile)
+	t.Parallel()
 
 	rlpGetProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_res.json")
 	rlpUpdateProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_withdraw_res.json")
-	rlpUserProfileNotFoundRes := utils.LoadTestData[responses.UserProfileErrorResponse]("rlp_user_not_found_err_res.json")
-
-	ciamGetAuth := utils.LoadTestData[responses.TokenResponse]("ciam_getAuth_res.json")
-	ciamGetUserRes := utils.LoadTestData[responses.GraphUserCollection]("ciam_getUser_success_res.json")
 
 	expectedRes := utils.LoadTestData[responses.ApiResponse[any]]("lbe11_withdrawUser_res.json")
 
-	rlpProfileUrl := strings.ReplaceAll(services.ProfileURL, ":api_key", config.GetConfig().Api.Rlp.Core.ApiKey)
+	// each case withdraws its own member, the handler locks on the external id
+	addMember := func(u *fakes.Upstreams, externalId string) {
+		u.Rlp.AddProfile(externalId, rlpGetProfileRes)
+		u.Rlp.UpdateResult = &rlpUpdateProfileRes
+		u.Ciam.AddUser(ciam.User{Mail: rlpGetProfileRes.User.Email})
+	}
 
 	tests := []struct {
 		name                 string
 		externalId           string
-		setupMocks           func()
+		setupFakes           func(u *fakes.Upstreams, externalId string)
 		expectedHTTPCode     int
 		expectedResponseBody any
+		verify               func(t *testing.T, u *fakes.Upstreams)
 	}{
 		{
-			name:       "SUCCESS - User withdrawn",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(ciamGetUserRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM update user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamGetUserRes.Value[0].ID)).
-					Reply(204)
-
-				// Mock ACS auth
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock ACS email send
-				sendEndpoint := strings.ReplaceAll(
-					services.AcsSendEmailByTemplateURL,
-					":template_name", services.AcsEmailTemplateRequestOtp,
-				)
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(sendEndpoint).
-					Reply(200).
-					JSON(nil)
-			},
+			name:                 "SUCCESS - User withdrawn",
+			externalId:           "25052300111",
+			setupFakes:           addMember,
 			expectedHTTPCode:     http.StatusOK,
 			expectedResponseBody: expectedRes,
-		},
-		{
-			name:       "CONFLICT - RLP Get user not found",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(400).
-					JSON(rlpUserProfileNotFoundRes)
+			verify: func(t *testing.T, u *fakes.Upstreams) {
+				users := u.Ciam.Users()
+				if assert.Len(t, users, 1) && assert.NotNil(t, users[0].AccountEnabled) {
+					assert.False(t, *users[0].AccountEnabled, "ciam account disabled")
+				}
+				if sent := u.Acs.Sent(); assert.Len(t, sent, 1) {
+					assert.Equal(t, services.AcsEmailTemplateRequestOtp, sent[0].Template)
+				}
 			},
-			expectedHTTPCode:     http.StatusConflict,
-			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
 		},
 		{
-			name:       "CONFLICT - CIAM get user by email not found",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user DOES NOT exist
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(map[string]any{"value": []any{}})
-			},
+			name:                 "CONFLICT - RLP Get user not found",
+			externalId:           "25052300112",
+			setupFakes:           func(u *fakes.Upstreams, externalId string) {},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
 		},
 		{
-			name:       "CONFLICT - RLP update user profile not found",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(ciamGetUserRes)
-
-				// Mock RLP Put Profile not found
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(400).
-					JSON(rlpUserProfileNotFoundRes)
+			name:       "CONFLICT - CIAM get user by email not found",
+			externalId: "25052300113",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				u.Rlp.AddProfile(externalId, rlpGetProfileRes)
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
 		},
 		{
 			name:       "ERROR - RLP get user failed",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(500)
+			externalId: "25052300114",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				u.Rlp.GetErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
 		{
 			name:       "ERROR - CIAM get user by email failed",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists error
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(500)
+			externalId: "25052300115",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				addMember(u, externalId)
+				u.Ciam.FindByEmailErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
 		{
 			name:       "ERROR - RLP put profile withdraw failed",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(ciamGetUserRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM update user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamGetUserRes.Value[0].ID)).
-					Reply(500)
+			externalId: "25052300116",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				addMember(u, externalId)
+				u.Rlp.UpdateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
 		{
 			name:       "ERROR - CIAM update user withdraw failed",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(ciamGetUserRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(500)
+			externalId: "25052300117",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				addMember(u, externalId)
+				u.Ciam.UpdateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
 		{
 			name:       "ERROR - ACS send email failed",
-			externalId: "25052300047",
-			setupMocks: func() {
-				// Mock RLP Get Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Get(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpGetProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(rlpGetProfileRes.User.Email)).
-					Reply(200).
-					JSON(ciamGetUserRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(rlpProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM update user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamGetUserRes.Value[0].ID)).
-					Reply(204)
-
-				// Mock ACS auth
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock ACS email send
-				sendEndpoint := strings.ReplaceAll(
-					services.AcsSendEmailByTemplateURL,
-					":template_name", services.AcsEmailTemplateRequestOtp, //TODO: update proper impl
-				)
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(sendEndpoint).
-					Reply(500).
-					JSON(nil)
+			externalId: "25052300118",
+			setupFakes: func(u *fakes.Upstreams, externalId string) {
+				addMember(u, externalId)
+				u.Acs.Err = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -563,10 +463,14 @@ func Test_LBE_11_WithdrawUserProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
+
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams, tt.externalId)
 
-			// Setup mocks
-			tt.setupMocks()
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/user/archive/:external_id", user.NewHandler(upstreams.App()).WithdrawUserProfile)
 
 			req := httptest.NewRequest(http.MethodPut, "/user/archive/"+tt.externalId, nil)
 			req.Header.Set("Content-Type", "application/json")
@@ -576,16 +480,16 @@ func Test_LBE_11_WithdrawUserProfile(t *testing.T) {
 
 			assert.Equal(t, tt.expectedHTTPCode, rec.Code)
 
-			if rec.Code == http.StatusOK || rec.Code == http.StatusConflict {
-				var resp responses.ApiResponse[any]
-				err := json.Unmarshal(rec.Body.Bytes(), &resp)
-				assert.NoError(t, err)
+			var resp responses.ApiResponse[any]
+			err := json.Unmarshal(rec.Body.Bytes(), &resp)
+			assert.NoError(t, err)
 
-				if tt.expectedResponseBody != nil {
-					expected, _ := json.Marshal(tt.expectedResponseBody)
-					actual, _ := json.Marshal(resp)
-					assert.JSONEq(t, string(expected), string(actual))
-				}
+			expected, _ := json.Marshal(tt.expectedResponseBody)
+			actual, _ := json.Marshal(resp)
+			assert.JSONEq(t, string(expected), string(actual))
+
+			if tt.verify != nil {
+				tt.verify(t, upstreams)
 			}
 		})
 	}
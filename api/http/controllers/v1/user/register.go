@@ -1,9 +1,9 @@
 package user
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,10 +12,11 @@ import (
 	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/api/http/services"
+	"lbe/ciam"
 	"lbe/codes"
 	"lbe/config"
 	"lbe/model"
-	"lbe/system"
+	"lbe/saga"
 	"lbe/utils"
 
 	"github.com/gin-gonic/gin"
@@ -34,35 +35,35 @@ import (
 // @Failure      401      {object}  responses.ErrorResponse                       "Unauthorized – API key missing or invalid"
 // @Failure      409      {object}  responses.ErrorResponse                      "existing user found"
 // @Failure      500      {object}  responses.ErrorResponse               "Internal server error"
+// @Failure      503      {object}  responses.ErrorResponse               "Upstream service unavailable"
+// @Failure      429      {object}  responses.ErrorResponse                      "otp resend cooldown or daily limit reached"
 // @Security     ApiKeyAuth
 // @Router       /user/register/verify [post]
-func VerifyUserExistence(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) VerifyUserExistence(c *gin.Context) {
 	var req requests.VerifyUserExistence
 
 	// Bind the incoming JSON payload.
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
 
-	if respData, _, err := services.GetCIAMUserByEmail(c, httpClient, req.Email); err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
+	if ciamUsers, err := h.app.Ciam.FindUsersByEmail(c, req.Email); err != nil {
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
-	} else if len(respData.Value) != 0 {
+	} else if len(ciamUsers) != 0 {
 		c.JSON(http.StatusConflict, responses.ExistingUserFoundErrorResponse())
 		return
 	}
 
-	log.Printf("user %s not found, generating otp", req.Email)
+	utils.Logf(c, "user %s not found, generating otp", req.Email)
 
 	// if user is not found, generate OTP
-	otpService := services.NewOTPService()
-	otpResp, err := otpService.GenerateOTP(c, req.Email)
+	otpResp, err := h.app.Otp.GenerateOTP(c, codes.OtpPurposeRegistration, req.Email)
 	if err != nil {
-		log.Printf("error encountered generating otp: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		abortOtpGenerationError(c, err)
 		return
 	}
 
@@ -76,16 +77,17 @@ func VerifyUserExistence(c *gin.Context) {
 		},
 	}
 
-	if err := services.PostAcsSendEmailByTemplate(c, httpClient, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
-		log.Printf("failed to send email otp: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+	if err := h.app.Acs.SendEmailByTemplate(c, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
+		utils.Logf(c, "failed to send email otp: %v", err)
+		h.releaseOtp(c, codes.OtpPurposeRegistration, req.Email)
+		c.Error(err)
 		return
 	}
 
 	resp := responses.ApiResponse[model.Otp]{
 		Code:    codes.SUCCESSFUL,
 		Message: "existing user not found",
-		Data:    otpResp,
+		Data:    services.ResponseOTP(otpResp),
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -100,32 +102,56 @@ func VerifyUserExistence(c *gin.Context) {
 // @Success      201      {object}  responses.CreateSuccessResponse  "User created successfully"
 // @Failure      400      {object}  responses.ErrorResponse  "Invalid JSON request body"
 // @Failure      401      {object}  responses.ErrorResponse                      "Unauthorized – API key missing or invalid"
+// @Failure      409      {object}  responses.ErrorResponse                      "invalid verification token, existing user found, request in progress"
 // @Failure      500      {object}  responses.ErrorResponse              "Internal server error"
+// @Failure      503      {object}  responses.ErrorResponse              "Upstream service unavailable"
 // @Security     ApiKeyAuth
 // @Router       /user/register [post]
-func CreateUser(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) CreateUser(c *gin.Context) {
 	var req requests.RegisterUser
 	// Bind the incoming JSON payload to the user struct.
 	if err := c.ShouldBindJSON(&req); err != nil {
-		fmt.Println("BindJSON error:", err)
+		utils.Logf(c, "BindJSON error: %v", err)
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		log.Println(err.Error())
+		utils.Logf(c, "%v", err)
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodySpecificErrorResponse(err.Error()))
 		return
 	}
 
+	// NEW and GR sign-ups must prove the email or GR id with a verified otp
+	if purpose, ok := codes.RegistrationOtpPurpose(req.SignUpType); ok {
+		verification, err := h.app.Otp.GetVerification(c, req.VerificationToken)
+		if err != nil {
+			if errors.Is(err, services.ErrVerificationTokenNotFound) {
+				c.JSON(http.StatusConflict, responses.InvalidVerificationTokenErrorResponse())
+				return
+			}
+			utils.Logf(c, "error encountered getting verification token: %v", err)
+			c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+			return
+		}
+
+		if verification.Purpose != purpose || verification.SignUpType != req.SignUpType ||
+			!strings.EqualFold(verification.Identifier, req.VerifiedIdentifier()) {
+			utils.Logf(c, "verification token issued for %s %s, registering %s", verification.Purpose, verification.Identifier, req.VerifiedIdentifier())
+			c.JSON(http.StatusConflict, responses.InvalidVerificationTokenErrorResponse())
+			return
+		}
+	}
+
 	switch req.SignUpType {
 	case codes.SignUpTypeNew: // only need to match tier
 
 	case codes.SignUpTypeGRCMS:
-		cachedProfile, err := system.ObjectGet(req.RegId, &model.User{})
+		cachedProfile, err := h.app.GrProfiles.Get(c, req.RegId)
 		if err != nil {
-			log.Printf("error getting cache value: %v", err)
+			utils.Logf(c, "error getting cache value: %v", err)
 			c.JSON(http.StatusConflict, responses.CachedProfileNotFoundErrorResponse())
 			return
 		}
@@ -140,114 +166,46 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// match tier (assuming "X" format for class)
-	if err := assignTier(&req.User, req.SignUpType); err != nil {
+	if err := assignTier(c, &req.User, req.SignUpType); err != nil {
 		// only gr member will throw error during assign
 		c.JSON(http.StatusConflict, responses.InvalidGrMemberClassErrorResponse())
 		return
 	}
 
-	newRlpNumbering, newRlpNumberingErr := utils.GenerateNextRLPUserNumberingWithRetry()
+	// concurrent sign-ups of the same email, possibly on other replicas, run one after another
+	lock, ok := h.lockUser(c, services.RegistrationLockKey(req.User.Email))
+	if !ok {
+		return
+	}
+	defer releaseUserLock(c, lock)
+
+	newRlpNumbering, newRlpNumberingErr := h.app.Numbering.Next(c)
 	if newRlpNumberingErr != nil {
-		log.Printf("Generate RLP User Number failed: %v", newRlpNumberingErr)
+		utils.Logf(c, "Generate RLP User Number failed: %v", newRlpNumberingErr)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
 	}
 
-	log.Printf("RLP User Number generated: %v", newRlpNumbering)
+	utils.Logf(c, "RLP User Number generated: %v", newRlpNumbering)
 
 	// populate registrations defaults
 	req.User.PopulateIdentifiers(newRlpNumbering.RLP_ID, newRlpNumbering.RLP_NO)
 
-	rlpUserModel := req.User.MapLbeToRlpUser()
-	rlpUserModel.PopulateRegistrationDefaults(newRlpNumbering.RLP_ID)
-
-	//TODO: add rollback mechanism
-	// Create CIAM User
-	if respData, raw, err := services.PostCIAMRegisterUser(c, httpClient, requests.GenerateInitialRegistrationRequest(&req.User)); err != nil {
-		// Log the error
-		log.Printf("CIAM Register User failed: %v", err)
-
-		var errResp responses.GraphApiErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Error.Message == responses.CiamUserAlreadyExists {
-				c.JSON(http.StatusConflict, responses.ExistingUserFoundErrorResponse())
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
-		return
-	} else {
-		// add schema extensions
-		grID := ""
-		if req.User.GrProfile != nil {
-			grID = req.User.GrProfile.Id
-		}
-
-		schemaExtensionsPayload := map[string]any{
-			config.GetConfig().Api.Eeid.UserIdLinkExtensionKey: requests.UserIdLinkSchemaExtensionFields{
-				RlpId: newRlpNumbering.RLP_ID,
-				RlpNo: newRlpNumbering.RLP_NO,
-				GrId:  grID,
-			},
-		}
-
-		if _, err := services.PatchCIAMAddUserSchemaExtensions(c, httpClient, respData.Id, schemaExtensionsPayload); err != nil {
-			log.Printf("CIAM Patch User Schema Extensions failed: %v", err)
-			c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
-			return
-		}
-	}
-
-	// Initial User Creation in RLP
-	rlpIntialUserCreationReq := requests.UserProfileRequest{
-		User: model.RlpUserReq{
-			ExternalID:     newRlpNumbering.RLP_ID,
-			ExternalIDType: "RLP_ID",
-		},
-	}
-	_, _, err := services.CreateProfile(c, httpClient, rlpIntialUserCreationReq)
+	// Create CIAM user and RLP profile, resumable or rolled back on failure
+	profileResp, err := services.RegisterUser(c, h.app, &req, newRlpNumbering)
 	if err != nil {
 		// Log the error
-		log.Printf("RLP Intitial Register User failed: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
-		return
-	}
+		utils.Logf(c, "Register User failed: %v", err)
 
-	// Update User Details in RLP
-	rlpUserUpdateReq := requests.UserProfileRequest{
-		User: rlpUserModel,
-	}
-	profileResp, raw, err := services.UpdateProfile(c, httpClient, newRlpNumbering.RLP_ID, rlpUserUpdateReq)
-	if err != nil {
-		// Log the error
-		log.Printf("RLP Update Register User failed: %v", err)
-
-		var errResp responses.UserProfileErrorResponse
-		if err := json.Unmarshal(raw, &errResp); err == nil {
-			if errResp.Errors.Code == responses.RlpErrorCodeUserNotFound {
-				c.JSON(http.StatusConflict, responses.ExistingUserNotFoundErrorResponse())
-				return
-			}
+		var stepErr *saga.StepError
+		if errors.As(err, &stepErr) && stepErr.Step == model.RegistrationStepCiamCreate && errors.Is(err, ciam.ErrObjectConflict) {
+			c.JSON(http.StatusConflict, responses.ExistingUserFoundErrorResponse())
+			return
 		}
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		c.Error(err)
 		return
 	}
 
-	// RLP: Request User Tier update
-	userTierReq := requests.UserTierUpdateEventRequest{
-		EventLookup: services.GetUserTierEventName(req.User.Tier),
-		UserId:      newRlpNumbering.RLP_ID,
-		RetailerID:  config.GetConfig().Api.Rlp.RetailerID,
-	}
-
-	if _, _, err := services.UpdateUserTier(c, httpClient, userTierReq); err != nil {
-		log.Printf("RLP Update User Tier failed: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
-		return
-	} else {
-		profileResp.User.Tier = req.User.Tier // update tier for response dto
-	}
-
 	resp := responses.ApiResponse[responses.CreateUserResponseData]{
 		Code:    codes.SUCCESSFUL,
 		Message: "user created",
@@ -259,7 +217,16 @@ func CreateUser(c *gin.Context) {
 
 	// purge regId cache if used
 	if req.SignUpType == codes.SignUpTypeGRCMS {
-		system.ObjectDelete(req.RegId)
+		if err := h.app.GrProfiles.Delete(c, req.RegId); err != nil {
+			utils.Logf(c, "failed to purge cached gr profile: %v", err)
+		}
+	}
+
+	// verification token is single use
+	if req.VerificationToken != "" {
+		if err := h.app.Otp.RevokeVerificationToken(c, req.VerificationToken); err != nil {
+			utils.Logf(c, "failed to revoke verification token: %v", err)
+		}
 	}
 }
 
@@ -273,76 +240,79 @@ func CreateUser(c *gin.Context) {
 // @Success      200      {object}  responses.GrExistenceSuccessResponse  "gr profile found"
 // @Failure      400      {object}  responses.ErrorResponse                     "Invalid JSON request body"
 // @Failure      401      {object}  responses.ErrorResponse                                       "Unauthorized – API key missing or invalid"
+// @Failure      409      {object}  responses.ErrorResponse                      "gr profile already linked or gr member not found"
 // @Failure      500      {object}  responses.ErrorResponse                            "Internal server error"
+// @Failure      503      {object}  responses.ErrorResponse                            "Upstream service unavailable"
+// @Failure      429      {object}  responses.ErrorResponse                      "otp resend cooldown or daily limit reached"
 // @Security     ApiKeyAuth
 // @Router       /user/gr [post]
-func VerifyGrExistence(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) VerifyGrExistence(c *gin.Context) {
 	var req requests.VerifyGrUser
 
 	// Bind the incoming JSON payload.
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
 
 	if err := req.Validate(); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodySpecificErrorResponse(err.Error()))
 		return
 	}
 
 	// verify if gr ID is unused
-	if respData, _, err := services.GetCIAMUserByGrId(c, httpClient, req.User.GrProfile.Id); err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
+	if ciamUsers, err := h.app.Ciam.FindUsersByGrId(c, req.User.GrProfile.Id); err != nil {
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
-	} else if len(respData.Value) != 0 {
+	} else if len(ciamUsers) != 0 {
 		c.JSON(http.StatusConflict, responses.GrMemberIdLinkedErrorResponse())
 		return
 	}
 
-	//TODO: add conflict response if cms member not found
-	cmsMember, err := services.GRMemberProfile(req.User.GrProfile.Id, nil, "GET", services.GetMemberURL)
-	if err != nil {
+	cmsMember, err := h.app.Cms.GRMemberProfile(c, req.User.GrProfile.Id)
+	if errors.Is(err, services.ErrGrMemberNotFound) {
+		c.JSON(http.StatusConflict, responses.GrMemberNotFoundErrorResponse())
+		return
+	} else if err != nil {
 		// Log the error
-		log.Printf("Error while getting GR Member: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		utils.Logf(c, "Error while getting GR Member: %v", err)
+		c.Error(err)
 		return
 	}
 
 	// generate OTP
-	otpService := services.NewOTPService()
-	otpResp, err := otpService.GenerateOTP(c, cmsMember.EmailAddress)
+	otpResp, err := h.app.Otp.GenerateOTP(c, codes.OtpPurposeGrRegistration, req.User.GrProfile.Id)
 	if err != nil {
-		log.Printf("error encountered generating otp: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		abortOtpGenerationError(c, err)
 		return
 	}
 
-	// send email otp via acs if gr member email consent = true
-	if cmsMember.ContactOptionEmail {
-		acsRequest := requests.AcsSendEmailByTemplateRequest{
-			Email:   cmsMember.EmailAddress,
-			Subject: services.AcsEmailSubjectRequestOtp,
-			Data: requests.RequestEmailOtpTemplateData{
-				Email: cmsMember.EmailAddress,
-				Otp:   *otpResp.Otp,
-			},
-		}
+	// send email otp via acs, the otp is transactional and goes out whatever the
+	// member's marketing email consent
+	acsRequest := requests.AcsSendEmailByTemplateRequest{
+		Email:   cmsMember.EmailAddress,
+		Subject: services.AcsEmailSubjectRequestOtp,
+		Data: requests.RequestEmailOtpTemplateData{
+			Email: cmsMember.EmailAddress,
+			Otp:   *otpResp.Otp,
+		},
+	}
 
-		httpClient := utils.GetHttpClient(c.Request.Context())
-		if err := services.PostAcsSendEmailByTemplate(c, httpClient, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
-			log.Printf("failed to send email otp: %v", err)
-			c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
-			return
-		}
+	if err := h.app.Acs.SendEmailByTemplate(c, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
+		utils.Logf(c, "failed to send email otp: %v", err)
+		h.releaseOtp(c, codes.OtpPurposeGrRegistration, req.User.GrProfile.Id)
+		c.Error(err)
+		return
 	}
 
 	// return response from CMS
 	resp := responses.ApiResponse[responses.VerifyGrUserResponseData]{
 		Code:    codes.SUCCESSFUL,
 		Message: "gr profile found",
-		Data:    responses.VerifyGrUserResponseData{User: cmsMember.MapCmsProfileToLbeUser(), Otp: otpResp},
+		Data:    responses.VerifyGrUserResponseData{User: cmsMember.MapCmsProfileToLbeUser(), Otp: services.ResponseOTP(otpResp)},
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -359,45 +329,51 @@ func VerifyGrExistence(c *gin.Context) {
 // @Failure      401      {object}  responses.ErrorResponse                      "Unauthorized – API key missing or invalid"
 // @Failure      409      {object}  responses.ErrorResponse                      "Email already registered"
 // @Failure      500      {object}  responses.ErrorResponse               "Internal server error"
+// @Failure      503      {object}  responses.ErrorResponse               "Upstream service unavailable"
 // @Security     ApiKeyAuth
 // @Router       /user/gr-cms [post]
-func VerifyGrCmsExistence(c *gin.Context) {
-	httpClient := utils.GetHttpClient(c.Request.Context())
+func (h *Handler) VerifyGrCmsExistence(c *gin.Context) {
 	var req requests.VerifyGrCmsUser
 
 	// Bind the incoming JSON payload.
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
 		return
 	}
 
 	if err := req.Validate(); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusBadRequest, responses.InvalidRequestBodySpecificErrorResponse(err.Error()))
 		return
 	}
 
-	if respData, _, err := services.GetCIAMUserByEmail(c, httpClient, req.User.Email); err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
+	if ciamUsers, err := h.app.Ciam.FindUsersByEmail(c, req.User.Email); err != nil {
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
-	} else if len(respData.Value) != 0 {
+	} else if len(ciamUsers) != 0 {
 		c.JSON(http.StatusConflict, responses.ExistingUserFoundErrorResponse())
 		return
 	}
 
 	// verify if gr ID is unused
-	if respData, _, err := services.GetCIAMUserByGrId(c, httpClient, req.User.GrProfile.Id); err != nil {
-		log.Printf("error encountered verifying user existence: %v", err)
+	if ciamUsers, err := h.app.Ciam.FindUsersByGrId(c, req.User.GrProfile.Id); err != nil {
+		utils.Logf(c, "error encountered verifying user existence: %v", err)
 		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
 		return
-	} else if len(respData.Value) != 0 {
+	} else if len(ciamUsers) != 0 {
 		c.JSON(http.StatusConflict, responses.GrMemberIdLinkedErrorResponse())
 		return
 	}
 
 	// TODO - Generate reg_id and cache gr member info within expiry timestamp
 	regId := uuid.New()
-	system.ObjectSet(regId.String(), req.User, 30*time.Minute)
+	if err := h.app.GrProfiles.Set(c, regId.String(), req.User, 30*time.Minute); err != nil {
+		utils.Logf(c, "failed to cache gr profile: %v", err)
+		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+		return
+	}
 
 	// generate url
 
@@ -414,9 +390,9 @@ func VerifyGrCmsExistence(c *gin.Context) {
 	}
 
 	//TODO: update template
-	if err := services.PostAcsSendEmailByTemplate(c, httpClient, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
-		log.Printf("failed to send registration url email: %v", err)
-		c.JSON(http.StatusInternalServerError, responses.InternalErrorResponse())
+	if err := h.app.Acs.SendEmailByTemplate(c, services.AcsEmailTemplateRequestOtp, acsRequest); err != nil {
+		utils.Logf(c, "failed to send registration url email: %v", err)
+		c.Error(err)
 		return
 	}
 
@@ -437,13 +413,13 @@ func VerifyGrCmsExistence(c *gin.Context) {
 // @Failure      500      {object}  responses.ErrorResponse                   "Internal server error"
 // @Security     ApiKeyAuth
 // @Router       /user/gr-reg/{reg_id} [get]
-func GetCachedGrCmsProfile(c *gin.Context) {
+func (h *Handler) GetCachedGrCmsProfile(c *gin.Context) {
 
 	regId := c.Param("reg_id")
 
-	cachedUserProfile, err := system.ObjectGet(regId, &model.User{})
+	cachedUserProfile, err := h.app.GrProfiles.Get(c, regId)
 	if err != nil {
-		log.Printf("error getting cache value: %v", err)
+		utils.Logf(c, "error getting cache value: %v", err)
 		resp := responses.ApiResponse[any]{
 			Code:    codes.CACHED_PROFILE_NOT_FOUND,
 			Message: "cached profile not found",
@@ -464,12 +440,12 @@ func GetCachedGrCmsProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func assignTier(user *model.User, signUpType string) error {
+func assignTier(ctx context.Context, user *model.User, signUpType string) error {
 	user.Tier = "Tier A"
 	if signUpType == codes.SignUpTypeGRCMS || signUpType == codes.SignUpTypeGR {
 		tier, err := GrTierMatching(user.GrProfile.Class)
 		if err != nil {
-			log.Printf("error matching gr class to member tier: %v", err)
+			utils.Logf(ctx, "error matching gr class to member tier: %v", err)
 			return err
 		}
 		user.Tier = tier
@@ -2,26 +2,25 @@ package user_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"lbe/api/http/controllers/v1/user"
+	"lbe/api/http/middleware"
 	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/api/http/services"
+	"lbe/api/http/services/fakes"
+	"lbe/ciam"
 	"lbe/codes"
-	"lbe/config"
 	"lbe/model"
-	"lbe/system"
 	"lbe/utils"
 	"log"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/h2non/gock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,54 +69,22 @@ func TestGrTierMatching(t *testing.T) {
 
 // LBE 3 Unit Test
 func Test_LBE_3_VerifyUserExistence(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/user/register/verify", user.VerifyUserExistence)
+	t.Parallel()
 
 	tests := []struct {
 		name                    string
 		requestBody             any
-		setupMocks              func(email string)
+		setupFakes              func(u *fakes.Upstreams)
 		expectedHTTPCode        int
 		expectedResponseCode    int64
 		expectedResponseMessage string
 		expectedResponseBody    any
 	}{
 		{
-			name:        "SUCCESS - User not found",
-			requestBody: requests.VerifyUserExistence{Email: "newuser@example.com"},
-			setupMocks: func(email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user not found
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(map[string]any{"value": []any{}})
-
-				// Mock ACS auth
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock ACS email send
-				sendEndpoint := strings.ReplaceAll(
-					services.AcsSendEmailByTemplateURL,
-					":template_name", services.AcsEmailTemplateRequestOtp,
-				)
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(sendEndpoint).
-					Reply(200).
-					JSON(nil)
-			},
+			name:                    "SUCCESS - User not found",
+			requestBody:             requests.VerifyUserExistence{Email: "newuser@example.com"},
+			setupFakes:              func(u *fakes.Upstreams) {},
 			expectedHTTPCode:        http.StatusOK,
 			expectedResponseCode:    codes.SUCCESSFUL,
 			expectedResponseMessage: "existing user not found",
@@ -125,21 +92,8 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 		{
 			name:        "CONFLICT - User already exists",
 			requestBody: requests.VerifyUserExistence{Email: "existing@example.com"},
-			setupMocks: func(email string) {
-				// CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// CIAM user exists
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(map[string]any{
-						"value": []map[string]any{{"id": "abc123"}},
-					})
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{Mail: "existing@example.com"})
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserFoundErrorResponse(),
@@ -147,11 +101,8 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 		{
 			name:        "ERROR - CIAM get user by email fail",
 			requestBody: requests.VerifyUserExistence{Email: "existing@example.com"},
-			setupMocks: func(email string) {
-				// CIAM auth error
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.FindByEmailErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -159,24 +110,8 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 		{
 			name:        "ERROR - ACS send email fail",
 			requestBody: requests.VerifyUserExistence{Email: "newuser@example.com"},
-			setupMocks: func(email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user not found
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(map[string]any{"value": []any{}})
-
-				// Mock ACS auth error
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Acs.Err = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -185,7 +120,7 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 		{
 			name:                 "ERROR - Invalid request body",
 			requestBody:          nil, // raw string invalid body
-			setupMocks:           func(email string) {},
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
@@ -193,12 +128,14 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			// Setup mocks
-			if req, ok := tt.requestBody.(requests.VerifyUserExistence); ok {
-				tt.setupMocks(req.Email)
-			}
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/user/register/verify", user.NewHandler(upstreams.App()).VerifyUserExistence)
 
 			var bodyBytes []byte
 			switch b := tt.requestBody.(type) {
@@ -230,28 +167,34 @@ func Test_LBE_3_VerifyUserExistence(t *testing.T) {
 					assert.Equal(t, tt.expectedResponseMessage, resp.Message)
 				}
 			}
+
+			if rec.Code == http.StatusOK {
+				verifyReq := tt.requestBody.(requests.VerifyUserExistence)
+				otp, ok := upstreams.Otp.Issued(codes.OtpPurposeRegistration, verifyReq.Email)
+				assert.True(t, ok, "otp issued")
+				if sent := upstreams.Acs.Sent(); assert.Len(t, sent, 1) {
+					assert.Equal(t, requests.RequestEmailOtpTemplateData{Email: verifyReq.Email, Otp: otp},
+						sent[0].Payload.(requests.AcsSendEmailByTemplateRequest).Data)
+				}
+			}
+
+			if upstreams.Acs.Err != nil {
+				verifyReq := tt.requestBody.(requests.VerifyUserExistence)
+				_, ok := upstreams.Otp.Issued(codes.OtpPurposeRegistration, verifyReq.Email)
+				assert.False(t, ok, "undelivered otp released")
+			}
 		})
 	}
 }
 
 // LBE 4 Unit Test
 func Test_LBE_4_CreateUser(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/register", user.CreateUser)
+	t.Parallel()
 
-	rlpCreateProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_create_profile_res.json")
 	rlpUpdateProfileRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_res.json")
 	rlpUpdateProfileGrRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_GR_res.json")
 	rlpUpdateProfileTmRes := utils.LoadTestData[responses.GetUserResponse]("rlp_update_profile_TM_res.json")
-	rlpUserProfileNotFoundRes := utils.LoadTestData[responses.UserProfileErrorResponse]("rlp_user_not_found_err_res.json")
-	rlpUpdateUserTierEventRes := utils.LoadTestData[any]("rlp_updateUserTier_event_res.json")
-
-	ciamGetAuth := utils.LoadTestData[responses.TokenResponse]("ciam_getAuth_res.json")
-	ciamRegisterUserRes := utils.LoadTestData[responses.GraphCreateUserResponse]("ciam_createUser_success_res.json")
-	ciamRegisterUserErrorRes := utils.LoadTestData[responses.GraphApiErrorResponse]("ciam_createUser_error_res.json")
 
 	validSampleReqNew := utils.LoadTestData[requests.RegisterUser]("lbe4_createUser_NEW_req.json")
 	validSampleReqGrCms := utils.LoadTestData[requests.RegisterUser]("lbe4_createUser_GRCMS_req.json")
@@ -263,109 +206,48 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 	expectedResGr := utils.LoadTestData[responses.ApiResponse[any]]("lbe4_createUser_GR_res.json")
 	expectedResTm := utils.LoadTestData[responses.ApiResponse[any]]("lbe4_createUser_TM_res.json")
 
-	createRlpUserProfileUrl := strings.ReplaceAll(services.CreateProfileURL, ":api_key", config.GetConfig().Api.Rlp.Core.ApiKey)
-	rlpProfileUrl := strings.ReplaceAll(services.ProfileURL, ":api_key", config.GetConfig().Api.Rlp.Core.ApiKey)
-	updateRlpUserProfileUrl := fmt.Sprintf("%s/.+", rlpProfileUrl)
+	// the verification tokens the sample requests carry
+	verifications := map[string]services.OtpVerificationRecord{
+		validSampleReqNew.VerificationToken:  {Purpose: codes.OtpPurposeRegistration, Identifier: validSampleReqNew.User.Email, SignUpType: codes.SignUpTypeNew},
+		validSampleReqGr.VerificationToken:   {Purpose: codes.OtpPurposeGrRegistration, Identifier: validSampleReqGr.User.GrProfile.Id, SignUpType: codes.SignUpTypeGR},
+		invalidSampleReqGr.VerificationToken: {Purpose: codes.OtpPurposeGrRegistration, Identifier: invalidSampleReqGr.User.GrProfile.Id, SignUpType: codes.SignUpTypeGR},
+		"lbe4-other-verification-token":      {Purpose: codes.OtpPurposeRegistration, Identifier: "other@example.com", SignUpType: codes.SignUpTypeNew},
+	}
 
 	tests := []struct {
 		name                 string
 		requestBody          any
-		setupMocks           func(grId, email string)
+		setupFakes           func(u *fakes.Upstreams)
 		expectedHTTPCode     int
 		expectedResponseBody any
+		verify               func(t *testing.T, u *fakes.Upstreams)
 	}{
 		{
 			name:        "SUCCESS - NEW user registration",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
-
-				// Mock RLP Update User Tier Event
-				gock.New(config.GetConfig().Api.Rlp.Offers.Host).
-					Post(services.EventUrl).
-					Reply(200).
-					JSON(rlpUpdateUserTierEventRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateResult = &rlpUpdateProfileRes
 			},
 			expectedHTTPCode:     http.StatusCreated,
 			expectedResponseBody: expectedResNew,
+			verify: func(t *testing.T, u *fakes.Upstreams) {
+				users := u.Ciam.Users()
+				if assert.Len(t, users, 1) && assert.NotNil(t, users[0].UserIdLink) {
+					assert.Equal(t, validSampleReqNew.User.Email, users[0].Mail)
+					_, ok := u.Rlp.Profile(users[0].UserIdLink.RlpId)
+					assert.True(t, ok, "rlp profile created for the linked rlp id")
+				}
+				assert.Len(t, u.Rlp.TierEvents(), 1)
+
+				_, ok := u.Otp.Verification(validSampleReqNew.VerificationToken)
+				assert.False(t, ok, "verification token revoked")
+			},
 		},
 		{
 			name:        "SUCCESS - GR CMS user registration",
 			requestBody: validSampleReqGrCms,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileGrRes)
-
-				// Mock RLP Update User Tier Event
-				gock.New(config.GetConfig().Api.Rlp.Offers.Host).
-					Post(services.EventUrl).
-					Reply(200).
-					JSON(rlpUpdateUserTierEventRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateResult = &rlpUpdateProfileGrRes
 			},
 			expectedHTTPCode:     http.StatusCreated,
 			expectedResponseBody: expectedResGr,
@@ -373,47 +255,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "SUCCESS - GR user registration",
 			requestBody: validSampleReqGr,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileGrRes)
-
-				// Mock RLP Update User Tier Event
-				gock.New(config.GetConfig().Api.Rlp.Offers.Host).
-					Post(services.EventUrl).
-					Reply(200).
-					JSON(rlpUpdateUserTierEventRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateResult = &rlpUpdateProfileGrRes
 			},
 			expectedHTTPCode:     http.StatusCreated,
 			expectedResponseBody: expectedResGr,
@@ -421,47 +264,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "SUCCESS - TM user registration",
 			requestBody: validSampleReqTm,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileTmRes)
-
-				// Mock RLP Update User Tier Event
-				gock.New(config.GetConfig().Api.Rlp.Offers.Host).
-					Post(services.EventUrl).
-					Reply(200).
-					JSON(rlpUpdateUserTierEventRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateResult = &rlpUpdateProfileTmRes
 			},
 			expectedHTTPCode:     http.StatusCreated,
 			expectedResponseBody: expectedResTm,
@@ -472,33 +276,55 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 				SignUpType: codes.SignUpTypeGRCMS,
 				RegId:      "0000",
 			},
-			setupMocks:           func(grId, email string) {},
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.CachedProfileNotFoundErrorResponse(),
 		},
 		{
-			name:        "CONFLICT - Invalid GR Class",
-			requestBody: invalidSampleReqGr,
-			setupMocks: func(grId, email string) {
-			},
+			name: "CONFLICT - Verification token issued for another email",
+			requestBody: func() requests.RegisterUser {
+				req := validSampleReqNew
+				req.VerificationToken = "lbe4-other-verification-token"
+				return req
+			}(),
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.InvalidVerificationTokenErrorResponse(),
+		},
+		{
+			name: "CONFLICT - Verification token expired",
+			requestBody: func() requests.RegisterUser {
+				req := validSampleReqNew
+				req.VerificationToken = "lbe4-expired-verification-token"
+				return req
+			}(),
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.InvalidVerificationTokenErrorResponse(),
+		},
+		{
+			name: "ERROR - Missing verification token",
+			requestBody: func() requests.RegisterUser {
+				req := validSampleReqNew
+				req.VerificationToken = ""
+				return req
+			}(),
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusBadRequest,
+			expectedResponseBody: responses.InvalidRequestBodySpecificErrorResponse("verification_token is required"),
+		},
+		{
+			name:                 "CONFLICT - Invalid GR Class",
+			requestBody:          invalidSampleReqGr,
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.InvalidGrMemberClassErrorResponse(),
 		},
 		{
 			name:        "CONFLICT - CIAM user already exists",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user error due to existing user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(400).
-					JSON(ciamRegisterUserErrorRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{Mail: validSampleReqNew.User.Email})
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserFoundErrorResponse(),
@@ -506,41 +332,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "CONFLICT - RLP Update Profile user not found",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile user does not exist
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(400).
-					JSON(rlpUserProfileNotFoundRes)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateErr = fakes.RlpUserNotFound()
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserNotFoundErrorResponse(),
@@ -548,17 +341,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "ERROR - CIAM register user fail",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user error
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.CreateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -566,29 +350,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "ERROR - CIAM patch user schema extensions fail",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions error
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.LinkErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -596,34 +359,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "ERROR - RLP Create initial user profile fail",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.CreateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -631,40 +368,8 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:        "ERROR - RLP Update profile fail",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile fail
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -672,46 +377,9 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{ //TODO: add rollback check
 			name:        "ERROR - RLP update user tier fail",
 			requestBody: validSampleReqNew,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM create user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Post(services.CiamUserURL).
-					Reply(201).
-					JSON(ciamRegisterUserRes)
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(ciamGetAuth)
-
-				// Mock CIAM add schema extensions
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Patch(fmt.Sprintf("%s/%s", services.CiamUserURL, ciamRegisterUserRes.Id)).
-					Reply(204)
-
-				// Mock RLP Initial User Creation
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Post(createRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpCreateProfileRes)
-
-				// Mock RLP Put Profile
-				gock.New(config.GetConfig().Api.Rlp.Core.Host).
-					Put(updateRlpUserProfileUrl).
-					Reply(200).
-					JSON(rlpUpdateProfileRes)
-
-				// Mock RLP Update User Tier Event Fail
-				gock.New(config.GetConfig().Api.Rlp.Offers.Host).
-					Post(services.EventUrl).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Rlp.UpdateResult = &rlpUpdateProfileRes
+				u.Rlp.TierErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -719,14 +387,14 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 		{
 			name:                 "ERROR - Invalid request body",
 			requestBody:          `{}`,
-			setupMocks:           func(email, grId string) {}, // No mock needed
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodySpecificErrorResponse("invalid sign_up_type provided"),
 		},
 		{
 			name:                 "ERROR - Invalid JSON ShouldBindJSON",
-			requestBody:          `{"user": "invalid-json}`,   // malformed JSON (missing closing quote)
-			setupMocks:           func(email, grId string) {}, // No mocks needed
+			requestBody:          `{"user": "invalid-json}`, // malformed JSON (missing closing quote)
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
@@ -734,23 +402,20 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			//setup cache
-			system.ObjectSet(validSampleReqGrCms.RegId, validSampleReqGr.User, 30*time.Minute)
-			defer system.ObjectDelete(validSampleReqGrCms.RegId)
-
-			var grId, email string
-			if req, ok := tt.requestBody.(requests.RegisterUser); ok {
-				if req.SignUpType == codes.SignUpTypeGR {
-					if req.User.GrProfile != nil {
-						grId = req.User.GrProfile.Id
-					}
-				}
-				email = req.User.Email
+			upstreams := fakes.New()
+			for token, verification := range verifications {
+				upstreams.Otp.AddVerification(token, verification)
 			}
+			tt.setupFakes(upstreams)
 
-			tt.setupMocks(grId, email)
+			//setup cache, read only by the GR CMS case
+			upstreams.GrProfiles.Set(context.Background(), validSampleReqGrCms.RegId, validSampleReqGr.User, 30*time.Minute)
+
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/register", user.NewHandler(upstreams.App()).CreateUser)
 
 			var bodyBytes []byte
 			switch b := tt.requestBody.(type) {
@@ -779,95 +444,61 @@ func Test_LBE_4_CreateUser(t *testing.T) {
 					assert.JSONEq(t, string(expected), string(actual))
 				}
 			}
+
+			if tt.verify != nil {
+				tt.verify(t, upstreams)
+			}
 		})
 	}
 }
 
 // LBE 6 Unit Test
 func Test_LBE_6_VerifyGrExistence(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/user/gr", user.VerifyGrExistence)
+	t.Parallel()
 
 	validSampleReq := utils.LoadTestData[requests.VerifyGrUser]("lbe6_verifyGrExistence_req.json")
+	grId := validSampleReq.User.GrProfile.Id
+
+	addCmsMember := func(u *fakes.Upstreams) {
+		u.Cms.AddMember(grId, responses.GRProfilePayload{
+			EmailAddress:       "new@example.com",
+			ContactOptionEmail: true,
+		})
+	}
 
 	tests := []struct {
 		name                    string
 		requestBody             any
-		setupMocks              func(grId, email string)
+		setupFakes              func(u *fakes.Upstreams)
 		expectedHTTPCode        int
 		expectedResponseCode    int64
 		expectedResponseMessage string
 		expectedResponseBody    any
 	}{
 		{
-			name:        "SUCCESS - GR ID not found",
+			name:                    "SUCCESS - GR ID not found",
+			requestBody:             validSampleReq,
+			setupFakes:              addCmsMember,
+			expectedHTTPCode:        http.StatusOK,
+			expectedResponseCode:    codes.SUCCESSFUL,
+			expectedResponseMessage: "gr profile found",
+		},
+		{
+			name:        "SUCCESS - otp sent without marketing email consent",
 			requestBody: validSampleReq,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				// CMS member fetch
-				gock.New(config.GetConfig().Api.Cms.Host).
-					Get(services.GetMemberURL).
-					MatchParam("systemId", config.GetConfig().Api.Cms.SystemID).
-					MatchParam("memberId", grId).
-					Reply(200).
-					JSON(responses.GRProfilePayload{
-						EmailAddress:       email,
-						ContactOptionEmail: true,
-					})
-
-				// Mock ACS auth
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock ACS email send
-				sendEndpoint := strings.ReplaceAll(
-					services.AcsSendEmailByTemplateURL,
-					":template_name", services.AcsEmailTemplateRequestOtp,
-				)
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(sendEndpoint).
-					Reply(200).
-					JSON(nil)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Cms.AddMember(grId, responses.GRProfilePayload{EmailAddress: "new@example.com"})
 			},
 			expectedHTTPCode:        http.StatusOK,
 			expectedResponseCode:    codes.SUCCESSFUL,
-			expectedResponseMessage: "gr profile found", //TODO: use correct response - need to fix otp impl to be mockable
+			expectedResponseMessage: "gr profile found",
 		},
 		{
 			name:        "CONFLICT - GR ID already linked",
 			requestBody: validSampleReq,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// CIAM returns existing user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{
-						Value: []responses.GraphUser{{ID: "existing-user"}},
-					})
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{ID: "existing-user", UserIdLink: &ciam.UserIdLink{GrId: grId}})
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.GrMemberIdLinkedErrorResponse(),
@@ -875,11 +506,8 @@ func Test_LBE_6_VerifyGrExistence(t *testing.T) {
 		{
 			name:        "ERROR - CIAM get user by grId fail",
 			requestBody: validSampleReq,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.FindByGrIdErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -887,62 +515,25 @@ func Test_LBE_6_VerifyGrExistence(t *testing.T) {
 		{
 			name:        "ERROR - CMS profile fetch",
 			requestBody: validSampleReq,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				// CMS member fetch error
-				gock.New(config.GetConfig().Api.Cms.Host).
-					Get(services.GetMemberURL).
-					MatchParam("systemId", config.GetConfig().Api.Cms.SystemID).
-					MatchParam("memberId", grId).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Cms.Err = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
 		},
+		{
+			name:                 "CONFLICT - GR member not found in CMS",
+			requestBody:          validSampleReq,
+			setupFakes:           func(u *fakes.Upstreams) {},
+			expectedHTTPCode:     http.StatusConflict,
+			expectedResponseBody: responses.GrMemberNotFoundErrorResponse(),
+		},
 		{
 			name:        "ERROR - ACS send email fail",
 			requestBody: validSampleReq,
-			setupMocks: func(grId, email string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				// CMS member fetch
-				gock.New(config.GetConfig().Api.Cms.Host).
-					Get(services.GetMemberURL).
-					MatchParam("systemId", config.GetConfig().Api.Cms.SystemID).
-					MatchParam("memberId", grId).
-					Reply(200).
-					JSON(responses.GRProfilePayload{
-						EmailAddress:       email,
-						ContactOptionEmail: true,
-					})
-
-				// Mock ACS auth error
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				addCmsMember(u)
+				u.Acs.Err = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -950,14 +541,14 @@ func Test_LBE_6_VerifyGrExistence(t *testing.T) {
 		{
 			name:                 "ERROR - Invalid request body",
 			requestBody:          `{}`,
-			setupMocks:           func(email, grId string) {}, // No mock needed
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
 		{
 			name:                 "ERROR - Invalid JSON ShouldBindJSON",
-			requestBody:          `{"user": "invalid-json}`,   // malformed JSON (missing closing quote)
-			setupMocks:           func(email, grId string) {}, // No mocks needed
+			requestBody:          `{"user": "invalid-json}`, // malformed JSON (missing closing quote)
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
@@ -965,15 +556,14 @@ func Test_LBE_6_VerifyGrExistence(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			var grId, email string
-			if req, ok := tt.requestBody.(requests.VerifyGrUser); ok {
-				grId = req.User.GrProfile.Id
-				email = req.User.Email
-			}
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
 
-			tt.setupMocks(grId, email)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/user/gr", user.NewHandler(upstreams.App()).VerifyGrExistence)
 
 			var bodyBytes []byte
 			switch b := tt.requestBody.(type) {
@@ -1005,76 +595,41 @@ func Test_LBE_6_VerifyGrExistence(t *testing.T) {
 					assert.Equal(t, tt.expectedResponseMessage, resp.Message)
 				}
 			}
+
+			if rec.Code == http.StatusOK {
+				_, ok := upstreams.Otp.Issued(codes.OtpPurposeGrRegistration, grId)
+				assert.True(t, ok, "otp issued")
+				assert.Len(t, upstreams.Acs.Sent(), 1)
+			}
+
+			if upstreams.Acs.Err != nil {
+				_, ok := upstreams.Otp.Issued(codes.OtpPurposeGrRegistration, grId)
+				assert.False(t, ok, "undelivered otp released")
+			}
 		})
 	}
 }
 
 // LBE 7 Unit Test
 func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
-	defer gock.Off()
-
 	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.POST("/user/gr-cms", user.VerifyGrCmsExistence)
+	t.Parallel()
 
 	validSampleReq := utils.LoadTestData[requests.VerifyGrCmsUser]("lbe7_verifyGrCmsExistence_req.json")
 
 	tests := []struct {
 		name                    string
 		requestBody             any
-		setupMocks              func(email, grId string)
+		setupFakes              func(u *fakes.Upstreams)
 		expectedHTTPCode        int
 		expectedResponseCode    int64
 		expectedResponseMessage string
 		expectedResponseBody    any
 	}{
 		{
-			name:        "SUCCESS - User and GR ID not found",
-			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by email returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-					// Mock ACS auth
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock ACS email send //TODO: update to correct template
-				sendEndpoint := strings.ReplaceAll(
-					services.AcsSendEmailByTemplateURL,
-					":template_name", services.AcsEmailTemplateRequestOtp,
-				)
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(sendEndpoint).
-					Reply(200).
-					JSON(nil)
-			},
+			name:                    "SUCCESS - User and GR ID not found",
+			requestBody:             validSampleReq,
+			setupFakes:              func(u *fakes.Upstreams) {},
 			expectedHTTPCode:        http.StatusOK,
 			expectedResponseCode:    codes.SUCCESSFUL,
 			expectedResponseMessage: "existing user not found",
@@ -1082,23 +637,8 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:        "CONFLICT - Existing email found",
 			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by email returning found user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{
-						Value: []responses.GraphUser{
-							{ID: "abc123"},
-						},
-					})
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{Mail: validSampleReq.User.Email})
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.ExistingUserFoundErrorResponse(),
@@ -1106,36 +646,8 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:        "CONFLICT - Existing GR ID found",
 			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by email returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns a found user
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{
-						Value: []responses.GraphUser{
-							{ID: "abc123"},
-						},
-					})
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.AddUser(ciam.User{Mail: "other@example.com", UserIdLink: &ciam.UserIdLink{GrId: validSampleReq.User.GrProfile.Id}})
 			},
 			expectedHTTPCode:     http.StatusConflict,
 			expectedResponseBody: responses.GrMemberIdLinkedErrorResponse(),
@@ -1143,12 +655,8 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:        "ERROR - CIAM get user by email fail",
 			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-
-				// Mock CIAM auth error
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.FindByEmailErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -1156,31 +664,8 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:        "ERROR - CIAM get user by grId fail",
 			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by email returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID error
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Ciam.FindByGrIdErr = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -1188,38 +673,8 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:        "ERROR - ACS send email fail",
 			requestBody: validSampleReq,
-			setupMocks: func(email, grId string) {
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by email returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamEmailFilter(email)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-				// Mock CIAM auth
-				gock.New(config.GetConfig().Api.Eeid.AuthHost).
-					Post(fmt.Sprintf("/%s%s", config.GetConfig().Api.Eeid.TenantID, services.CiamAuthURL)).
-					Reply(200).
-					JSON(responses.AcsAuthResponseData{AccessToken: "mockToken"})
-
-				// Mock CIAM user by GR ID returns empty
-				gock.New(config.GetConfig().Api.Eeid.Host).
-					Get(services.CiamUserURL).
-					MatchParam("$filter", utils.BuildCiamGrIdFilter(grId)).
-					Reply(200).
-					JSON(responses.GraphUserCollection{})
-
-					// Mock ACS auth error
-				gock.New(config.GetConfig().Api.Acs.Host).
-					Post(services.AcsAuthURL).
-					Reply(500)
+			setupFakes: func(u *fakes.Upstreams) {
+				u.Acs.Err = fakes.ServerError()
 			},
 			expectedHTTPCode:     http.StatusInternalServerError,
 			expectedResponseBody: responses.InternalErrorResponse(),
@@ -1227,14 +682,14 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 		{
 			name:                 "ERROR - Invalid request body",
 			requestBody:          nil, // raw string invalid body
-			setupMocks:           func(email, grId string) {},
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
 		{
 			name:                 "ERROR - Invalid JSON ShouldBindJSON",
-			requestBody:          `{"user": "invalid-json}`,   // malformed JSON (missing closing quote)
-			setupMocks:           func(email, grId string) {}, // No mocks needed
+			requestBody:          `{"user": "invalid-json}`, // malformed JSON (missing closing quote)
+			setupFakes:           func(u *fakes.Upstreams) {},
 			expectedHTTPCode:     http.StatusBadRequest,
 			expectedResponseBody: responses.InvalidRequestBodyErrorResponse(),
 		},
@@ -1242,17 +697,14 @@ func Test_LBE_7_VerifyGrCmsExistence(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer gock.Off()
+			t.Parallel()
 
-			var email, grId string
-			if req, ok := tt.requestBody.(requests.VerifyGrCmsUser); ok {
-				email = req.User.Email
-				if req.User.GrProfile != nil {
-					grId = req.User.GrProfile.Id
-				}
-			}
+			upstreams := fakes.New()
+			tt.setupFakes(upstreams)
 
-			tt.setupMocks(email, grId)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/user/gr-cms", user.NewHandler(upstreams.App()).VerifyGrCmsExistence)
 
 			var bodyBytes []byte
 			switch b := tt.requestBody.(type) {
@@ -1334,11 +786,12 @@ func Test_LBE_8_GetCachedGrCmsProfile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			//setup cache
-			system.ObjectSet(cachedRegId, cachedUser, 30*time.Minute)
-			defer system.ObjectDelete(cachedRegId)
+			upstreams := fakes.New()
+			upstreams.GrProfiles.Set(context.Background(), cachedRegId, *cachedUser, 30*time.Minute)
 
 			router := gin.New()
-			router.GET("/gr-cms/:reg_id", user.GetCachedGrCmsProfile)
+			router.Use(middleware.ErrorHandler())
+			router.GET("/gr-cms/:reg_id", user.NewHandler(upstreams.App()).GetCachedGrCmsProfile)
 
 			req := httptest.NewRequest(http.MethodGet, "/gr-cms/"+tt.regId, nil)
 			rec := httptest.NewRecorder()
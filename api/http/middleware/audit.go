@@ -6,52 +6,74 @@ import (
 	"log"
 	"time"
 
+	"lbe/audit"
+	"lbe/config"
 	"lbe/model"
+	"lbe/utils"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// bodyLogWriter wraps gin.ResponseWriter and captures the response body.
+// bodyLogWriter wraps gin.ResponseWriter and captures the response body up to limit bytes.
 type bodyLogWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body  *bytes.Buffer
+	limit int
+	size  int
 }
 
 func (w *bodyLogWriter) Write(data []byte) (int, error) {
-	// write into our buffer
-	w.body.Write(data)
+	w.capture(data)
 	// write out to the real ResponseWriter
 	return w.ResponseWriter.Write(data)
 }
 
 // if your handlers use WriteString:
 func (w *bodyLogWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
-func AuditLogger(db *gorm.DB) gin.HandlerFunc {
+func (w *bodyLogWriter) capture(data []byte) {
+	w.size += len(data)
+	// an oversized body is not stored anyway, stop buffering it
+	if w.size <= w.limit {
+		w.body.Write(data)
+	}
+}
+
+// AuditLogger stores every request in the audit log. Bodies are redacted following
+// application.audit: the built-in rules cover OTPs, PINs, tokens and personal
+// data, configuration adds global and per-route rules and caps the captured size.
+// Entries are handed to writer, which persists them in batches, with the request
+// id set by RequestID that the upstream calls of the request are logged with too.
+func AuditLogger(writer *audit.Writer[*model.AuditLog]) gin.HandlerFunc {
+	redaction, err := newAuditRedaction(config.GetConfig().Application.Audit)
+	if err != nil {
+		log.Fatalf("audit redaction config: %v", err)
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 
 		// capture request body
-		var reqBody string
+		var reqBuf []byte
 		if c.Request.Body != nil {
-			buf, _ := io.ReadAll(c.Request.Body)
-			reqBody = string(buf)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(buf))
+			reqBuf, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(reqBuf))
 		}
 
 		// wrap the ResponseWriter
-		blw := &bodyLogWriter{body: bytes.NewBuffer([]byte{}), ResponseWriter: c.Writer}
+		blw := &bodyLogWriter{body: bytes.NewBuffer([]byte{}), ResponseWriter: c.Writer, limit: redaction.maxBodySize}
 		c.Writer = blw
 
 		// run the handler
 		c.Next()
 
-		// after handler: grab response
-		respBody := blw.body.String()
+		// after handler: redact what is kept of both bodies
+		redactor := redaction.redactor(c.Request.Method, c.FullPath())
+		reqBody := redaction.body(redactor, reqBuf, len(reqBuf))
+		respBody := redaction.body(redactor, blw.body.Bytes(), blw.size)
 
 		actor := c.GetString("app_id")
 		// 2) if empty, fall back to the header
@@ -61,9 +83,12 @@ func AuditLogger(db *gorm.DB) gin.HandlerFunc {
 
 		// build audit entry
 		entry := model.AuditLog{
+			CreatedAt:    start,
+			RequestID:    utils.GetRequestID(c.Request.Context()),
 			ActorID:      actor,
 			Method:       c.Request.Method,
 			Path:         c.FullPath(),
+			RequestPath:  c.Request.URL.Path,
 			StatusCode:   c.Writer.Status(),
 			ClientIP:     c.ClientIP(),
 			UserAgent:    c.Request.UserAgent(),
@@ -72,11 +97,7 @@ func AuditLogger(db *gorm.DB) gin.HandlerFunc {
 			LatencyMs:    time.Since(start).Milliseconds(),
 		}
 
-		// persist asynchronously
-		go func() {
-			if err := db.Create(&entry).Error; err != nil {
-				log.Printf("audit log persistence error: %v", err)
-			}
-		}()
+		// persisted in batches by the writer, dropped if it falls too far behind
+		writer.Enqueue(&entry)
 	}
 }
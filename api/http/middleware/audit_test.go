@@ -0,0 +1,84 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lbe/api/http/middleware"
+	"lbe/audit"
+	"lbe/config"
+	"lbe/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureWriter returns an audit writer that collects what it would persist.
+// Entries are available once the writer is closed.
+func captureWriter[T any]() (*audit.Writer[T], *[]T) {
+	var entries []T
+	writer := audit.NewWriter("test", func(batch []T) error {
+		entries = append(entries, batch...)
+		return nil
+	}, config.AuditConfig{})
+	return writer, &entries
+}
+
+func TestAuditLogger_Redaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "otp verify hashes the identifier and masks the otp",
+			path:        "/user/otp/verify",
+			body:        `{"purpose":"LOGIN","identifier":"user@example.com","otp":"123456"}`,
+			contains:    []string{`"purpose":"LOGIN"`, `"identifier":"sha256:`, `"otp":"****"`},
+			notContains: []string{"user@example.com", "123456"},
+		},
+		{
+			name:        "gr member id used as identifier",
+			path:        "/user/otp/verify",
+			body:        `{"purpose":"GR_REGISTRATION","identifier":"GR0012345","otp":"654321"}`,
+			contains:    []string{`"identifier":"sha256:`},
+			notContains: []string{"GR0012345", "654321"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, entries := captureWriter[*model.AuditLog]()
+
+			router := gin.New()
+			router.Use(middleware.AuditLogger(writer))
+			router.POST(tt.path, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"verification_token": "token"})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.NoError(t, writer.Close(context.Background()))
+			if !assert.Len(t, *entries, 1) {
+				return
+			}
+			entry := (*entries)[0]
+			for _, s := range tt.contains {
+				assert.Contains(t, entry.RequestBody, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, entry.RequestBody, s)
+			}
+			assert.Equal(t, `{"verification_token":"****"}`, entry.ResponseBody)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type errorResponse struct {
+	status   int
+	response func() responses.ApiResponse[any]
+}
+
+type upstreamCode struct {
+	upstream string
+	code     string
+}
+
+// upstreamCodeResponses answers upstream error codes that tell the client more
+// than the kind of failure does.
+var upstreamCodeResponses = map[upstreamCode]errorResponse{
+	{model.UpstreamRlpCore, responses.RlpErrorCodeUserNotFound}:    {http.StatusConflict, responses.ExistingUserNotFoundErrorResponse},
+	{model.UpstreamRlpCore, responses.RlpErrorCodeUserSuspended}:   {http.StatusConflict, responses.MemberSuspendedErrorResponse},
+	{model.UpstreamRlpOffers, responses.RlpErrorCodeUserSuspended}: {http.StatusConflict, responses.MemberSuspendedErrorResponse},
+}
+
+// upstreamKindResponses answers the kinds of upstream failure, of upstream or of
+// any upstream when it is empty. Only RLP profiles are looked up by the member's
+// id, a not found from any other upstream is an internal error.
+var upstreamKindResponses = []struct {
+	upstream string
+	kind     error
+	errorResponse
+}{
+	{model.UpstreamRlpCore, services.ErrUpstreamNotFound, errorResponse{http.StatusConflict, responses.ExistingUserNotFoundErrorResponse}},
+	{"", services.ErrUpstreamConflict, errorResponse{http.StatusConflict, responses.UpstreamConflictErrorResponse}},
+	{"", services.ErrUpstreamValidation, errorResponse{http.StatusBadRequest, responses.UpstreamValidationErrorResponse}},
+	{"", services.ErrUpstreamUnavailable, errorResponse{http.StatusServiceUnavailable, responses.UpstreamUnavailableErrorResponse}},
+}
+
+// ErrorHandler answers requests whose handler gave up with c.Error instead of
+// writing a response. Upstream errors are mapped by their code and kind, anything
+// else is an internal error. It has to be the last middleware before the routes,
+// so the audit log sees the status it writes.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		resp := errorResponseFor(c.Errors.Last().Err)
+		c.JSON(resp.status, resp.response())
+	}
+}
+
+func errorResponseFor(err error) errorResponse {
+	var upstreamErr *services.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		if resp, ok := upstreamCodeResponses[upstreamCode{upstreamErr.Upstream, upstreamErr.Code}]; ok {
+			return resp
+		}
+		for _, r := range upstreamKindResponses {
+			if (r.upstream == "" || r.upstream == upstreamErr.Upstream) && errors.Is(err, r.kind) {
+				return r.errorResponse
+			}
+		}
+	}
+	return errorResponse{http.StatusInternalServerError, responses.InternalErrorResponse}
+}
@@ -0,0 +1,134 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lbe/api/http/middleware"
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/codes"
+	"lbe/model"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// rlpError is what the RLP client returns when RLP answers with status and code.
+func rlpError(status int, code string) error {
+	var body []byte
+	if code != "" {
+		body, _ = json.Marshal(responses.UserProfileErrorResponse{Errors: responses.RlpErrors{Code: code}})
+	}
+	return services.RlpError(model.UpstreamRlpCore, &utils.UnexpectedStatusError{StatusCode: status, Body: body})
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody responses.ApiResponse[any]
+	}{
+		{
+			name:         "rlp user not found",
+			err:          rlpError(http.StatusBadRequest, responses.RlpErrorCodeUserNotFound),
+			expectedCode: http.StatusConflict,
+			expectedBody: responses.ExistingUserNotFoundErrorResponse(),
+		},
+		{
+			name:         "rlp profile not found",
+			err:          rlpError(http.StatusNotFound, ""),
+			expectedCode: http.StatusConflict,
+			expectedBody: responses.ExistingUserNotFoundErrorResponse(),
+		},
+		{
+			name:         "rlp offers not found",
+			err:          services.RlpError(model.UpstreamRlpOffers, &utils.UnexpectedStatusError{StatusCode: http.StatusNotFound}),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: responses.InternalErrorResponse(),
+		},
+		{
+			name: "non-rlp not found",
+			err: &services.UpstreamError{
+				Upstream: model.UpstreamMember,
+				Kind:     services.ErrUpstreamNotFound,
+				Err:      &utils.UnexpectedStatusError{StatusCode: http.StatusNotFound},
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: responses.InternalErrorResponse(),
+		},
+		{
+			name:         "rlp user suspended",
+			err:          rlpError(http.StatusBadRequest, responses.RlpErrorCodeUserSuspended),
+			expectedCode: http.StatusConflict,
+			expectedBody: responses.MemberSuspendedErrorResponse(),
+		},
+		{
+			name:         "rlp validation",
+			err:          rlpError(http.StatusBadRequest, responses.RlpErrorCodeValidation),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: responses.UpstreamValidationErrorResponse(),
+		},
+		{
+			name:         "upstream conflict status",
+			err:          rlpError(http.StatusConflict, ""),
+			expectedCode: http.StatusConflict,
+			expectedBody: responses.UpstreamConflictErrorResponse(),
+		},
+		{
+			name:         "upstream unavailable",
+			err:          rlpError(http.StatusServiceUnavailable, ""),
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: responses.UpstreamUnavailableErrorResponse(),
+		},
+		{
+			name:         "unclassified upstream error",
+			err:          rlpError(http.StatusInternalServerError, ""),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: responses.InternalErrorResponse(),
+		},
+		{
+			name:         "other error",
+			err:          errors.New("boom"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: responses.InternalErrorResponse(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.ErrorHandler())
+			r.GET("/fail", func(c *gin.Context) {
+				c.Error(tt.err)
+			})
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			var body responses.ApiResponse[any]
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedBody, body)
+		})
+	}
+}
+
+func TestErrorHandlerKeepsWrittenResponse(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/written", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusOK, responses.DefaultResponse(codes.SUCCESSFUL, "ok"))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/written", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
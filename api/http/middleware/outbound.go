@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"lbe/audit"
+	"lbe/config"
+	"lbe/model"
+	"lbe/utils"
+)
+
+// OutboundAudit returns a hook for utils.SetOutboundAuditHook that stores every
+// upstream call in upstream_call_logs through writer. Bodies are redacted with the
+// global application.audit rules, the same as the audit log's, and the upstream's
+// own rules for payloads named after its conventions. Personal data in the query
+// is masked as well.
+func OutboundAudit(writer *audit.Writer[*model.UpstreamCallLog]) utils.OutboundAuditHook {
+	redaction, err := newAuditRedaction(config.GetConfig().Application.Audit)
+	if err != nil {
+		log.Fatalf("audit redaction config: %v", err)
+	}
+
+	return func(_ context.Context, call utils.OutboundCall) {
+		redactor := redaction.upstreamRedactor(call.Upstream)
+		entry := model.UpstreamCallLog{
+			CreatedAt:    call.StartedAt,
+			RequestID:    call.RequestID,
+			Upstream:     call.Upstream,
+			Method:       call.Method,
+			URL:          redaction.upstreamURL(call.Upstream, call.URL),
+			StatusCode:   call.StatusCode,
+			LatencyMs:    call.Latency.Milliseconds(),
+			RequestBody:  redaction.body(redactor, call.RequestBody, len(call.RequestBody)),
+			ResponseBody: redaction.body(redactor, call.ResponseBody, len(call.ResponseBody)),
+		}
+		if call.Err != nil {
+			entry.Error = strings.ToValidUTF8(truncate(call.Err.Error(), 1024), "")
+		}
+
+		writer.Enqueue(&entry)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"lbe/api/http/middleware"
+	"lbe/model"
+	"lbe/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	graphUser = `{"id":"03f1e0b8-df1a-4349-bbb7-2f641676f095","displayName":"John Tan","givenName":"John","surname":"Tan",` +
+		`"mail":"john.tan@example.com","userPrincipalName":"03f1e0b8-df1a-4349-bbb7-2f641676f095@lbe.onmicrosoft.com",` +
+		`"identities":[{"signInType":"emailAddress","issuer":"lbe.onmicrosoft.com","issuerAssignedId":"john.tan@example.com"}]}`
+	cmsMember = `{"MemberNo":"GR0012345","FirstName":"John","LastName":"Tan","DateOfBirth":"1990-01-02T00:00:00Z",` +
+		`"CardDisplayName":"JOHN TAN","IdentificationTypeID":1,"IdentificationNo":"S1234567D","EmailAddress":"john.tan@example.com"}`
+)
+
+func TestOutboundAudit_Redaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		call        utils.OutboundCall
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "graph user lookup by mail",
+			call: utils.OutboundCall{
+				Upstream: model.UpstreamCiam,
+				Method:   http.MethodGet,
+				URL: "https://graph.microsoft.com/v1.0/users?" + url.Values{
+					"$filter": {"mail eq 'john.tan@example.com'"},
+					"$select": {"id,mail"},
+				}.Encode(),
+				StatusCode:   http.StatusOK,
+				ResponseBody: []byte(`{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#users","value":[` + graphUser + `]}`),
+			},
+			contains:    []string{"mail+eq+%27%2A%2A%2A%2A%27", `"givenName":"****"`, `"mail":"sha256:`, `"issuerAssignedId":"sha256:`, `"signInType":"emailAddress"`},
+			notContains: []string{"john.tan", "John", "Tan"},
+		},
+		{
+			name: "graph user creation",
+			call: utils.OutboundCall{
+				Upstream: model.UpstreamCiam,
+				Method:   http.MethodPost,
+				URL:      "https://graph.microsoft.com/v1.0/users",
+				RequestBody: []byte(`{"accountEnabled":true,"displayName":"John Tan","givenName":"John","surname":"Tan","mail":"john.tan@example.com",` +
+					`"identities":[{"signInType":"emailAddress","issuer":"lbe.onmicrosoft.com","issuerAssignedId":"john.tan@example.com"}],` +
+					`"passwordProfile":{"password":"S3cret!pass","forceChangePasswordNextSignIn":false}}`),
+				StatusCode:   http.StatusCreated,
+				ResponseBody: []byte(graphUser),
+			},
+			contains:    []string{`"surname":"****"`, `"displayName":"****"`, `"password":"****"`},
+			notContains: []string{"john.tan", "John", "Tan", "S3cret"},
+		},
+		{
+			name: "cms member profile",
+			call: utils.OutboundCall{
+				Upstream:     model.UpstreamCms,
+				Method:       http.MethodGet,
+				URL:          "https://cms.example.com/cms-webapi-bsp/v2/member?memberId=GR0012345&systemId=LBE",
+				StatusCode:   http.StatusOK,
+				ResponseBody: []byte(cmsMember),
+			},
+			contains:    []string{"memberId=%2A%2A%2A%2A", "systemId=LBE", `"FirstName":"****"`, `"DateOfBirth":"sha256:`, `"IdentificationNo":"****"`, `"IdentificationTypeID":1`},
+			notContains: []string{"john.tan", "John", "Tan", "S1234567D", "1990-01-02", "memberId=GR0012345"},
+		},
+		{
+			name: "snake_case upstreams keep the global rules",
+			call: utils.OutboundCall{
+				Upstream:     model.UpstreamRlpCore,
+				Method:       http.MethodPost,
+				URL:          "https://rlp.example.com/api/v1/users",
+				RequestBody:  []byte(`{"user":{"email":"john.tan@example.com","first_name":"John","FirstName":"John"}}`),
+				StatusCode:   http.StatusOK,
+				ResponseBody: []byte(`{}`),
+			},
+			contains:    []string{`"email":"sha256:`, `"first_name":"****"`, `"FirstName":"John"`},
+			notContains: []string{"john.tan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, entries := captureWriter[*model.UpstreamCallLog]()
+
+			tt.call.StartedAt = time.Now()
+			middleware.OutboundAudit(writer)(context.Background(), tt.call)
+
+			assert.NoError(t, writer.Close(context.Background()))
+			if !assert.Len(t, *entries, 1) {
+				return
+			}
+			entry := (*entries)[0]
+			stored := strings.Join([]string{entry.URL, entry.RequestBody, entry.ResponseBody}, "\n")
+			for _, s := range tt.contains {
+				assert.Contains(t, stored, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, stored, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// problemWriter holds back error responses, so they can be rewritten as problem
+// details once the handler is done. Other responses pass straight through.
+type problemWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *problemWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *problemWriter) WriteHeaderNow() {
+	if w.status == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *problemWriter) Write(data []byte) (int, error) {
+	if w.status != 0 {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *problemWriter) WriteString(s string) (int, error) {
+	if w.status != 0 {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *problemWriter) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *problemWriter) Written() bool {
+	return w.status != 0 || w.ResponseWriter.Written()
+}
+
+// ProblemDetails answers the failed requests of the v2 API with RFC 7807 problem
+// details instead of the v1 envelope. The handlers stay shared with v1: the error
+// response they write is held back and rewritten, errors left to c.Error are mapped
+// the way ErrorHandler does. Validation errors passed to c.Error are listed as field
+// errors, an *services.UpstreamError as the upstream the request failed on.
+func ProblemDetails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pw := &problemWriter{ResponseWriter: c.Writer}
+		c.Writer = pw
+
+		c.Next()
+
+		c.Writer = pw.ResponseWriter
+
+		var (
+			status int
+			resp   responses.ApiResponse[any]
+		)
+		switch {
+		case pw.status != 0:
+			status = pw.status
+			if err := json.Unmarshal(pw.body.Bytes(), &resp); err != nil || resp.Code == 0 {
+				// not a v1 error envelope, send it as it is
+				c.Writer.WriteHeader(status)
+				_, _ = c.Writer.Write(pw.body.Bytes())
+				return
+			}
+		case len(c.Errors) > 0 && !c.Writer.Written():
+			r := errorResponseFor(c.Errors.Last().Err)
+			status, resp = r.status, r.response()
+		default:
+			return
+		}
+
+		problem := responses.NewProblem(status, resp)
+		problem.Instance = c.Request.URL.Path
+		problem.RequestID = utils.GetRequestID(c.Request.Context())
+		for _, ginErr := range c.Errors {
+			for _, field := range requests.FieldErrors(ginErr.Err) {
+				problem.Errors = append(problem.Errors, responses.ProblemFieldError{Pointer: field.Pointer(), Detail: field.Message})
+			}
+
+			var upstreamErr *services.UpstreamError
+			if errors.As(ginErr.Err, &upstreamErr) {
+				problem.Upstream = &responses.ProblemUpstream{Name: upstreamErr.Upstream, Code: upstreamErr.Code}
+			}
+		}
+
+		c.Header("Content-Type", responses.ProblemContentType)
+		c.Render(status, render.JSON{Data: problem})
+	}
+}
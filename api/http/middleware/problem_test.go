@@ -0,0 +1,126 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lbe/api/http/middleware"
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/codes"
+	"lbe/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemDetails(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		handler         gin.HandlerFunc
+		expectedCode    int
+		expectedProblem *responses.Problem
+	}{
+		{
+			name: "validation error",
+			body: `{"sign_up_type":"NEW","user":{}}`,
+			handler: func(c *gin.Context) {
+				var req requests.RegisterUser
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Error(err)
+					c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
+					return
+				}
+				if err := req.Validate(); err != nil {
+					c.Error(err)
+					c.JSON(http.StatusBadRequest, responses.InvalidRequestBodySpecificErrorResponse(err.Error()))
+				}
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedProblem: &responses.Problem{
+				Type:     "urn:lbe:problem:invalid-request-body",
+				Title:    "invalid request body",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid json request body:user.email is required",
+				Instance: "/v2/test",
+				Code:     codes.INVALID_REQUEST_BODY,
+				Errors:   []responses.ProblemFieldError{{Pointer: "/user/email", Detail: "user.email is required"}},
+			},
+		},
+		{
+			name: "binding error",
+			body: `{}`,
+			handler: func(c *gin.Context) {
+				var req requests.VerifyUserExistence
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Error(err)
+					c.JSON(http.StatusBadRequest, responses.InvalidRequestBodyErrorResponse())
+				}
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedProblem: &responses.Problem{
+				Type:     "urn:lbe:problem:invalid-request-body",
+				Title:    "invalid request body",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid json request body",
+				Instance: "/v2/test",
+				Code:     codes.INVALID_REQUEST_BODY,
+				Errors:   []responses.ProblemFieldError{{Pointer: "/email", Detail: "email is required"}},
+			},
+		},
+		{
+			name: "upstream error",
+			handler: func(c *gin.Context) {
+				c.Error(rlpError(http.StatusBadRequest, responses.RlpErrorCodeUserSuspended))
+			},
+			expectedCode: http.StatusConflict,
+			expectedProblem: &responses.Problem{
+				Type:     "urn:lbe:problem:member-suspended",
+				Title:    "member suspended",
+				Status:   http.StatusConflict,
+				Detail:   "member is suspended",
+				Instance: "/v2/test",
+				Code:     codes.MEMBER_SUSPENDED,
+				Upstream: &responses.ProblemUpstream{Name: model.UpstreamRlpCore, Code: responses.RlpErrorCodeUserSuspended},
+			},
+		},
+		{
+			name: "success passes through",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, responses.DefaultResponse(codes.SUCCESSFUL, "ok"))
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.RequestID(), middleware.ErrorHandler())
+			r.POST("/v2/test", middleware.ProblemDetails(), tt.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/v2/test", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(model.HeaderRequestID, "problem-test")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedProblem == nil {
+				assert.Equal(t, `{"code":1000,"message":"ok","data":null}`, rec.Body.String())
+				return
+			}
+
+			assert.Equal(t, responses.ProblemContentType, rec.Header().Get("Content-Type"))
+			expected := *tt.expectedProblem
+			expected.RequestID = "problem-test"
+			var problem responses.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, expected, problem)
+		})
+	}
+}
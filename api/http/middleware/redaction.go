@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"lbe/config"
+	"lbe/model"
+	"lbe/security/redact"
+)
+
+// defaultRedactRules cover the personal data in model.User, model.GrProfile and
+// model.Otp, and the credentials handed out by the API. Values that audit
+// searches correlate on are hashed, the rest masked.
+var defaultRedactRules = []redact.Rule{
+	// model.Otp and verification, identifier is the email or GR member id the OTP went to
+	{Path: "**.otp", Action: redact.Mask},
+	{Path: "**.identifier", Action: redact.Hash},
+	{Path: "**.verification_token", Action: redact.Mask},
+
+	// credentials and session tokens
+	{Path: "**.password", Action: redact.Mask},
+	{Path: "**.secret", Action: redact.Mask},
+	{Path: "**.access_token", Action: redact.Mask},
+	{Path: "**.auth_token", Action: redact.Mask},
+	{Path: "**.login_session_token", Action: redact.Mask},
+
+	// model.GrProfile and model.UserProfile pins
+	{Path: "**.pin", Action: redact.Mask},
+	{Path: "**.burn_pin", Action: redact.Mask},
+
+	// model.User and model.UserProfile personal data
+	{Path: "**.email", Action: redact.Hash},
+	{Path: "**.previous_email", Action: redact.Hash},
+	{Path: "**.phone_number", Action: redact.Hash},
+	{Path: "**.dob", Action: redact.Hash},
+	{Path: "**.first_name", Action: redact.Mask},
+	{Path: "**.last_name", Action: redact.Mask},
+	{Path: "**.employee_number", Action: redact.Mask},
+}
+
+// upstreamRedactRules cover the personal data of upstreams whose payloads do not
+// follow our snake_case names, applied to their calls on top of the global rules.
+var upstreamRedactRules = map[string][]redact.Rule{
+	// Microsoft Graph users, see ciam.User
+	model.UpstreamCiam: {
+		{Path: "**.mail", Action: redact.Hash},
+		{Path: "**.otherMails", Action: redact.Hash},
+		{Path: "**.identities.issuerAssignedId", Action: redact.Hash},
+		{Path: "**.givenName", Action: redact.Mask},
+		{Path: "**.surname", Action: redact.Mask},
+		{Path: "**.displayName", Action: redact.Mask},
+		{Path: "**.mobilePhone", Action: redact.Hash},
+	},
+	// CMS member profiles, see responses.GRProfilePayload
+	model.UpstreamCms: {
+		{Path: "**.EmailAddress", Action: redact.Hash},
+		{Path: "**.DateOfBirth", Action: redact.Hash},
+		{Path: "**.FirstName", Action: redact.Mask},
+		{Path: "**.LastName", Action: redact.Mask},
+		{Path: "**.CardDisplayName", Action: redact.Mask},
+		{Path: "**.IdentificationNo", Action: redact.Mask},
+	},
+}
+
+// upstreamQueryParams are the query parameters of an upstream whose values are
+// personal data, masked in the stored URL.
+var upstreamQueryParams = map[string][]string{
+	model.UpstreamCms: {"memberId"},
+}
+
+// odataQueryParams hold OData expressions such as $filter=mail eq '...'. Their
+// string literals are masked and the rest kept, to show what was searched by.
+var odataQueryParams = []string{"$filter", "$search"}
+
+var odataLiteral = regexp.MustCompile(`'(?:[^']|'')*'|"[^"]*"`)
+
+// auditRedaction decides what the audit log keeps of captured bodies.
+type auditRedaction struct {
+	maxBodySize int
+	global      *redact.Redactor
+	// routes is keyed by "METHOD path", with "*" as method for rules on any method
+	routes map[string]*redact.Redactor
+	// upstreams is keyed by model.UpstreamCallLog upstream
+	upstreams map[string]*redact.Redactor
+}
+
+func newAuditRedaction(conf config.AuditConfig) (*auditRedaction, error) {
+	if conf.HashKey == "" {
+		log.Printf("application.audit.hashKey is not set, hashed audit fields are not keyed")
+	}
+	hashKey := []byte(conf.HashKey)
+
+	globalRules := append(append([]redact.Rule{}, defaultRedactRules...), redactRules(conf.Redact)...)
+	global, err := redact.New(hashKey, globalRules...)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &auditRedaction{
+		maxBodySize: conf.GetMaxBodySize(),
+		global:      global,
+		routes:      make(map[string]*redact.Redactor),
+		upstreams:   make(map[string]*redact.Redactor),
+	}
+	for _, route := range conf.Routes {
+		rules := append(append([]redact.Rule{}, globalRules...), redactRules(route.Redact)...)
+		r, err := redact.New(hashKey, rules...)
+		if err != nil {
+			return nil, fmt.Errorf("audit route %s %s: %w", route.Method, route.Path, err)
+		}
+		a.routes[routeKey(route.Method, route.Path)] = r
+	}
+	for upstream, upstreamRules := range upstreamRedactRules {
+		rules := append(append([]redact.Rule{}, globalRules...), upstreamRules...)
+		r, err := redact.New(hashKey, rules...)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", upstream, err)
+		}
+		a.upstreams[upstream] = r
+	}
+
+	return a, nil
+}
+
+func (a *auditRedaction) redactor(method, path string) *redact.Redactor {
+	if r, ok := a.routes[routeKey(method, path)]; ok {
+		return r
+	}
+	if r, ok := a.routes[routeKey("", path)]; ok {
+		return r
+	}
+	return a.global
+}
+
+func (a *auditRedaction) upstreamRedactor(upstream string) *redact.Redactor {
+	if r, ok := a.upstreams[upstream]; ok {
+		return r
+	}
+	return a.global
+}
+
+// upstreamURL masks the personal data in the query of a call to upstream. The
+// credentials are already stripped by utils.StripURLSecrets.
+func (a *auditRedaction) upstreamURL(upstream, raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "[unparsable url]"
+	}
+
+	query := u.Query()
+	masked := false
+	for _, name := range odataQueryParams {
+		values := query[name]
+		for i := range values {
+			values[i] = odataLiteral.ReplaceAllStringFunc(values[i], func(literal string) string {
+				return literal[:1] + redact.Masked + literal[:1]
+			})
+			masked = true
+		}
+	}
+	for _, name := range upstreamQueryParams[upstream] {
+		values := query[name]
+		for i := range values {
+			values[i] = redact.Masked
+			masked = true
+		}
+	}
+	if masked {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// body returns what is stored of a captured body of size bytes. Oversized and
+// non-JSON bodies are left out, since they cannot be redacted reliably.
+func (a *auditRedaction) body(r *redact.Redactor, body []byte, size int) string {
+	if size == 0 {
+		return ""
+	}
+	if size > a.maxBodySize {
+		return fmt.Sprintf("[body of %d bytes exceeds the %d bytes captured]", size, a.maxBodySize)
+	}
+
+	redacted, err := r.JSON(body)
+	if err != nil {
+		return fmt.Sprintf("[non-json body of %d bytes not captured]", size)
+	}
+	return string(redacted)
+}
+
+func routeKey(method, path string) string {
+	if method == "" {
+		method = "*"
+	}
+	return strings.ToUpper(method) + " " + path
+}
+
+func redactRules(rules []config.RedactRule) []redact.Rule {
+	out := make([]redact.Rule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, redact.Rule{Path: rule.Path, Action: redact.Action(strings.ToLower(rule.Action))})
+	}
+	return out
+}
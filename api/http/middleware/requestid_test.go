@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lbe/api/http/middleware"
+	"lbe/model"
+	"lbe/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		keepsSent bool
+	}{
+		{name: "generated when missing"},
+		{name: "caller id kept", header: "lbe-web:7f3a9c", keepsSent: true},
+		{name: "log forging characters replaced", header: "abc\n[GIN] forged"},
+		{name: "overlong id replaced", header: strings.Repeat("a", 65)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.Use(middleware.RequestID())
+			r.GET("/ping", func(c *gin.Context) {
+				seen = utils.GetRequestID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(model.HeaderRequestID, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(model.HeaderRequestID)
+			assert.NotEmpty(t, echoed)
+			assert.Equal(t, echoed, seen)
+			if tt.keepsSent {
+				assert.Equal(t, tt.header, echoed)
+			} else {
+				assert.NotEqual(t, tt.header, echoed)
+				assert.Len(t, echoed, 36)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"lbe/codes"
+)
+
+const (
+	DefaultAuditLogPageSize = 50
+	MaxAuditLogPageSize     = 500
+)
+
+// AuditLogQuery filters audit log entries. All filters are optional and combined with AND.
+type AuditLogQuery struct {
+	// RequestID is the id shared with the upstream calls of the request.
+	RequestID string `form:"request_id" example:"3f1c9a52-7d7e-4c53-9a0e-2b1f6c8d4e10"`
+
+	// Actor is the AppID of the calling channel.
+	Actor string `form:"actor" example:"app1234"`
+
+	// Path is the route as registered.
+	Path string `form:"path" example:"/api/v1/user/:external_id"`
+
+	// RequestPath is the path as requested, naming the member for member routes.
+	RequestPath string `form:"request_path" example:"/api/v1/user/25052300047"`
+
+	// StatusCode of the response.
+	StatusCode int `form:"status_code" example:"409"`
+
+	// From is the inclusive lower bound of the entry time (RFC3339).
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-05-01T00:00:00+08:00"`
+
+	// To is the exclusive upper bound of the entry time (RFC3339).
+	To time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-06-01T00:00:00+08:00"`
+
+	// MinLatencyMs only keeps requests that took at least this long.
+	MinLatencyMs int64 `form:"min_latency_ms" example:"1000"`
+
+	// Cursor is the next_cursor of the previous page.
+	Cursor string `form:"cursor"`
+
+	// Limit is the page size, 50 by default and at most 500.
+	Limit int `form:"limit" example:"50"`
+
+	// Format of an export: csv or jsonl.
+	Format string `form:"format" example:"csv"`
+}
+
+// UpstreamCallQuery selects the upstream calls made while serving one request.
+type UpstreamCallQuery struct {
+	RequestID string `form:"request_id" binding:"required" example:"3f1c9a52-7d7e-4c53-9a0e-2b1f6c8d4e10"`
+}
+
+func (r *AuditLogQuery) Validate() error {
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return errors.New("from must be before to")
+	}
+	if r.StatusCode < 0 || r.MinLatencyMs < 0 {
+		return errors.New("status_code and min_latency_ms must not be negative")
+	}
+	if r.Limit < 0 || r.Limit > MaxAuditLogPageSize {
+		return errors.New("limit out of range")
+	}
+	if r.Limit == 0 {
+		r.Limit = DefaultAuditLogPageSize
+	}
+	if r.Format != "" && !codes.IsValidAuditExportFormat(r.Format) {
+		return errors.New("invalid format provided")
+	}
+	return nil
+}
@@ -60,12 +60,6 @@ func GenerateInitialRegistrationRequest(user *model.User) GraphCreateUserRequest
 	}
 }
 
-type UserIdLinkSchemaExtensionFields struct {
-	RlpId string `json:"rlpid"`
-	RlpNo string `json:"rlpno"`
-	GrId  string `json:"grid"`
-}
-
 type GraphDisableAccountRequest struct {
 	AccountEnabled bool `json:"accountEnabled"`
 }
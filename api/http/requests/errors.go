@@ -0,0 +1,90 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// name fields in binding errors the way clients send them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+// FieldError is a request field failing validation. Field is the dotted json path of
+// the field, Message what is wrong with it.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Pointer returns the JSON pointer of the field.
+func (e *FieldError) Pointer() string {
+	replacer := strings.NewReplacer("~", "~0", "/", "~1")
+	segments := strings.Split(e.Field, ".")
+	for i, s := range segments {
+		segments[i] = replacer.Replace(s)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func fieldError(field, message string) error {
+	return &FieldError{Field: field, Message: message}
+}
+
+// FieldErrors lists the fields a binding or Validate error complains about, nil
+// for errors not tied to a field such as malformed JSON.
+func FieldErrors(err error) []FieldError {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return []FieldError{*fieldErr}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{Field: typeErr.Field, Message: fmt.Sprintf("%s must not be a %s", typeErr.Field, typeErr.Value)}}
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, e := range validationErrs {
+		// the namespace starts with the name of the request struct
+		field := e.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		message := fmt.Sprintf("%s failed on %s", field, e.Tag())
+		if e.Tag() == "required" {
+			message = field + " is required"
+		}
+		fields = append(fields, FieldError{Field: field, Message: message})
+	}
+	return fields
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
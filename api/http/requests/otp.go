@@ -0,0 +1,24 @@
+package requests
+
+import (
+	"lbe/codes"
+)
+
+// VerifyOtp is the payload to verify an OTP previously sent to the user.
+type VerifyOtp struct {
+	// Purpose the OTP was issued for: REGISTRATION, GR_REGISTRATION or LOGIN.
+	Purpose string `json:"purpose" binding:"required" example:"REGISTRATION"`
+
+	// Identifier the OTP was issued for: the email, or the GR member id for GR_REGISTRATION.
+	Identifier string `json:"identifier" binding:"required" example:"user@example.com"`
+
+	// Otp entered by the user.
+	Otp string `json:"otp" binding:"required" example:"123456"`
+}
+
+func (r *VerifyOtp) Validate() error {
+	if !codes.IsValidOtpPurpose(r.Purpose) {
+		return fieldError("purpose", "invalid purpose provided")
+	}
+	return nil
+}
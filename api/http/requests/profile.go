@@ -1,7 +1,35 @@
 package requests
 
-import "lbe/model"
+import (
+	"errors"
+	"lbe/ciam"
+	"lbe/codes"
+	"lbe/model"
+	"lbe/utils"
+)
 
 type UpdateUserProfile struct {
 	User model.User `json:"user"`
 }
+
+// LookupUser identifies a member by any of its identifiers.
+type LookupUser struct {
+	// Type of the identifier: rlp_id, rlp_no, gr_id, email or ciam_id.
+	Type string `form:"type" binding:"required" example:"rlp_no"`
+
+	// Value of the identifier.
+	Value string `form:"value" binding:"required" example:"70000000001"`
+}
+
+func (r *LookupUser) Validate() error {
+	if !codes.IsValidLookupType(r.Type) {
+		return errors.New("invalid type provided")
+	}
+	switch r.Type {
+	case codes.LookupTypeRlpNo:
+		return utils.ValidateRLPNo(r.Value)
+	case codes.LookupTypeCiamId:
+		return ciam.ValidateID(r.Value)
+	}
+	return nil
+}
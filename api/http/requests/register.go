@@ -1,7 +1,6 @@
 package requests
 
 import (
-	"errors"
 	"lbe/codes"
 	model "lbe/model"
 )
@@ -17,62 +16,77 @@ type RegisterUser struct {
 	User       model.User `json:"user"`
 	SignUpType string     `json:"sign_up_type" example:"NEW"`
 	RegId      string     `json:"reg_id" example:"123456"`
+	// VerificationToken returned by /user/otp/verify, required for NEW and GR sign-ups.
+	VerificationToken string `json:"verification_token" example:"2f1c7a4e-8d6b-4b6f-9a51-0c3e7d2b9f10"`
+}
+
+// VerifiedIdentifier is the identifier the verification token must have been issued for:
+// the GR member id for GR sign-ups, the email otherwise.
+func (r *RegisterUser) VerifiedIdentifier() string {
+	if r.SignUpType == codes.SignUpTypeGR && r.User.GrProfile != nil {
+		return r.User.GrProfile.Id
+	}
+	return r.User.Email
 }
 
 func (r *RegisterUser) Validate() error {
 	signUpType := r.SignUpType
 
 	if !codes.IsValidSignUpType(signUpType) {
-		return errors.New("invalid sign_up_type provided")
+		return fieldError("sign_up_type", "invalid sign_up_type provided")
 	}
 
 	if signUpType == codes.SignUpTypeTM {
 		if r.User.UserProfile.EmployeeNumber == "" {
-			return errors.New("user.user_profile.employee_number is required")
+			return fieldError("user.user_profile.employee_number", "user.user_profile.employee_number is required")
 		}
 	} else if signUpType == codes.SignUpTypeGRCMS {
 		if r.RegId == "" {
-			return errors.New("reg_id is required")
+			return fieldError("reg_id", "reg_id is required")
 		}
 	} else {
 		if r.User.Email == "" {
-			return errors.New("user.email is required")
+			return fieldError("user.email", "user.email is required")
 		}
 		if r.User.FirstName == "" {
-			return errors.New("user.first_name is required")
+			return fieldError("user.first_name", "user.first_name is required")
 		}
 		if r.User.LastName == "" {
-			return errors.New("user.last_name is required")
+			return fieldError("user.last_name", "user.last_name is required")
 		}
 		if r.User.DateOfBirth == nil {
-			return errors.New("user.dob is required")
+			return fieldError("user.dob", "user.dob is required")
 		}
 		if r.User.PhoneNumbers == nil {
-			return errors.New("user.phone_numbers is required")
+			return fieldError("user.phone_numbers", "user.phone_numbers is required")
 		} else {
 			if len(r.User.PhoneNumbers) == 0 || r.User.PhoneNumbers[0].PhoneNumber == "" {
-				return errors.New("user.phone_numbers must be properly populated")
+				return fieldError("user.phone_numbers", "user.phone_numbers must be properly populated")
 			}
 		}
 		if r.User.UserProfile.CountryCode == "" {
-			return errors.New("user.user_profile.country_code is required")
+			return fieldError("user.user_profile.country_code", "user.user_profile.country_code is required")
 		}
 		if r.User.UserProfile.CountryName == "" {
-			return errors.New("user.user_profile.country_name is required")
+			return fieldError("user.user_profile.country_name", "user.user_profile.country_name is required")
 		}
 		// marketing preference flags will be false by default
 
 		if signUpType == codes.SignUpTypeGR {
 			if r.User.GrProfile == nil {
-				return errors.New("gr_profile is required")
+				return fieldError("user.gr_profile", "gr_profile is required")
 			} else {
 				if r.User.GrProfile.Class == "" {
-					return errors.New("gr_profile.class is required")
+					return fieldError("user.gr_profile.class", "gr_profile.class is required")
 				}
 			}
 		}
 	}
 
+	if _, ok := codes.RegistrationOtpPurpose(signUpType); ok && r.VerificationToken == "" {
+		return fieldError("verification_token", "verification_token is required")
+	}
+
 	return nil
 }
 
@@ -82,13 +96,13 @@ type VerifyGrUser struct {
 
 func (r *VerifyGrUser) Validate() error {
 	if r.User.GrProfile == nil {
-		return errors.New("gr_profile is required")
+		return fieldError("user.gr_profile", "gr_profile is required")
 	} else {
 		if r.User.GrProfile.Id == "" {
-			return errors.New("gr_profile.id is required")
+			return fieldError("user.gr_profile.id", "gr_profile.id is required")
 		}
 		if r.User.GrProfile.Pin == "" {
-			return errors.New("gr_profile.pin is required")
+			return fieldError("user.gr_profile.pin", "gr_profile.pin is required")
 		}
 	}
 
@@ -101,40 +115,40 @@ type VerifyGrCmsUser struct {
 
 func (r *VerifyGrCmsUser) Validate() error {
 	if r.User.GrProfile == nil {
-		return errors.New("gr_profile is required")
+		return fieldError("user.gr_profile", "gr_profile is required")
 	} else {
 		if r.User.GrProfile.Id == "" {
-			return errors.New("gr_profile.id is required")
+			return fieldError("user.gr_profile.id", "gr_profile.id is required")
 		}
 		if r.User.GrProfile.Class == "" {
-			return errors.New("gr_profile.class is required")
+			return fieldError("user.gr_profile.class", "gr_profile.class is required")
 		}
 	}
 
 	if r.User.Email == "" {
-		return errors.New("user.email is required")
+		return fieldError("user.email", "user.email is required")
 	}
 	if r.User.FirstName == "" {
-		return errors.New("user.first_name is required")
+		return fieldError("user.first_name", "user.first_name is required")
 	}
 	if r.User.LastName == "" {
-		return errors.New("user.last_name is required")
+		return fieldError("user.last_name", "user.last_name is required")
 	}
 	if r.User.DateOfBirth == nil {
-		return errors.New("user.dob is required")
+		return fieldError("user.dob", "user.dob is required")
 	}
 	if r.User.PhoneNumbers == nil {
-		return errors.New("user.phone_numbers is required")
+		return fieldError("user.phone_numbers", "user.phone_numbers is required")
 	} else {
 		if len(r.User.PhoneNumbers) == 0 || r.User.PhoneNumbers[0].PhoneNumber == "" {
-			return errors.New("user.phone_numbers must be properly populated")
+			return fieldError("user.phone_numbers", "user.phone_numbers must be properly populated")
 		}
 	}
 	if r.User.UserProfile.CountryCode == "" {
-		return errors.New("user.user_profile.country_code is required")
+		return fieldError("user.user_profile.country_code", "user.user_profile.country_code is required")
 	}
 	if r.User.UserProfile.CountryName == "" {
-		return errors.New("user.user_profile.country_name is required")
+		return fieldError("user.user_profile.country_name", "user.user_profile.country_name is required")
 	}
 
 	return nil
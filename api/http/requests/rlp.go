@@ -1,6 +1,10 @@
 package requests
 
-import "lbe/model"
+import (
+	"fmt"
+	"lbe/model"
+	"time"
+)
 
 type UserTierUpdateEventRequest struct {
 	EventLookup string `json:"event_lookup,omitempty"`
@@ -11,3 +15,26 @@ type UserTierUpdateEventRequest struct {
 type UserProfileRequest struct {
 	User model.RlpUserReq `json:"user"`
 }
+
+// GenerateArchiveProfileRequest builds the payload that deactivates an RLP profile,
+// freeing the email by suffixing it with the archive timestamp.
+func GenerateArchiveProfileRequest(email string, now time.Time) UserProfileRequest {
+	rlpUser := model.RlpUserReq{
+		UserProfile: model.UserProfile{
+			ActiveStatus: "0",
+			MarketingPreference: model.MarketingPreference{
+				Push:   model.BoolPtr(false),
+				Email:  model.BoolPtr(false),
+				Mobile: model.BoolPtr(false),
+			},
+		},
+	}
+
+	// profiles that never received their details have no email to free
+	if email != "" {
+		timestamp := now.Format("060102150405") // yyMMddHHmmss
+		rlpUser.Email = fmt.Sprintf("%s.delete_%v", email, timestamp)
+	}
+
+	return UserProfileRequest{User: rlpUser}
+}
@@ -0,0 +1,10 @@
+package responses
+
+import "lbe/model"
+
+// AuditLogsResponseData is one page of audit log entries.
+type AuditLogsResponseData struct {
+	Entries []model.AuditLog `json:"entries"`
+	// NextCursor fetches the following page, absent on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -1,10 +1,5 @@
 package responses
 
-const (
-	// error messages
-	CiamUserAlreadyExists = "Another object with the same value for property userPrincipalName already exists."
-)
-
 type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
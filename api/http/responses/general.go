@@ -73,6 +73,10 @@ func GrMemberIdLinkedErrorResponse() ApiResponse[any] {
 	return DefaultResponse(codes.GR_MEMBER_LINKED, "gr profile already linked to another email")
 }
 
+func GrMemberNotFoundErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.GR_MEMBER_NOT_FOUND, "gr member not found")
+}
+
 func InvalidGrMemberClassErrorResponse() ApiResponse[any] {
 	return DefaultResponse(codes.INVALID_GR_MEMBER_CLASS, "invalid gr member class provided")
 }
@@ -80,3 +84,71 @@ func InvalidGrMemberClassErrorResponse() ApiResponse[any] {
 func CachedProfileNotFoundErrorResponse() ApiResponse[any] {
 	return DefaultResponse(codes.CACHED_PROFILE_NOT_FOUND, "cached profile not found")
 }
+
+func AccessDeniedErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.ACCESS_DENIED, "access denied")
+}
+
+func RegistrationAttemptNotFoundErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.REGISTRATION_ATTEMPT_NOT_FOUND, "registration attempt not found")
+}
+
+func RegistrationNotResumableErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.REGISTRATION_NOT_RESUMABLE, "registration attempt is not resumable")
+}
+
+func InvalidOtpErrorResponse(remainingAttempts int) ApiResponse[any] {
+	return ApiResponse[any]{
+		Code:    codes.INVALID_OTP,
+		Message: "invalid otp",
+		Data:    InvalidOtpResponseData{RemainingAttempts: remainingAttempts},
+	}
+}
+
+func OtpNotFoundErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.OTP_NOT_FOUND, "otp not found or expired")
+}
+
+func OtpAttemptsExceededErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.OTP_ATTEMPTS_EXCEEDED, "too many invalid otp attempts, request a new otp")
+}
+
+func OtpResendCooldownErrorResponse(retryAfter int64) ApiResponse[any] {
+	return ApiResponse[any]{
+		Code:    codes.OTP_RESEND_COOLDOWN,
+		Message: "otp was sent recently, try again later",
+		Data:    OtpRateLimitResponseData{RetryAfter: retryAfter},
+	}
+}
+
+func OtpDailyLimitReachedErrorResponse(retryAfter int64) ApiResponse[any] {
+	return ApiResponse[any]{
+		Code:    codes.OTP_DAILY_LIMIT_REACHED,
+		Message: "daily otp limit reached",
+		Data:    OtpRateLimitResponseData{RetryAfter: retryAfter},
+	}
+}
+
+func InvalidVerificationTokenErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.INVALID_VERIFICATION_TOKEN, "verification token is invalid, expired or issued for another user")
+}
+
+func RequestInProgressErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.REQUEST_IN_PROGRESS, "another request for this user is in progress, try again later")
+}
+
+func MemberSuspendedErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.MEMBER_SUSPENDED, "member is suspended")
+}
+
+func UpstreamConflictErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.UPSTREAM_CONFLICT, "request conflicts with the member's current state")
+}
+
+func UpstreamValidationErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.UPSTREAM_VALIDATION_FAILED, "request was rejected by an upstream service")
+}
+
+func UpstreamUnavailableErrorResponse() ApiResponse[any] {
+	return DefaultResponse(codes.UPSTREAM_UNAVAILABLE, "upstream service unavailable, try again later")
+}
@@ -0,0 +1,17 @@
+package responses
+
+import "lbe/model"
+
+type VerifyOtpResponseData struct {
+	model.OtpVerification
+}
+
+type InvalidOtpResponseData struct {
+	// RemainingAttempts before the otp is invalidated and a new one has to be requested.
+	RemainingAttempts int `json:"remaining_attempts" example:"4"`
+}
+
+type OtpRateLimitResponseData struct {
+	// RetryAfter is the number of seconds until a new otp can be requested.
+	RetryAfter int64 `json:"retry_after" example:"60"`
+}
@@ -0,0 +1,49 @@
+package responses
+
+import (
+	"lbe/codes"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 problem details of a failed v2 request. Code carries the
+// same codes value as the v1 envelope.
+type Problem struct {
+	// Type identifies the kind of problem, one stable URI per code.
+	Type   string `json:"type" example:"urn:lbe:problem:invalid-request-body"`
+	Title  string `json:"title" example:"invalid request body"`
+	Status int    `json:"status" example:"400"`
+	// Detail explains this occurrence of the problem.
+	Detail   string `json:"detail,omitempty" example:"invalid json request body:user.dob is required"`
+	Instance string `json:"instance,omitempty" example:"/api/v2/user/register"`
+
+	Code      int64  `json:"code" example:"4001"`
+	RequestID string `json:"request_id,omitempty" example:"3f1c9a52-7d7e-4c53-9a0e-2b1f6c8d4e10"`
+	// Errors lists the request fields failing validation.
+	Errors []ProblemFieldError `json:"errors,omitempty"`
+	// Upstream names the upstream service the request failed on.
+	Upstream *ProblemUpstream `json:"upstream,omitempty"`
+}
+
+type ProblemFieldError struct {
+	// Pointer is the JSON pointer of the field in the request body.
+	Pointer string `json:"pointer" example:"/user/dob"`
+	Detail  string `json:"detail" example:"user.dob is required"`
+}
+
+type ProblemUpstream struct {
+	Name string `json:"name" example:"rlp_core"`
+	// Code is the upstream's own error code, if it sent one.
+	Code string `json:"code,omitempty" example:"user_suspended"`
+}
+
+// NewProblem turns a v1 error response written with status into problem details.
+func NewProblem(status int, resp ApiResponse[any]) Problem {
+	return Problem{
+		Type:   codes.ProblemType(resp.Code),
+		Title:  codes.ProblemTitle(resp.Code),
+		Status: status,
+		Detail: resp.Message,
+		Code:   resp.Code,
+	}
+}
@@ -6,7 +6,9 @@ import (
 
 const (
 	// error codes
-	RlpErrorCodeUserNotFound = "user_not_found"
+	RlpErrorCodeUserNotFound  = "user_not_found"
+	RlpErrorCodeUserSuspended = "user_suspended"
+	RlpErrorCodeValidation    = "validation"
 )
 
 // GetUserResponse represents the top-level JSON
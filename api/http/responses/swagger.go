@@ -1,6 +1,10 @@
 package responses
 
-import "lbe/model"
+import (
+	"lbe/audit"
+	"lbe/model"
+	"lbe/upstream"
+)
 
 type AuthSuccessResponse struct {
 	// in: body
@@ -30,6 +34,41 @@ type CreateSuccessResponse struct {
 	Data    CreateUserResponseData `json:"data"`
 }
 
+type VerifyOtpSuccessResponse struct {
+	// in: body
+	Code    int64                 `json:"code" example:"1000"`
+	Message string                `json:"message" example:"otp verified"`
+	Data    VerifyOtpResponseData `json:"data"`
+}
+
+type AuditStatsSuccessResponse struct {
+	// in: body
+	Code    int64       `json:"code" example:"1000"`
+	Message string      `json:"message" example:"audit stats"`
+	Data    audit.Stats `json:"data"`
+}
+
+type AuditLogsSuccessResponse struct {
+	// in: body
+	Code    int64                 `json:"code" example:"1000"`
+	Message string                `json:"message" example:"audit logs found"`
+	Data    AuditLogsResponseData `json:"data"`
+}
+
+type UpstreamCallsSuccessResponse struct {
+	// in: body
+	Code    int64                   `json:"code" example:"1000"`
+	Message string                  `json:"message" example:"upstream calls found"`
+	Data    []model.UpstreamCallLog `json:"data"`
+}
+
+type UpstreamStatesSuccessResponse struct {
+	// in: body
+	Code    int64                   `json:"code" example:"1000"`
+	Message string                  `json:"message" example:"upstream states"`
+	Data    []upstream.BreakerState `json:"data"`
+}
+
 type GrExistenceSuccessResponse struct {
 	// in: body
 	Code    int64                    `json:"code" example:"1000"`
@@ -2,48 +2,86 @@ package http
 
 import (
 	v1 "lbe/api/http/controllers/v1"
+	"lbe/api/http/controllers/v1/admin"
+	"lbe/api/http/middleware"
 
 	user "lbe/api/http/controllers/v1/user"
+	"lbe/api/http/services"
 	"lbe/api/interceptor"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Routers(e *gin.RouterGroup) {
+// Routers mounts the API, its handlers reach the upstreams through the clients of app.
+func Routers(app *services.App) func(*gin.RouterGroup) {
+	userHandler := user.NewHandler(app)
+	adminHandler := admin.NewHandler(app)
 
-	v1Group := e.Group("/v1")
+	return func(e *gin.RouterGroup) {
+		routes(e, userHandler, adminHandler)
+	}
+}
+
+func routes(e *gin.RouterGroup, userHandler *user.Handler, adminHandler *admin.Handler) {
+	// v1 answers with the ApiResponse envelope, kept unchanged for existing channels
+	versionRoutes(e.Group("/v1"), userHandler, adminHandler)
+	// v2 serves the same endpoints, failed requests get RFC 7807 problem details
+	versionRoutes(e.Group("/v2", middleware.ProblemDetails()), userHandler, adminHandler)
+}
 
-	v1Group.POST("/auth", v1.AuthHandler)
+func versionRoutes(versionGroup *gin.RouterGroup, userHandler *user.Handler, adminHandler *admin.Handler) {
+	versionGroup.POST("/auth", v1.AuthHandler)
 
-	usersGroup := v1Group.Group("/user", interceptor.HttpInterceptor())
+	usersGroup := versionGroup.Group("/user", interceptor.HttpInterceptor())
 	{
 		// The endpoints below will all require a valid access token.
 		//POST - LBE-2 - api/v1/user/login - user login To be removed
-		// usersGroup.POST("/login", user.Login)
+		// usersGroup.POST("/login", userHandler.Login)
 
 		//POST - LBE-3 - api/v1/user/register/verify - verify if user email is new and unregistered
-		usersGroup.POST("/register/verify", user.VerifyUserExistence)
+		usersGroup.POST("/register/verify", userHandler.VerifyUserExistence)
 		//POST - LBE-4 - api/v1/user/register - register user based on provided fields
-		usersGroup.POST("/register", user.CreateUser)
+		usersGroup.POST("/register", userHandler.CreateUser)
 		// LBE-5 To be removed
-		// usersGroup.PUT("/pin", user.UpdateBurnPin)
+		// usersGroup.PUT("/pin", userHandler.UpdateBurnPin)
+		//POST - api/v1/user/otp/verify - verify otp and issue a short-lived verification token
+		usersGroup.POST("/otp/verify", userHandler.VerifyOtp)
 		//POST - LBE-6 - api/v1/user/gr - GR user's profile verification
-		usersGroup.POST("/gr", user.VerifyGrExistence)
+		usersGroup.POST("/gr", userHandler.VerifyGrExistence)
 		//POST - LBE-7 - api/v1/user/gr-cms - GR user's profile pushed by CMS
-		usersGroup.POST("/gr-cms", user.VerifyGrCmsExistence)
+		usersGroup.POST("/gr-cms", userHandler.VerifyGrCmsExistence)
 		//GET - LBE-8 - api/v1/user/gr-reg - verify GR user's profile pushed by CMS
-		usersGroup.GET("/gr-reg/:reg_id", user.GetCachedGrCmsProfile)
+		usersGroup.GET("/gr-reg/:reg_id", userHandler.GetCachedGrCmsProfile)
 		usersGroup.GET("/gr-reg", v1.InvalidQueryParametersHandler)
 
+		//GET - api/v1/user/lookup?type=&value= - get user profile by rlp_no, gr_id, email or ciam_id
+		usersGroup.GET("/lookup", userHandler.LookupUserProfile)
 		//GET - LBE-9 - api/v1/user/:external_id - get user profile from rlp
-		usersGroup.GET("/:external_id", user.GetUserProfile)
+		usersGroup.GET("/:external_id", userHandler.GetUserProfile)
 		usersGroup.GET("", v1.InvalidQueryParametersHandler)
 		//PUT - LBE-10 - api/v1/user/update/:external_id - update user profile
-		usersGroup.PUT("/update/:external_id", user.UpdateUserProfile)
+		usersGroup.PUT("/update/:external_id", userHandler.UpdateUserProfile)
 		usersGroup.PUT("/update", v1.InvalidQueryParametersHandler)
 		//PUT - LBE-11 - api/v1/user/archive - withdraw user profile (active_status=0, previous email=current email, email=null)
-		usersGroup.PUT("/archive/:external_id", user.WithdrawUserProfile)
+		usersGroup.PUT("/archive/:external_id", userHandler.WithdrawUserProfile)
 		usersGroup.PUT("/archive", v1.InvalidQueryParametersHandler)
 	}
 
+	adminGroup := versionGroup.Group("/admin", interceptor.HttpInterceptor(), interceptor.AdminInterceptor())
+	{
+		// The endpoints below are restricted to the channels listed in application.admin.appIds.
+		//POST - api/v1/admin/registrations/:rlp_id/retry - re-drive a stuck registration
+		adminGroup.POST("/registrations/:rlp_id/retry", adminHandler.RetryRegistration)
+		//GET - api/v1/admin/audit/stats - audit log queue depth, drop and failure counters
+		adminGroup.GET("/audit/stats", admin.GetAuditStats)
+		//GET - api/v1/admin/audit/logs - search audit log entries, cursor paginated
+		adminGroup.GET("/audit/logs", admin.SearchAuditLogs)
+		//GET - api/v1/admin/audit/logs/export?format=csv|jsonl - export matching audit log entries
+		adminGroup.GET("/audit/logs/export", admin.ExportAuditLogs)
+		//GET - api/v1/admin/audit/upstream-calls?request_id= - upstream calls made while serving a request
+		adminGroup.GET("/audit/upstream-calls", admin.ListUpstreamCalls)
+		//GET - api/v1/admin/upstreams - circuit breaker state of every upstream
+		adminGroup.GET("/upstreams", admin.GetUpstreamStates)
+	}
+
 }
@@ -7,7 +7,6 @@ import (
 	"lbe/config"
 	"lbe/model"
 	"lbe/utils"
-	"log"
 	"net/http"
 	"strings"
 )
@@ -24,13 +23,22 @@ const (
 	AcsEmailTemplateRequestOtp = "request_email_otp"
 )
 
+// acsTokenProvider caches the ACS token, the auth response carries no expiry so the default ttl applies.
+var acsTokenProvider = utils.NewTokenProvider("acs", func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+	token, err := getAcsAccessToken(ctx, client)
+	if err != nil {
+		return utils.AccessToken{}, err
+	}
+	return utils.AccessToken{Token: token}, nil
+})
+
 func getAcsAccessToken(ctx context.Context, client *http.Client) (string, error) {
 	appId := config.GetConfig().Api.Acs.AppId
 	secretKey := config.GetConfig().Api.Acs.Secret
 	reqBody, err := GenerateSignature(appId, secretKey)
 
 	if err != nil {
-		log.Printf("unable to generate auth signature: %v", err)
+		utils.Logf(ctx, "unable to generate auth signature: %v", err)
 		return "", err
 	}
 
@@ -47,6 +55,7 @@ func getAcsAccessToken(ctx context.Context, client *http.Client) (string, error)
 		Client:         client,
 		Context:        ctx,
 		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamAcs,
 	}); err != nil {
 		return "", err
 	} else {
@@ -54,30 +63,38 @@ func getAcsAccessToken(ctx context.Context, client *http.Client) (string, error)
 	}
 }
 
-func PostAcsSendEmailByTemplate(ctx context.Context, client *http.Client, templateName string, payload any) error {
-	bearerToken, err := getAcsAccessToken(ctx, client)
-	if err != nil {
-		log.Printf("error getting acs token: %v", err)
-		return err
-	}
+// AcsClient sends emails through ACS.
+type AcsClient interface {
+	SendEmailByTemplate(ctx context.Context, templateName string, payload any) error
+}
+
+type acsClient struct {
+	client *http.Client
+}
+
+func NewAcsClient(client *http.Client) AcsClient {
+	return &acsClient{client: client}
+}
+
+func (a *acsClient) SendEmailByTemplate(ctx context.Context, templateName string, payload any) error {
 	headers := map[string]string{
 		"AppID": config.GetConfig().Api.Acs.AppId,
 	}
 
 	url := strings.ReplaceAll(AcsSendEmailByTemplateURL, ":template_name", templateName)
 
-	if _, _, err := utils.DoAPIRequest[struct{}](model.APIRequestOptions{
+	if _, _, err := utils.DoAuthorizedRequest[struct{}](acsTokenProvider, model.APIRequestOptions{
 		Method:         http.MethodPost,
 		URL:            buildFullAcsUrl(url),
 		Body:           payload,
-		BearerToken:    bearerToken,
 		ExpectedStatus: http.StatusOK,
 		Headers:        headers,
-		Client:         client,
+		Client:         a.client,
 		Context:        ctx,
 		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamAcs,
 	}); err != nil {
-		return err
+		return upstreamError(model.UpstreamAcs, err, "", "", nil)
 	}
 
 	return nil
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"net/http"
+
+	mycache "lbe/cache"
+	"lbe/system"
+)
+
+// App holds the upstream clients, services and stores the handlers depend on. It
+// is built once at start up; tests build one from the in-memory fakes of
+// lbe/api/http/services/fakes instead.
+type App struct {
+	Ciam   CiamClient
+	Rlp    RlpClient
+	Cms    CmsClient
+	Acs    AcsClient
+	Member MemberClient
+	Otp    OTPService
+
+	Numbering     RlpNumbering
+	GrProfiles    GrProfileCache
+	Registrations RegistrationStore
+	Locker        mycache.Locker
+}
+
+// NewApp calls every upstream through client, the policy of each upstream
+// is added per call.
+func NewApp(client *http.Client) *App {
+	return &App{
+		Ciam:   NewCiamClient(client),
+		Rlp:    NewRlpClient(client),
+		Cms:    NewCmsClient(client),
+		Acs:    NewAcsClient(client),
+		Member: NewMemberClient(client),
+		Otp:    NewOTPService(system.GetRedis()),
+
+		Numbering:     NewRlpNumbering(),
+		GrProfiles:    NewGrProfileCache(),
+		Registrations: NewRegistrationStore(),
+		Locker:        mycache.GetLocker(),
+	}
+}
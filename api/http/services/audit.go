@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"lbe/api/http/requests"
+	"lbe/model"
+	"lbe/system"
+
+	"gorm.io/gorm"
+)
+
+// auditExportBatchSize is the number of entries loaded per query while exporting.
+const auditExportBatchSize = 500
+
+var ErrInvalidAuditCursor = errors.New("invalid audit log cursor")
+
+// SearchAuditLogs returns one page of the entries matching q, newest first, and
+// the cursor of the next page, empty on the last page.
+func SearchAuditLogs(ctx context.Context, q requests.AuditLogQuery) ([]model.AuditLog, string, error) {
+	scope, err := auditLogScope(q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// one extra row tells whether another page follows
+	var entries []model.AuditLog
+	if err := system.GetDb().WithContext(ctx).Scopes(scope).Limit(q.Limit + 1).Find(&entries).Error; err != nil {
+		return nil, "", err
+	}
+
+	if len(entries) <= q.Limit {
+		return entries, "", nil
+	}
+	entries = entries[:q.Limit]
+	return entries, encodeAuditCursor(entries[len(entries)-1].ID), nil
+}
+
+// ExportAuditLogs calls fn for every entry matching q, newest first, loading them
+// in batches so exports of any size run in constant memory.
+func ExportAuditLogs(ctx context.Context, q requests.AuditLogQuery, fn func(model.AuditLog) error) error {
+	scope, err := auditLogScope(q)
+	if err != nil {
+		return err
+	}
+
+	var lastId uint
+	for {
+		query := system.GetDb().WithContext(ctx).Scopes(scope)
+		if lastId != 0 {
+			// keyset pagination, continuing below the last id written
+			query = query.Where("id < ?", lastId)
+		}
+
+		var batch []model.AuditLog
+		if err := query.Limit(auditExportBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range batch {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(batch) < auditExportBatchSize {
+			return nil
+		}
+		lastId = batch[len(batch)-1].ID
+	}
+}
+
+// ListUpstreamCalls returns the upstream calls made while serving the request
+// with q.RequestID, in the order they were made.
+func ListUpstreamCalls(ctx context.Context, q requests.UpstreamCallQuery) ([]model.UpstreamCallLog, error) {
+	var calls []model.UpstreamCallLog
+	err := system.GetDb().WithContext(ctx).
+		Where("request_id = ?", q.RequestID).
+		Order("id").
+		Limit(requests.MaxAuditLogPageSize).
+		Find(&calls).Error
+	return calls, err
+}
+
+func auditLogScope(q requests.AuditLogQuery) (func(*gorm.DB) *gorm.DB, error) {
+	var cursor uint64
+	if q.Cursor != "" {
+		var err error
+		if cursor, err = decodeAuditCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&model.AuditLog{}).Order("id DESC")
+		if q.RequestID != "" {
+			db = db.Where("request_id = ?", q.RequestID)
+		}
+		if q.Actor != "" {
+			db = db.Where("actor_id = ?", q.Actor)
+		}
+		if q.Path != "" {
+			db = db.Where("path = ?", q.Path)
+		}
+		if q.RequestPath != "" {
+			db = db.Where("request_path = ?", q.RequestPath)
+		}
+		if q.StatusCode != 0 {
+			db = db.Where("status_code = ?", q.StatusCode)
+		}
+		if !q.From.IsZero() {
+			db = db.Where("created_at >= ?", q.From)
+		}
+		if !q.To.IsZero() {
+			db = db.Where("created_at < ?", q.To)
+		}
+		if q.MinLatencyMs > 0 {
+			db = db.Where("latency_ms >= ?", q.MinLatencyMs)
+		}
+		if cursor != 0 {
+			db = db.Where("id < ?", cursor)
+		}
+		return db
+	}, nil
+}
+
+// the cursor is the id of the last entry returned, kept opaque to callers
+func encodeAuditCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeAuditCursor(cursor string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidAuditCursor
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidAuditCursor
+	}
+	return id, nil
+}
@@ -4,13 +4,13 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
-	"lbe/api/http/requests"
 	"lbe/api/http/responses"
+	"lbe/ciam"
 	"lbe/config"
 	"lbe/model"
 	"lbe/utils"
@@ -18,13 +18,25 @@ import (
 
 // Endpoints
 const (
-	CiamAuthURL      = "/oauth2/v2.0/token"
-	CiamUserURL      = "/v1.0/users"
-	extensionURL     = "/v1.0/users/:id/extensions"
-	extensionDataURL = "/v1.0/users/:id/extensions/:extensionsid"
+	CiamAuthURL = "/oauth2/v2.0/token"
 )
 
+// ciamTokenProvider caches the Graph token shared by all CIAM calls.
+var ciamTokenProvider = utils.NewTokenProvider("ciam", func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
+	if err != nil {
+		return utils.AccessToken{}, err
+	}
+
+	token := utils.AccessToken{Token: tokenResp.AccessToken}
+	if tokenResp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+})
+
 // GetCIAMAccessToken acquires a bearer token from Azure AD using client credentials.
+// API calls should go through ciamTokenProvider rather than calling it directly.
 func GetCIAMAccessToken(ctx context.Context, client *http.Client) (*responses.TokenResponse, []byte, error) {
 	cfg := config.GetConfig().Api.Eeid
 
@@ -50,134 +62,24 @@ func GetCIAMAccessToken(ctx context.Context, client *http.Client) (*responses.To
 		Client:         client,
 		Context:        ctx,
 		ContentType:    model.ContentTypeForm,
+		Upstream:       model.UpstreamCiam,
 	})
 }
 
-// GetCIAMUserByEmail calls Graph GET /users?$filter=mail eq '{email}'.
-func GetCIAMUserByEmail(ctx context.Context, client *http.Client, email string) (*responses.GraphUserCollection, []byte, error) {
-	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
-	if err != nil {
-		return nil, nil, fmt.Errorf("getting access token: %w", err)
-	}
-	// extract the actual bearer token
-	bearer := tokenResp.AccessToken
-
-	cfg := config.GetConfig().Api.Eeid
-	base := strings.TrimRight(cfg.Host, "/")
-	filter := url.QueryEscape(fmt.Sprintf("mail eq '%s'", email))
-	fullURL := fmt.Sprintf("%s%s?$filter=%s", base, CiamUserURL, filter)
-
-	return utils.DoAPIRequest[responses.GraphUserCollection](model.APIRequestOptions{
-		Method:         http.MethodGet,
-		URL:            fullURL,
-		Body:           nil,
-		BearerToken:    bearer,
-		ExpectedStatus: http.StatusOK,
-		Client:         client,
-		Context:        ctx,
-		ContentType:    model.ContentTypeJson,
-	})
+// CiamClient manages the CIAM users of members.
+type CiamClient interface {
+	FindUsersByEmail(ctx context.Context, email string) ([]ciam.User, error)
+	FindUsersByGrId(ctx context.Context, grId string) ([]ciam.User, error)
+	GetUser(ctx context.Context, id string) (*ciam.User, error)
+	CreateUser(ctx context.Context, payload any) (*ciam.User, error)
+	UpdateUser(ctx context.Context, id string, payload any) error
+	DeleteUser(ctx context.Context, id string) error
+	// SetUserIdLink writes the RLP and GR identifiers into the user id link schema extension.
+	SetUserIdLink(ctx context.Context, id string, link ciam.UserIdLink) error
 }
 
-// GetCIAMUserByGrId calls Graph GET /users?$filter={schemaIdKey}/grid eq '{grId}'.
-func GetCIAMUserByGrId(ctx context.Context, client *http.Client, grId string) (*responses.GraphUserCollection, []byte, error) {
-	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
-	if err != nil {
-		return nil, nil, fmt.Errorf("getting access token: %w", err)
-	}
-	// extract the actual bearer token
-	bearer := tokenResp.AccessToken
-
+// NewCiamClient returns the Graph client of the CIAM tenant.
+func NewCiamClient(client *http.Client) CiamClient {
 	cfg := config.GetConfig().Api.Eeid
-	base := strings.TrimRight(cfg.Host, "/")
-	filter := url.QueryEscape(fmt.Sprintf("%s/grid eq '%s'", cfg.UserIdLinkExtensionKey, grId))
-	fullURL := fmt.Sprintf("%s%s?$filter=%s", base, CiamUserURL, filter)
-
-	return utils.DoAPIRequest[responses.GraphUserCollection](model.APIRequestOptions{
-		Method:         http.MethodGet,
-		URL:            fullURL,
-		Body:           nil,
-		BearerToken:    bearer,
-		ExpectedStatus: http.StatusOK,
-		Client:         client,
-		Context:        ctx,
-		ContentType:    model.ContentTypeJson,
-	})
-}
-
-// PostCIAMRegisterUser calls Graph POST /users to create a new AD user.
-func PostCIAMRegisterUser(ctx context.Context, client *http.Client, payload requests.GraphCreateUserRequest) (*responses.GraphCreateUserResponse, []byte, error) {
-	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
-	if err != nil {
-		return nil, nil, fmt.Errorf("getting access token: %w", err)
-	}
-	// extract the actual bearer token
-	bearer := tokenResp.AccessToken
-
-	cfg := config.GetConfig().Api.Eeid
-	base := strings.TrimRight(cfg.Host, "/")
-	fullURL := fmt.Sprintf("%s%s", base, CiamUserURL)
-
-	log.Printf("registering user: %v", payload.Mail)
-	return utils.DoAPIRequest[responses.GraphCreateUserResponse](model.APIRequestOptions{
-		Method:         http.MethodPost,
-		URL:            fullURL,
-		Body:           payload,
-		BearerToken:    bearer,
-		ExpectedStatus: http.StatusCreated,
-		Client:         client,
-		Context:        ctx,
-		ContentType:    model.ContentTypeJson,
-	})
-}
-
-func PatchCIAMAddUserSchemaExtensions(ctx context.Context, client *http.Client, userId string, payload any) ([]byte, error) {
-	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
-	if err != nil {
-		return nil, fmt.Errorf("getting access token: %w", err)
-	}
-	// extract the actual bearer token
-	bearer := tokenResp.AccessToken
-
-	cfg := config.GetConfig().Api.Eeid
-	base := strings.TrimRight(cfg.Host, "/")
-	fullURL := fmt.Sprintf("%s%s/%s", base, CiamUserURL, userId)
-
-	_, raw, err := utils.DoAPIRequest[struct{}](model.APIRequestOptions{
-		Method:         http.MethodPatch,
-		URL:            fullURL,
-		Body:           payload,
-		BearerToken:    bearer,
-		ExpectedStatus: http.StatusNoContent,
-		Client:         client,
-		Context:        ctx,
-		ContentType:    model.ContentTypeJson,
-	})
-	return raw, err
-}
-
-func PatchCIAMUpdateUser(ctx context.Context, client *http.Client, userId string, payload any) ([]byte, error) {
-	tokenResp, _, err := GetCIAMAccessToken(ctx, client)
-	if err != nil {
-		return nil, fmt.Errorf("getting access token: %w", err)
-	}
-	// extract the actual bearer token
-	bearer := tokenResp.AccessToken
-
-	cfg := config.GetConfig().Api.Eeid
-	base := strings.TrimRight(cfg.Host, "/")
-	fullURL := fmt.Sprintf("%s%s/%s", base, CiamUserURL, userId)
-
-	log.Printf("patching CIAM user id: %v", userId)
-	_, raw, err := utils.DoAPIRequest[struct{}](model.APIRequestOptions{
-		Method:         http.MethodPatch,
-		URL:            fullURL,
-		Body:           payload,
-		BearerToken:    bearer,
-		ExpectedStatus: http.StatusNoContent,
-		Client:         client,
-		Context:        ctx,
-		ContentType:    model.ContentTypeJson,
-	})
-	return raw, err
+	return ciam.NewClient(client, cfg.Host, cfg.UserIdLinkExtensionKey, ciamTokenProvider)
 }
@@ -2,15 +2,16 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"strings"
 
 	"lbe/api/http/responses"
 	"lbe/config"
+	"lbe/model"
+	"lbe/utils"
 	"net/http"
+	"net/url"
 )
 
 // Endpoints
@@ -18,45 +19,46 @@ const (
 	GetMemberURL = "/cms-webapi-bsp/v2/member"
 )
 
-// TODO: Fix to correct spec
-func GRMemberProfile(memberId string, payload any, operation string, endpoint string) (*responses.GRProfilePayload, error) {
-	conf := config.GetConfig()
-	urlWithParams := fmt.Sprintf("%s%s?systemId=%s&memberId=%s", conf.Api.Cms.Host, endpoint, conf.Api.Cms.SystemID, memberId)
-
-	resp, err := buildHttpClient(operation, urlWithParams, payload)
-	if err != nil {
-		return nil, err
-	}
+var ErrGrMemberNotFound = errors.New("gr member not found")
 
-	if resp.StatusCode != http.StatusOK {
-		// Read the response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			// In case of error reading the body, just return the status code
-			return nil, fmt.Errorf("error calling CMS services: received status code %d, but failed to read response body: %v", resp.StatusCode, err)
-		}
-		defer resp.Body.Close()
+// CmsClient reads GR member profiles from CMS.
+type CmsClient interface {
+	// GRMemberProfile fetches a GR member's CMS profile, ErrGrMemberNotFound is
+	// returned when CMS does not know the member.
+	GRMemberProfile(ctx context.Context, memberId string) (*responses.GRProfilePayload, error)
+}
 
-		return nil, fmt.Errorf("error calling CMS services: received status code %d and response: %s", resp.StatusCode, string(body))
-	}
+type cmsClient struct {
+	client *http.Client
+}
 
-	// on 200 OK, read & clean the body
-	raw, err := io.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
+func NewCmsClient(client *http.Client) CmsClient {
+	return &cmsClient{client: client}
+}
 
-	// 1) strip UTF-8 BOM if present
-	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
-	// 2) replace any non-breaking space (U+00A0) with a normal space
-	raw = []byte(strings.ReplaceAll(string(raw), "\u00A0", " "))
+// TODO: Fix to correct spec
+func (c *cmsClient) GRMemberProfile(ctx context.Context, memberId string) (*responses.GRProfilePayload, error) {
+	conf := config.GetConfig()
+	query := url.Values{}
+	query.Set("systemId", conf.Api.Cms.SystemID)
+	query.Set("memberId", memberId)
+	urlWithParams := conf.Api.Cms.Host + GetMemberURL + "?" + query.Encode()
 
-	// now unmarshal into your struct
-	var result responses.GRProfilePayload
-	if err := json.Unmarshal(raw, &result); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON: %w; cleaned body: %q", err, raw)
+	profile, _, err := utils.DoAPIRequest[responses.GRProfilePayload](model.APIRequestOptions{
+		Method:         http.MethodGet,
+		URL:            urlWithParams,
+		ExpectedStatus: http.StatusOK,
+		Client:         c.client,
+		Context:        ctx,
+		Upstream:       model.UpstreamCms,
+	})
+	if err != nil {
+		var statusErr *utils.UnexpectedStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, ErrGrMemberNotFound
+		}
+		return nil, fmt.Errorf("error calling CMS services: %w", upstreamError(model.UpstreamCms, err, "", "", nil))
 	}
 
-	return &result, nil
+	return profile, nil
 }
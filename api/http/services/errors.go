@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"lbe/utils"
+)
+
+// Kinds of upstream failure, matched with errors.Is on an *UpstreamError.
+var (
+	ErrUpstreamNotFound    = errors.New("upstream: not found")
+	ErrUpstreamConflict    = errors.New("upstream: conflict")
+	ErrUpstreamUnavailable = errors.New("upstream: unavailable")
+	ErrUpstreamValidation  = errors.New("upstream: validation failed")
+)
+
+// UpstreamError is a failed upstream call together with what went wrong. Kind is one
+// of the ErrUpstream* errors, nil when the failure could not be classified. Err stays
+// reachable, so errors.As on *utils.UnexpectedStatusError and utils.IsTransientError
+// keep working on it.
+type UpstreamError struct {
+	Upstream string
+	Kind     error
+	// Code and Message are the upstream's own error code and message, if it sent any
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s: %v", e.Upstream, e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Upstream, e.Err)
+}
+
+func (e *UpstreamError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// upstreamError wraps an error of a call to upstream into an *UpstreamError. The kind
+// is looked up by the upstream's error code in kinds first, then derived from the HTTP
+// status. Transport failures, timeouts and open circuits count as unavailable.
+func upstreamError(upstream string, err error, code, message string, kinds map[string]error) error {
+	if err == nil {
+		return nil
+	}
+
+	upstreamErr := &UpstreamError{Upstream: upstream, Code: code, Message: message, Err: err}
+	if kind, ok := kinds[code]; ok && code != "" {
+		upstreamErr.Kind = kind
+		return upstreamErr
+	}
+
+	var statusErr *utils.UnexpectedStatusError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusNotFound:
+			upstreamErr.Kind = ErrUpstreamNotFound
+		case http.StatusConflict:
+			upstreamErr.Kind = ErrUpstreamConflict
+		case http.StatusUnprocessableEntity:
+			upstreamErr.Kind = ErrUpstreamValidation
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			upstreamErr.Kind = ErrUpstreamUnavailable
+		}
+	case errors.As(err, &urlErr):
+		upstreamErr.Kind = ErrUpstreamUnavailable
+	}
+	return upstreamErr
+}
@@ -0,0 +1,39 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+// Email is one email sent through Acs.
+type Email struct {
+	Template string
+	Payload  any
+}
+
+// Acs records the emails it is asked to send.
+type Acs struct {
+	// Err fails every send when set
+	Err error
+
+	mu   sync.Mutex
+	sent []Email
+}
+
+func (f *Acs) SendEmailByTemplate(ctx context.Context, templateName string, payload any) error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, Email{Template: templateName, Payload: payload})
+	return nil
+}
+
+// Sent returns the emails sent so far.
+func (f *Acs) Sent() []Email {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Email(nil), f.sent...)
+}
@@ -0,0 +1,175 @@
+package fakes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"lbe/ciam"
+
+	"github.com/google/uuid"
+)
+
+// Ciam is an in-memory CIAM tenant. Creating a user whose mail is taken fails
+// with an ObjectConflict like Graph does.
+type Ciam struct {
+	// the errors fail the matching calls when set
+	FindByEmailErr error
+	FindByGrIdErr  error
+	GetErr         error
+	CreateErr      error
+	UpdateErr      error
+	LinkErr        error
+
+	mu    sync.Mutex
+	users []ciam.User
+}
+
+func NewCiam() *Ciam {
+	return &Ciam{}
+}
+
+// AddUser seeds a user, an id is assigned when it has none.
+func (f *Ciam) AddUser(user ciam.User) ciam.User {
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users = append(f.users, user)
+	return user
+}
+
+// Users returns a copy of the users of the tenant.
+func (f *Ciam) Users() []ciam.User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ciam.User(nil), f.users...)
+}
+
+func (f *Ciam) FindUsersByEmail(ctx context.Context, email string) ([]ciam.User, error) {
+	if f.FindByEmailErr != nil {
+		return nil, f.FindByEmailErr
+	}
+	return f.find(func(u ciam.User) bool { return strings.EqualFold(u.Mail, email) })
+}
+
+func (f *Ciam) FindUsersByGrId(ctx context.Context, grId string) ([]ciam.User, error) {
+	if f.FindByGrIdErr != nil {
+		return nil, f.FindByGrIdErr
+	}
+	return f.find(func(u ciam.User) bool { return u.UserIdLink != nil && u.UserIdLink.GrId == grId })
+}
+
+func (f *Ciam) find(match func(ciam.User) bool) ([]ciam.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var users []ciam.User
+	for _, u := range f.users {
+		if match(u) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (f *Ciam) GetUser(ctx context.Context, id string) (*ciam.User, error) {
+	if f.GetErr != nil {
+		return nil, f.GetErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.index(id)
+	if i < 0 {
+		return nil, notFound()
+	}
+	user := f.users[i]
+	return &user, nil
+}
+
+func (f *Ciam) CreateUser(ctx context.Context, payload any) (*ciam.User, error) {
+	if f.CreateErr != nil {
+		return nil, f.CreateErr
+	}
+
+	var user ciam.User
+	if err := convert(payload, &user); err != nil {
+		return nil, err
+	}
+	user.ID = uuid.NewString()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if user.Mail != "" && strings.EqualFold(u.Mail, user.Mail) {
+			return nil, &ciam.GraphError{
+				StatusCode: http.StatusBadRequest,
+				Code:       ciam.CodeObjectConflict,
+				Message:    "Another object with the same value for property mail already exists.",
+			}
+		}
+	}
+	f.users = append(f.users, user)
+	return &user, nil
+}
+
+// UpdateUser applies the fields set in payload to the user.
+func (f *Ciam) UpdateUser(ctx context.Context, id string, payload any) error {
+	if f.UpdateErr != nil {
+		return f.UpdateErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.index(id)
+	if i < 0 {
+		return notFound()
+	}
+	return convert(payload, &f.users[i])
+}
+
+func (f *Ciam) DeleteUser(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.index(id)
+	if i < 0 {
+		return notFound()
+	}
+	f.users = append(f.users[:i], f.users[i+1:]...)
+	return nil
+}
+
+func (f *Ciam) SetUserIdLink(ctx context.Context, id string, link ciam.UserIdLink) error {
+	if f.LinkErr != nil {
+		return f.LinkErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.index(id)
+	if i < 0 {
+		return notFound()
+	}
+	f.users[i].UserIdLink = &link
+	return nil
+}
+
+func (f *Ciam) index(id string) int {
+	for i, u := range f.users {
+		if u.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func notFound() error {
+	return &ciam.GraphError{
+		StatusCode: http.StatusNotFound,
+		Code:       ciam.CodeResourceNotFound,
+		Message:    "Resource does not exist or one of its queried reference-property objects are not present.",
+	}
+}
@@ -0,0 +1,43 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+)
+
+// Cms is an in-memory CMS keyed by GR member id.
+type Cms struct {
+	// Err fails every call when set
+	Err error
+
+	mu      sync.Mutex
+	members map[string]responses.GRProfilePayload
+}
+
+func NewCms() *Cms {
+	return &Cms{members: map[string]responses.GRProfilePayload{}}
+}
+
+// AddMember seeds the profile of memberId.
+func (f *Cms) AddMember(memberId string, profile responses.GRProfilePayload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[memberId] = profile
+}
+
+func (f *Cms) GRMemberProfile(ctx context.Context, memberId string) (*responses.GRProfilePayload, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profile, ok := f.members[memberId]
+	if !ok {
+		return nil, services.ErrGrMemberNotFound
+	}
+	return &profile, nil
+}
@@ -0,0 +1,88 @@
+// Package fakes holds in-memory implementations of the upstream clients and
+// stores of services.App. Handlers built on them run without HTTP mocking, so tests
+// using one set of fakes per case can run in parallel.
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"lbe/api/http/services"
+	mycache "lbe/cache"
+	"lbe/utils"
+)
+
+var (
+	_ services.CiamClient   = (*Ciam)(nil)
+	_ services.RlpClient    = (*Rlp)(nil)
+	_ services.CmsClient    = (*Cms)(nil)
+	_ services.AcsClient    = (*Acs)(nil)
+	_ services.MemberClient = (*Member)(nil)
+	_ services.OTPService   = (*Otp)(nil)
+
+	_ services.RlpNumbering      = (*Numbering)(nil)
+	_ services.GrProfileCache    = (*GrProfiles)(nil)
+	_ services.RegistrationStore = (*Registrations)(nil)
+)
+
+// Upstreams is one set of fakes, each test case should build its own.
+type Upstreams struct {
+	Ciam   *Ciam
+	Rlp    *Rlp
+	Cms    *Cms
+	Acs    *Acs
+	Member *Member
+	Otp    *Otp
+
+	Numbering     *Numbering
+	GrProfiles    *GrProfiles
+	Registrations *Registrations
+	Locker        mycache.Locker
+}
+
+func New() *Upstreams {
+	return &Upstreams{
+		Ciam:   NewCiam(),
+		Rlp:    NewRlp(),
+		Cms:    NewCms(),
+		Acs:    &Acs{},
+		Member: &Member{},
+		Otp:    NewOtp(),
+
+		Numbering:     NewNumbering(),
+		GrProfiles:    NewGrProfiles(),
+		Registrations: NewRegistrations(),
+		Locker:        mycache.NewMemoryLocker(),
+	}
+}
+
+// App wires the fakes into a services.App.
+func (u *Upstreams) App() *services.App {
+	return &services.App{
+		Ciam:   u.Ciam,
+		Rlp:    u.Rlp,
+		Cms:    u.Cms,
+		Acs:    u.Acs,
+		Member: u.Member,
+		Otp:    u.Otp,
+
+		Numbering:     u.Numbering,
+		GrProfiles:    u.GrProfiles,
+		Registrations: u.Registrations,
+		Locker:        u.Locker,
+	}
+}
+
+// ServerError is what a call failing with an upstream 500 returns.
+func ServerError() error {
+	return &utils.UnexpectedStatusError{StatusCode: http.StatusInternalServerError}
+}
+
+// convert copies payload into out through JSON, the way the real clients send it.
+func convert(payload any, out any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,44 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lbe/api/http/services"
+	"lbe/model"
+)
+
+// GrProfiles is an in-memory GrProfileCache. Entries do not expire.
+type GrProfiles struct {
+	mu       sync.Mutex
+	profiles map[string]model.User
+}
+
+func NewGrProfiles() *GrProfiles {
+	return &GrProfiles{profiles: map[string]model.User{}}
+}
+
+func (f *GrProfiles) Set(ctx context.Context, regId string, user model.User, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles[regId] = user
+	return nil
+}
+
+func (f *GrProfiles) Get(ctx context.Context, regId string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.profiles[regId]
+	if !ok {
+		return nil, services.ErrGrProfileNotFound
+	}
+	return &user, nil
+}
+
+func (f *GrProfiles) Delete(ctx context.Context, regId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.profiles, regId)
+	return nil
+}
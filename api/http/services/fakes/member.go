@@ -0,0 +1,60 @@
+package fakes
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/codes"
+	"lbe/model"
+)
+
+// Member is an in-memory member service keyed by email.
+type Member struct {
+	// Err fails every call when set
+	Err error
+
+	mu         sync.Mutex
+	registered []requests.CreateMemberUser
+	burnPins   []any
+}
+
+// VerifyMemberExistence finds members registered through PostRegisterUser.
+func (f *Member) VerifyMemberExistence(ctx context.Context, email string, updateSessionToken bool) (*responses.ApiResponse[model.LoginSessionToken], error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.registered {
+		if strings.EqualFold(m.User.Email, email) {
+			return &responses.ApiResponse[model.LoginSessionToken]{Code: codes.FOUND, Message: "member found"}, nil
+		}
+	}
+	return &responses.ApiResponse[model.LoginSessionToken]{Code: codes.NOT_FOUND, Message: "member not found"}, nil
+}
+
+func (f *Member) PostRegisterUser(ctx context.Context, payload requests.CreateMemberUser) error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.registered = append(f.registered, payload)
+	return nil
+}
+
+func (f *Member) UpdateBurnPin(ctx context.Context, payload any) error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.burnPins = append(f.burnPins, payload)
+	return nil
+}
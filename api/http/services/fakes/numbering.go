@@ -0,0 +1,90 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lbe/api/http/services"
+	"lbe/model"
+	"lbe/utils"
+)
+
+// Numbering is an in-memory RlpNumbering handing out consecutive numbers.
+type Numbering struct {
+	// Err fails Next when set
+	Err error
+
+	mu          sync.Mutex
+	seq         int64
+	issued      map[string]model.RLPUserNumbering
+	releasedIds []string
+}
+
+func NewNumbering() *Numbering {
+	return &Numbering{issued: map[string]model.RLPUserNumbering{}}
+}
+
+// Add seeds an allocated numbering.
+func (f *Numbering) Add(numbering model.RLPUserNumbering) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issued[numbering.RLP_ID] = numbering
+}
+
+// Released returns the RLP_IDs given back through Release.
+func (f *Numbering) Released() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.releasedIds...)
+}
+
+func (f *Numbering) Next(ctx context.Context) (*model.RLPUserNumbering, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+
+	now := time.Now()
+	rlpId, err := utils.FormatRLPId(now, f.seq)
+	if err != nil {
+		return nil, err
+	}
+	rlpNo, err := utils.FormatRLPNo(f.seq)
+	if err != nil {
+		return nil, err
+	}
+
+	numbering := model.RLPUserNumbering{
+		Year:          int64(now.Year() % 100),
+		Month:         int64(now.Month()),
+		Day:           int64(now.Day()),
+		RLP_ID:        rlpId,
+		RLP_NO:        rlpNo,
+		RLPIDEndingNO: int(f.seq),
+	}
+	f.issued[rlpId] = numbering
+	return &numbering, nil
+}
+
+func (f *Numbering) Release(ctx context.Context, rlpId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.issued, rlpId)
+	f.releasedIds = append(f.releasedIds, rlpId)
+	return nil
+}
+
+func (f *Numbering) ByRlpNo(ctx context.Context, rlpNo string) (*model.RLPUserNumbering, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, numbering := range f.issued {
+		if numbering.RLP_NO == rlpNo {
+			return &numbering, nil
+		}
+	}
+	return nil, services.ErrMemberNotFound
+}
@@ -0,0 +1,148 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lbe/api/http/services"
+	"lbe/codes"
+	"lbe/model"
+
+	"github.com/google/uuid"
+)
+
+// Otp is an in-memory OTPService. It issues Code for every purpose and
+// identifier and does not throttle sending.
+type Otp struct {
+	Code        string
+	MaxAttempts int
+	Expiry      time.Duration
+	// GenerateErr fails GenerateOTP when set, e.g. with a *services.OtpLimitError
+	GenerateErr error
+
+	mu            sync.Mutex
+	otps          map[string]string
+	attempts      map[string]int
+	verifications map[string]services.OtpVerificationRecord
+}
+
+func NewOtp() *Otp {
+	return &Otp{
+		Code:          "123456",
+		MaxAttempts:   5,
+		Expiry:        5 * time.Minute,
+		otps:          map[string]string{},
+		attempts:      map[string]int{},
+		verifications: map[string]services.OtpVerificationRecord{},
+	}
+}
+
+func otpKey(purpose, identifier string) string {
+	return purpose + ":" + identifier
+}
+
+// AddVerification seeds a verification token.
+func (f *Otp) AddVerification(token string, record services.OtpVerificationRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verifications[token] = record
+}
+
+// Verification reports whether token is still valid.
+func (f *Otp) Verification(token string) (services.OtpVerificationRecord, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.verifications[token]
+	return record, ok
+}
+
+// Issued returns the otp outstanding for purpose and identifier.
+func (f *Otp) Issued(purpose, identifier string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	otp, ok := f.otps[otpKey(purpose, identifier)]
+	return otp, ok
+}
+
+func (f *Otp) GenerateOTP(ctx context.Context, purpose string, identifier string) (model.Otp, error) {
+	if f.GenerateErr != nil {
+		return model.Otp{}, f.GenerateErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := otpKey(purpose, identifier)
+	f.otps[key] = f.Code
+	delete(f.attempts, key)
+
+	otp := f.Code
+	expiresAt := time.Now().Add(f.Expiry).Unix()
+	return model.Otp{Otp: &otp, OtpExpiry: &expiresAt}, nil
+}
+
+func (f *Otp) ReleaseOTP(ctx context.Context, purpose string, identifier string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := otpKey(purpose, identifier)
+	delete(f.otps, key)
+	delete(f.attempts, key)
+	return nil
+}
+
+func (f *Otp) ValidateOTP(ctx context.Context, purpose string, identifier string, otp string) (services.OtpValidation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := otpKey(purpose, identifier)
+	stored, ok := f.otps[key]
+	if !ok {
+		return services.OtpValidation{}, services.ErrOtpNotFound
+	}
+
+	if stored != otp {
+		f.attempts[key]++
+		if f.attempts[key] >= f.MaxAttempts {
+			delete(f.otps, key)
+			delete(f.attempts, key)
+			return services.OtpValidation{}, services.ErrOtpAttemptsExceeded
+		}
+		return services.OtpValidation{RemainingAttempts: f.MaxAttempts - f.attempts[key]}, nil
+	}
+
+	delete(f.otps, key)
+	delete(f.attempts, key)
+	return services.OtpValidation{Valid: true}, nil
+}
+
+func (f *Otp) IssueVerificationToken(ctx context.Context, purpose string, identifier string) (model.OtpVerification, error) {
+	token := uuid.NewString()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verifications[token] = services.OtpVerificationRecord{
+		Purpose:    purpose,
+		Identifier: identifier,
+		SignUpType: codes.OtpPurposeSignUpType(purpose),
+	}
+	return model.OtpVerification{
+		VerificationToken:       token,
+		VerificationTokenExpiry: time.Now().Add(f.Expiry).Unix(),
+	}, nil
+}
+
+func (f *Otp) GetVerification(ctx context.Context, token string) (services.OtpVerificationRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.verifications[token]
+	if !ok {
+		return record, services.ErrVerificationTokenNotFound
+	}
+	return record, nil
+}
+
+func (f *Otp) RevokeVerificationToken(ctx context.Context, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.verifications, token)
+	return nil
+}
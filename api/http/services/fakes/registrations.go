@@ -0,0 +1,93 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lbe/api/http/services"
+	"lbe/model"
+)
+
+// Registrations is an in-memory RegistrationStore.
+type Registrations struct {
+	mu       sync.Mutex
+	attempts map[string]model.RegistrationAttempt
+	steps    []model.RegistrationStepLog
+}
+
+func NewRegistrations() *Registrations {
+	return &Registrations{attempts: map[string]model.RegistrationAttempt{}}
+}
+
+// Add seeds an attempt as it is, UpdatedAt included.
+func (f *Registrations) Add(attempt model.RegistrationAttempt) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts[attempt.RlpID] = attempt
+}
+
+// Attempt returns the stored attempt of rlpId.
+func (f *Registrations) Attempt(rlpId string) (model.RegistrationAttempt, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attempt, ok := f.attempts[rlpId]
+	return attempt, ok
+}
+
+// Steps returns the step log in the order it was written.
+func (f *Registrations) Steps() []model.RegistrationStepLog {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]model.RegistrationStepLog(nil), f.steps...)
+}
+
+func (f *Registrations) Create(ctx context.Context, attempt *model.RegistrationAttempt) error {
+	return f.Save(ctx, attempt)
+}
+
+func (f *Registrations) Get(ctx context.Context, rlpId string) (*model.RegistrationAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attempt, ok := f.attempts[rlpId]
+	if !ok {
+		return nil, services.ErrRegistrationAttemptNotFound
+	}
+	return &attempt, nil
+}
+
+func (f *Registrations) Save(ctx context.Context, attempt *model.RegistrationAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attempt.UpdatedAt = time.Now()
+	f.attempts[attempt.RlpID] = *attempt
+	return nil
+}
+
+func (f *Registrations) Claim(ctx context.Context, rlpId string, staleBefore time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attempt, ok := f.attempts[rlpId]
+	if !ok {
+		return false, nil
+	}
+
+	stuck := attempt.Status == model.RegistrationStatusInProgress && attempt.UpdatedAt.Before(staleBefore)
+	if attempt.Status != model.RegistrationStatusPendingRetry && !stuck {
+		return false, nil
+	}
+
+	attempt.Status = model.RegistrationStatusInProgress
+	attempt.RetryCount++
+	attempt.LastError = ""
+	attempt.UpdatedAt = time.Now()
+	f.attempts[rlpId] = attempt
+	return true, nil
+}
+
+func (f *Registrations) LogStep(ctx context.Context, entry *model.RegistrationStepLog) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.steps = append(f.steps, *entry)
+	return nil
+}
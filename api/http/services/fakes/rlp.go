@@ -0,0 +1,148 @@
+package fakes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	"lbe/api/http/services"
+	"lbe/model"
+	"lbe/utils"
+)
+
+// Rlp is an in-memory RLP keyed by external id. Calls on an unknown profile
+// fail with RLP's user_not_found error.
+type Rlp struct {
+	// UpdateResult, when set, is what UpdateProfile stores and returns
+	UpdateResult *responses.GetUserResponse
+
+	// CreateErr, GetErr, UpdateErr and TierErr fail the matching calls when set
+	CreateErr error
+	GetErr    error
+	UpdateErr error
+	TierErr   error
+
+	mu         sync.Mutex
+	profiles   map[string]responses.GetUserResponse
+	updates    []any
+	tierEvents []any
+}
+
+func NewRlp() *Rlp {
+	return &Rlp{profiles: map[string]responses.GetUserResponse{}}
+}
+
+// AddProfile seeds the profile of externalId.
+func (f *Rlp) AddProfile(externalId string, profile responses.GetUserResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles[externalId] = profile
+}
+
+// Profile returns the stored profile of externalId.
+func (f *Rlp) Profile(externalId string) (responses.GetUserResponse, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profile, ok := f.profiles[externalId]
+	return profile, ok
+}
+
+// Updates returns the payloads UpdateProfile was called with.
+func (f *Rlp) Updates() []any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]any(nil), f.updates...)
+}
+
+// TierEvents returns the payloads UpdateUserTier was called with.
+func (f *Rlp) TierEvents() []any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]any(nil), f.tierEvents...)
+}
+
+func (f *Rlp) CreateProfile(ctx context.Context, payload any) (*responses.GetUserResponse, error) {
+	if f.CreateErr != nil {
+		return nil, f.CreateErr
+	}
+
+	var req requests.UserProfileRequest
+	if err := convert(payload, &req); err != nil {
+		return nil, err
+	}
+
+	profile := responses.GetUserResponse{Status: "ok"}
+	profile.User.ExternalID = req.User.ExternalID
+	profile.User.OptedIn = true
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles[req.User.ExternalID] = profile
+	return &profile, nil
+}
+
+func (f *Rlp) GetProfile(ctx context.Context, externalId string) (*responses.GetUserResponse, error) {
+	if f.GetErr != nil {
+		return nil, f.GetErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profile, ok := f.profiles[externalId]
+	if !ok {
+		return nil, RlpUserNotFound()
+	}
+	return &profile, nil
+}
+
+func (f *Rlp) UpdateProfile(ctx context.Context, externalId string, payload any) (*responses.GetUserResponse, error) {
+	if f.UpdateErr != nil {
+		return nil, f.UpdateErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profile, ok := f.profiles[externalId]
+	if !ok {
+		return nil, RlpUserNotFound()
+	}
+	f.updates = append(f.updates, payload)
+
+	if f.UpdateResult != nil {
+		profile = *f.UpdateResult
+	}
+	f.profiles[externalId] = profile
+	return &profile, nil
+}
+
+func (f *Rlp) UpdateUserTier(ctx context.Context, payload any) error {
+	if f.TierErr != nil {
+		return f.TierErr
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tierEvents = append(f.tierEvents, payload)
+	return nil
+}
+
+// RlpUserNotFound is the error RLP fails calls on an unknown profile with.
+func RlpUserNotFound() error {
+	return RlpError(http.StatusBadRequest, responses.RlpErrorCodeUserNotFound, "user not found")
+}
+
+// RlpError is the error the RLP client returns when RLP answers with status and
+// the error code in its body, an empty code sends no body.
+func RlpError(status int, code, message string) error {
+	var raw []byte
+	if code != "" {
+		raw, _ = json.Marshal(responses.UserProfileErrorResponse{
+			Status: "error",
+			Errors: responses.RlpErrors{Code: code, Message: message},
+		})
+	}
+	return services.RlpError(model.UpstreamRlpCore, &utils.UnexpectedStatusError{StatusCode: status, Body: raw})
+}
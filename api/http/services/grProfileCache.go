@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lbe/model"
+	"lbe/system"
+)
+
+var ErrGrProfileNotFound = errors.New("gr profile not found")
+
+// GrProfileCache keeps the GR CMS profile of a pending GR CMS sign-up under its
+// reg_id until the member completes the registration.
+type GrProfileCache interface {
+	Set(ctx context.Context, regId string, user model.User, ttl time.Duration) error
+	// Get returns the profile cached under regId, or ErrGrProfileNotFound.
+	Get(ctx context.Context, regId string) (*model.User, error)
+	Delete(ctx context.Context, regId string) error
+}
+
+type redisGrProfileCache struct{}
+
+// NewGrProfileCache returns the GrProfileCache kept in Redis.
+func NewGrProfileCache() GrProfileCache {
+	return redisGrProfileCache{}
+}
+
+func (redisGrProfileCache) Set(ctx context.Context, regId string, user model.User, ttl time.Duration) error {
+	return system.ObjectSet(regId, user, ttl)
+}
+
+func (redisGrProfileCache) Get(ctx context.Context, regId string) (*model.User, error) {
+	val, err := system.GetRedis().Get(ctx, regId).Bytes()
+	if err != nil {
+		if errors.Is(err, system.Nil) {
+			return nil, ErrGrProfileNotFound
+		}
+		return nil, fmt.Errorf("error getting key: %w", err)
+	}
+
+	var user model.User
+	if err := json.Unmarshal(val, &user); err != nil {
+		return nil, fmt.Errorf("error unmarshaling JSON to struct: %w", err)
+	}
+	return &user, nil
+}
+
+func (redisGrProfileCache) Delete(ctx context.Context, regId string) error {
+	return system.ObjectDelete(regId)
+}
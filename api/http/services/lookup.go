@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lbe/ciam"
+	"lbe/codes"
+)
+
+var ErrMemberNotFound = errors.New("member not found")
+
+// ResolveRlpId resolves a member identifier of the given lookup type to its RLP_ID.
+// rlp_no is looked up in the local numbering table, the other identifiers through
+// the CIAM user id link extension. ErrMemberNotFound is returned when nothing matches.
+func ResolveRlpId(ctx context.Context, app *App, lookupType, value string) (string, error) {
+	switch lookupType {
+	case codes.LookupTypeRlpId:
+		return value, nil
+
+	case codes.LookupTypeRlpNo:
+		numbering, err := app.Numbering.ByRlpNo(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		return numbering.RLP_ID, nil
+
+	case codes.LookupTypeGrId:
+		users, err := app.Ciam.FindUsersByGrId(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		return linkedRlpId(users)
+
+	case codes.LookupTypeEmail:
+		users, err := app.Ciam.FindUsersByEmail(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		return linkedRlpId(users)
+
+	case codes.LookupTypeCiamId:
+		user, err := app.Ciam.GetUser(ctx, value)
+		if err != nil {
+			if errors.Is(err, ciam.ErrNotFound) {
+				return "", ErrMemberNotFound
+			}
+			return "", err
+		}
+		return linkedRlpId([]ciam.User{*user})
+
+	default:
+		return "", fmt.Errorf("unsupported lookup type %q", lookupType)
+	}
+}
+
+// linkedRlpId returns the RLP_ID of the first CIAM user linked to an RLP profile.
+func linkedRlpId(users []ciam.User) (string, error) {
+	for _, user := range users {
+		if user.UserIdLink != nil && user.UserIdLink.RlpId != "" {
+			return user.UserIdLink.RlpId, nil
+		}
+	}
+	return "", ErrMemberNotFound
+}
@@ -2,19 +2,17 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"log"
 
 	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/config"
 	"lbe/model"
+	"lbe/utils"
 	"net/http"
-	"time"
 )
 
 // Endpoints
@@ -27,56 +25,64 @@ const (
 	updateBurnPinURL       = "/api/v1/user/pin"
 )
 
-func GetAccessToken() (string, error) {
-	AppID := config.GetConfig().Api.Memberservice.AppID
-	secretKey := config.GetConfig().Api.Memberservice.Secret
-	reqBody, err := GenerateSignature(AppID, secretKey)
-
+// memberTokenProvider caches the member service token, the auth response carries no expiry so the default ttl applies.
+var memberTokenProvider = utils.NewTokenProvider("member", func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+	token, err := getMemberAccessToken(ctx, client)
 	if err != nil {
-		log.Printf("unable to generate auth signature: %v", err)
-		return "", err
+		return utils.AccessToken{}, err
 	}
+	return utils.AccessToken{Token: token}, nil
+})
 
-	// Encode the request body into JSON.
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
-	}
+func getMemberAccessToken(ctx context.Context, client *http.Client) (string, error) {
+	appId := config.GetConfig().Api.Memberservice.AppID
+	secretKey := config.GetConfig().Api.Memberservice.Secret
+	reqBody, err := GenerateSignature(appId, secretKey)
 
-	// Create a new HTTP request.
-	req, err := http.NewRequest("POST", BuildFullURL(authURL), bytes.NewBuffer(jsonData))
 	if err != nil {
+		utils.Logf(ctx, "unable to generate auth signature: %v", err)
 		return "", err
 	}
 
-	req.Header.Set("AppID", AppID)
-	req.Header.Set("Content-Type", "application/json")
+	headers := map[string]string{
+		"AppID": appId,
+	}
 
-	// Create an HTTP client with a timeout.
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	response, _, err := utils.DoAPIRequest[responses.ApiResponse[responses.MemberAuthResponseData]](model.APIRequestOptions{
+		Method:         http.MethodPost,
+		URL:            BuildFullURL(authURL),
+		Body:           reqBody,
+		ExpectedStatus: http.StatusOK,
+		Headers:        headers,
+		Client:         client,
+		Context:        ctx,
+		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamMember,
+	})
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
+	return response.Data.AccessToken, nil
+}
 
-	// Check for a non-OK status.
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("authentication endpoint returned non-OK status")
-	}
+// MemberClient calls the member service.
+type MemberClient interface {
+	// VerifyMemberExistence checks a member exists by email, a login session
+	// token is returned if updateSessionToken is true
+	VerifyMemberExistence(ctx context.Context, email string, updateSessionToken bool) (*responses.ApiResponse[model.LoginSessionToken], error)
+	PostRegisterUser(ctx context.Context, payload requests.CreateMemberUser) error
+	UpdateBurnPin(ctx context.Context, payload any) error
+}
 
-	// Decode the response.
-	var authResp responses.ApiResponse[responses.MemberAuthResponseData]
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return "", err
-	}
+type memberClient struct {
+	client *http.Client
+}
 
-	return authResp.Data.AccessToken, nil
+func NewMemberClient(client *http.Client) MemberClient {
+	return &memberClient{client: client}
 }
 
-// requests a check to verify member existence via email,
-// login session token is returned if updateSessionToken query param is true
-func VerifyMemberExistence(email string, updateSessionToken bool) (*responses.ApiResponse[model.LoginSessionToken], error) {
+func (m *memberClient) VerifyMemberExistence(ctx context.Context, email string, updateSessionToken bool) (*responses.ApiResponse[model.LoginSessionToken], error) {
 	payload := requests.VerifyUser{
 		Email: email,
 	}
@@ -88,84 +94,50 @@ func VerifyMemberExistence(email string, updateSessionToken bool) (*responses.Ap
 		targetURL = verifyUserExistenceURL
 	}
 
-	resp, err := buildMemberHttpClient("POST", BuildFullURL(targetURL), payload)
+	userResp, raw, err := memberRequest[responses.ApiResponse[model.LoginSessionToken]](ctx, m.client, http.MethodPost, BuildFullURL(targetURL), payload)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var userResp responses.ApiResponse[model.LoginSessionToken]
-	if err := json.NewDecoder(resp.Body).Decode(&userResp); err != nil {
-		return nil, err
+		// outcomes such as not found may come with an error status, the response code tells them apart
+		var statusErr *utils.UnexpectedStatusError
+		if errors.As(err, &statusErr) {
+			var errResp responses.ApiResponse[model.LoginSessionToken]
+			if json.Unmarshal(raw, &errResp) == nil && errResp.Code != 0 {
+				return &errResp, nil
+			}
+		}
+		return nil, upstreamError(model.UpstreamMember, err, "", "", nil)
 	}
 
-	return &userResp, nil
+	return userResp, nil
 }
 
 // TODO: update accordingly when member sevice endpoint updates
-func PostRegisterUser(payload requests.CreateMemberUser) error {
-
-	_, err := buildMemberHttpClient("POST", BuildFullURL(registerURL), payload)
-	if err != nil {
-		return err
-	}
-	return nil
-
+func (m *memberClient) PostRegisterUser(ctx context.Context, payload requests.CreateMemberUser) error {
+	_, _, err := memberRequest[struct{}](ctx, m.client, http.MethodPost, BuildFullURL(registerURL), payload)
+	return upstreamError(model.UpstreamMember, err, "", "", nil)
 }
 
-func UpdateBurnPin(payload interface{}) error {
-	// Build the full URL by combining the base URL, email, and signUpType.
-	urlWithParams := BuildFullURL(updateBurnPinURL)
-
-	resp, err := buildMemberHttpClient("PUT", urlWithParams, payload)
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// Read the response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			// In case of error reading the body, just return the status code
-			return fmt.Errorf("error calling member services: received status code %d, but failed to read response body: %v", resp.StatusCode, err)
-		}
-		defer resp.Body.Close()
-
-		return fmt.Errorf("error calling member services: received status code %d and response: %s", resp.StatusCode, string(body))
+func (m *memberClient) UpdateBurnPin(ctx context.Context, payload any) error {
+	if _, _, err := memberRequest[struct{}](ctx, m.client, http.MethodPut, BuildFullURL(updateBurnPinURL), payload); err != nil {
+		return fmt.Errorf("error calling member services: %w", upstreamError(model.UpstreamMember, err, "", "", nil))
 	}
-
 	return nil
 }
 
-func buildMemberHttpClient(httpMethod string, url string, payload any) (*http.Response, error) {
-	// Get the access token.
-	token, err := GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	// Marshal the passed payload into JSON.
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling payload: %w", err)
-	}
-
-	fmt.Print(url)
-	// Create a new POST request with the JSON payload.
-	req, err := http.NewRequest(httpMethod, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	// Set the required headers.
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("AppID", config.GetConfig().Api.Memberservice.AppID)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp, err
+// memberRequest makes an authenticated call to the member service.
+func memberRequest[T any](ctx context.Context, client *http.Client, httpMethod string, url string, payload any) (*T, []byte, error) {
+	headers := map[string]string{
+		"AppID": config.GetConfig().Api.Memberservice.AppID,
+	}
+
+	return utils.DoAuthorizedRequest[T](memberTokenProvider, model.APIRequestOptions{
+		Method:         httpMethod,
+		URL:            url,
+		Body:           payload,
+		ExpectedStatus: http.StatusOK,
+		Headers:        headers,
+		Client:         client,
+		Context:        ctx,
+		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamMember,
+	})
 }
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"lbe/model"
+	"lbe/utils"
+
+	"gorm.io/gorm"
+)
+
+// RlpNumbering allocates the RLP_NO and RLP_ID of new members and looks them up.
+type RlpNumbering interface {
+	// Next allocates the numbering of a new member.
+	Next(ctx context.Context) (*model.RLPUserNumbering, error)
+	// Release gives back the numbering of a registration that was rolled back.
+	Release(ctx context.Context, rlpId string) error
+	// ByRlpNo returns the numbering of rlpNo, or ErrMemberNotFound.
+	ByRlpNo(ctx context.Context, rlpNo string) (*model.RLPUserNumbering, error)
+}
+
+type dbRlpNumbering struct{}
+
+// NewRlpNumbering returns the RlpNumbering backed by the rlp_user_numbering table.
+func NewRlpNumbering() RlpNumbering {
+	return dbRlpNumbering{}
+}
+
+func (dbRlpNumbering) Next(ctx context.Context) (*model.RLPUserNumbering, error) {
+	return utils.GenerateNextRLPUserNumbering()
+}
+
+func (dbRlpNumbering) Release(ctx context.Context, rlpId string) error {
+	return utils.ReleaseRLPUserNumbering(rlpId)
+}
+
+func (dbRlpNumbering) ByRlpNo(ctx context.Context, rlpNo string) (*model.RLPUserNumbering, error) {
+	numbering, err := utils.GetRLPUserNumberingByRlpNo(rlpNo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	return numbering, err
+}
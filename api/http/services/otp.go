@@ -2,49 +2,193 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"lbe/codes"
+	"lbe/config"
 	"lbe/model"
+	"lbe/security/random"
 	"lbe/system"
-	"math/rand"
-	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
+var (
+	ErrOtpNotFound          = errors.New("otp not found or expired")
+	ErrOtpAttemptsExceeded  = errors.New("otp attempts exceeded")
+	ErrOtpResendCooldown    = errors.New("otp resend cooldown")
+	ErrOtpDailyLimitReached = errors.New("otp daily limit reached")
+
+	ErrVerificationTokenNotFound = errors.New("verification token not found or expired")
+)
+
+// OtpLimitError is returned by GenerateOTP when sending is throttled for the identifier.
+// It wraps ErrOtpResendCooldown or ErrOtpDailyLimitReached.
+type OtpLimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *OtpLimitError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", e.Err, e.RetryAfter)
+}
+
+func (e *OtpLimitError) Unwrap() error {
+	return e.Err
+}
+
+// OtpValidation is the outcome of a ValidateOTP call.
+type OtpValidation struct {
+	Valid bool
+	// RemainingAttempts before the OTP is invalidated, only meaningful when Valid is false
+	RemainingAttempts int
+}
+
 // OTPService is responsible for generating and validating OTP tokens.
 type OTPService interface {
-	GenerateOTP(ctx context.Context, identifier string) (model.Otp, error)
-	ValidateOTP(ctx context.Context, identifier string, otp string) (bool, error)
+	GenerateOTP(ctx context.Context, purpose string, identifier string) (model.Otp, error)
+	// ReleaseOTP withdraws the OTP of a failed delivery and hands its send back to
+	// the cooldown and the daily cap, so the member can ask again straight away.
+	ReleaseOTP(ctx context.Context, purpose string, identifier string) error
+	ValidateOTP(ctx context.Context, purpose string, identifier string, otp string) (OtpValidation, error)
+	IssueVerificationToken(ctx context.Context, purpose string, identifier string) (model.OtpVerification, error)
+	GetVerification(ctx context.Context, token string) (OtpVerificationRecord, error)
+	RevokeVerificationToken(ctx context.Context, token string) error
+}
+
+// OtpVerificationRecord is what a verification token resolves to.
+type OtpVerificationRecord struct {
+	Purpose    string `json:"purpose"`
+	Identifier string `json:"identifier"`
+	// SignUpType the token may register, empty for non-registration purposes
+	SignUpType string `json:"sign_up_type,omitempty"`
+}
+
+// reserveSendScript takes a send for the identifier in one step. It refuses while the
+// resend cooldown runs or once the daily cap is reached, otherwise it starts the
+// cooldown and counts the send. It returns {0} on success, {1, cooldown left in ms}
+// or {2} when refused.
+var reserveSendScript = redis.NewScript(`
+local cooldown = redis.call("PTTL", KEYS[1])
+if cooldown ~= -2 then
+	return {1, cooldown}
+end
+if tonumber(redis.call("GET", KEYS[2]) or "0") >= tonumber(ARGV[2]) then
+	return {2}
+end
+redis.call("SET", KEYS[1], 1, "PX", ARGV[1], "NX")
+if redis.call("INCR", KEYS[2]) == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+end
+return {0}
+`)
+
+// releaseSendScript undoes reserveSendScript.
+var releaseSendScript = redis.NewScript(`
+redis.call("DEL", KEYS[1])
+if tonumber(redis.call("GET", KEYS[2]) or "0") > 0 then
+	redis.call("DECR", KEYS[2])
+end
+return 1
+`)
+
+// validateScript checks an OTP against the attempt limit in one step, so no guess
+// is compared once the attempts are used up. A match deletes the OTP and its
+// counter, a mismatch is counted, the counter expiring with the OTP it guards. The
+// codes are compared by digest, so the time taken tells nothing about the OTP. It
+// returns {0} on a match, {1} when there is no OTP, {2} once the attempts are
+// used up and {3, attempts left} on a mismatch.
+var validateScript = redis.NewScript(`
+local stored = redis.call("GET", KEYS[1])
+if not stored then
+	return {1}
+end
+local maxAttempts = tonumber(ARGV[2])
+if tonumber(redis.call("GET", KEYS[2]) or "0") >= maxAttempts then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return {2}
+end
+if redis.sha1hex(stored) == redis.sha1hex(ARGV[1]) then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return {0}
+end
+local attempts = redis.call("INCR", KEYS[2])
+if attempts == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+end
+if attempts >= maxAttempts then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return {2}
+end
+return {3, maxAttempts - attempts}
+`)
+
+type otpService struct {
+	rdb *redis.Client
 }
 
-type otpService struct{}
+// NewOTPService creates an instance of OTPService keeping its OTPs in rdb.
+func NewOTPService(rdb *redis.Client) OTPService {
+	return &otpService{rdb: rdb}
+}
 
-// NewOTPService creates an instance of OTPService.
-func NewOTPService() OTPService {
-	return &otpService{}
+// otpIdentifier is the form of identifier the OTP keys are built from. Emails are
+// case-insensitive, so A@x.com and a@x.com share one OTP, cooldown, daily cap and
+// attempt counter.
+func otpIdentifier(identifier string) string {
+	return strings.ToLower(strings.TrimSpace(identifier))
 }
 
-// GenerateOTP generates a 6-digit OTP, stores it in Redis with a 30-minute expiration,
-// and returns the OTP along with its expiration time.
-func (s *otpService) GenerateOTP(ctx context.Context, identifier string) (model.Otp, error) {
-	// Seed the random number generator (consider seeding once in your application's startup in production)
-	rand.New(rand.NewSource(time.Now().UnixNano()))
+func otpKey(purpose, identifier string) string {
+	return fmt.Sprintf("otp:%s:%s", purpose, identifier)
+}
 
-	// Generate a 6-digit OTP.
-	otp := rand.Intn(900000) + 100000
-	otpStr := strconv.Itoa(otp)
+func otpAttemptsKey(purpose, identifier string) string {
+	return fmt.Sprintf("otp_attempts:%s:%s", purpose, identifier)
+}
+
+func otpCooldownKey(purpose, identifier string) string {
+	return fmt.Sprintf("otp_cooldown:%s:%s", purpose, identifier)
+}
+
+func otpDailySendsKey(purpose, identifier string, now time.Time) string {
+	return fmt.Sprintf("otp_sends:%s:%s:%s", purpose, identifier, now.Format("20060102"))
+}
+
+func otpVerificationKey(token string) string {
+	return "otp_verified:" + token
+}
+
+// GenerateOTP generates an OTP, 6 digits unless configured otherwise for the purpose, stores it in Redis keyed by purpose and identifier
+// with the configured expiration, and returns the OTP along with its expiration time.
+// Sending is throttled by a resend cooldown and a daily cap, reported as *OtpLimitError.
+func (s *otpService) GenerateOTP(ctx context.Context, purpose string, identifier string) (model.Otp, error) {
+	identifier = otpIdentifier(identifier)
+	if err := s.reserveSend(ctx, purpose, identifier); err != nil {
+		return model.Otp{}, err
+	}
+
+	otpConf := config.GetConfig().Application.Otp
+
+	// Generate the OTP with the length and alphabet configured for the purpose.
+	otpStr, err := random.String(otpConf.GetLength(purpose), otpConf.GetAlphabet(purpose))
+	if err != nil {
+		return model.Otp{}, fmt.Errorf("failed to generate OTP: %v", err)
+	}
 
-	// Set an expiration duration, e.g., 30 minutes.
-	expiration := 30 * time.Minute
-	/*
-		// Define the Redis key (e.g., "otp:user@example.com").
-		key := "otp:" + identifier
-		// Store the OTP in Redis.
+	expiration := otpConf.GetExpiry()
 
-			err := system.GetRedis().Set(ctx, key, otpStr, expiration).Err()
-			if err != nil {
-				return OTPResponse{}, fmt.Errorf("failed to store OTP in Redis: %v", err)
-			}
-	*/
+	// Store the OTP in Redis, replacing any previously issued one and its failed attempts.
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, otpKey(purpose, identifier), otpStr, expiration)
+	pipe.Del(ctx, otpAttemptsKey(purpose, identifier))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return model.Otp{}, fmt.Errorf("failed to store OTP in Redis: %v", err)
+	}
 
 	// Calculate the expiration timestamp.
 	expiresAt := time.Now().Add(expiration).Unix()
@@ -56,33 +200,127 @@ func (s *otpService) GenerateOTP(ctx context.Context, identifier string) (model.
 	}, nil
 }
 
-// ValidateOTP retrieves the stored OTP from Redis for the given identifier, compares it with the provided OTP,
-// and optionally deletes it after successful validation.
-func (s *otpService) ValidateOTP(ctx context.Context, identifier string, providedOTP string) (bool, error) {
-	// Define the Redis key.
-	key := "otp:" + identifier
+// ValidateOTP compares the OTP stored in Redis for the given purpose and identifier
+// with the provided one and deletes it once it matched. Every mismatch counts as a
+// failed attempt; once the configured maximum is reached the OTP is invalidated and
+// ErrOtpAttemptsExceeded is returned.
+func (s *otpService) ValidateOTP(ctx context.Context, purpose string, identifier string, providedOTP string) (OtpValidation, error) {
+	identifier = otpIdentifier(identifier)
+	otpConf := config.GetConfig().Application.Otp
+
+	keys := []string{otpKey(purpose, identifier), otpAttemptsKey(purpose, identifier)}
+	res, err := validateScript.Run(ctx, s.rdb, keys,
+		providedOTP, otpConf.GetMaxAttempts(), otpConf.GetExpiry().Milliseconds()).Int64Slice()
+	if err != nil {
+		return OtpValidation{}, fmt.Errorf("failed to validate OTP in Redis: %v", err)
+	}
+
+	switch res[0] {
+	case 0:
+		return OtpValidation{Valid: true}, nil
+	case 1:
+		// OTP not found or expired.
+		return OtpValidation{}, ErrOtpNotFound
+	case 2:
+		return OtpValidation{}, ErrOtpAttemptsExceeded
+	}
+	return OtpValidation{RemainingAttempts: int(res[1])}, nil
+}
+
+// reserveSend enforces the resend cooldown and the daily send cap for the identifier.
+// Both are checked and taken in one script, so concurrent requests cannot overrun
+// the cap and a refused request does not start a cooldown.
+func (s *otpService) reserveSend(ctx context.Context, purpose string, identifier string) error {
+	otpConf := config.GetConfig().Application.Otp
+
+	now := time.Now()
+	keys := []string{otpCooldownKey(purpose, identifier), otpDailySendsKey(purpose, identifier, now)}
+	res, err := reserveSendScript.Run(ctx, s.rdb, keys,
+		otpConf.GetResendCooldown().Milliseconds(), otpConf.GetDailySendCap(), (24 * time.Hour).Milliseconds()).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to reserve OTP send in Redis: %v", err)
+	}
+
+	switch res[0] {
+	case 1:
+		return &OtpLimitError{Err: ErrOtpResendCooldown, RetryAfter: time.Duration(max(res[1], 0)) * time.Millisecond}
+	case 2:
+		year, month, day := now.Date()
+		midnight := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+		return &OtpLimitError{Err: ErrOtpDailyLimitReached, RetryAfter: midnight.Sub(now)}
+	}
+	return nil
+}
+
+func (s *otpService) ReleaseOTP(ctx context.Context, purpose string, identifier string) error {
+	identifier = otpIdentifier(identifier)
+	if err := s.rdb.Del(ctx, otpKey(purpose, identifier), otpAttemptsKey(purpose, identifier)).Err(); err != nil {
+		return fmt.Errorf("failed to delete OTP from Redis: %v", err)
+	}
+
+	keys := []string{otpCooldownKey(purpose, identifier), otpDailySendsKey(purpose, identifier, time.Now())}
+	if err := releaseSendScript.Run(ctx, s.rdb, keys).Err(); err != nil {
+		return fmt.Errorf("failed to release OTP send in Redis: %v", err)
+	}
+	return nil
+}
+
+// IssueVerificationToken returns a short-lived token proving the OTP for the given
+// purpose and identifier was verified.
+func (s *otpService) IssueVerificationToken(ctx context.Context, purpose string, identifier string) (model.OtpVerification, error) {
+	token := uuid.New().String()
+	expiration := config.GetConfig().Application.Otp.GetVerificationTokenExpiry()
+
+	record := OtpVerificationRecord{
+		Purpose:    purpose,
+		Identifier: identifier,
+		SignUpType: codes.OtpPurposeSignUpType(purpose),
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return model.OtpVerification{}, fmt.Errorf("failed to marshal verification token: %v", err)
+	}
+	if err := s.rdb.Set(ctx, otpVerificationKey(token), data, expiration).Err(); err != nil {
+		return model.OtpVerification{}, fmt.Errorf("failed to store verification token in Redis: %v", err)
+	}
+
+	return model.OtpVerification{
+		VerificationToken:       token,
+		VerificationTokenExpiry: time.Now().Add(expiration).Unix(),
+	}, nil
+}
+
+// GetVerification resolves a verification token issued by IssueVerificationToken.
+func (s *otpService) GetVerification(ctx context.Context, token string) (OtpVerificationRecord, error) {
+	var record OtpVerificationRecord
 
-	// Retrieve the stored OTP from Redis.
-	storedOTP, err := system.GetRedis().Get(ctx, key).Result()
+	val, err := s.rdb.Get(ctx, otpVerificationKey(token)).Result()
 	if err != nil {
 		if err == system.Nil {
-			// OTP not found or expired.
-			return false, fmt.Errorf("OTP not found or expired for identifier: %s", identifier)
+			return record, ErrVerificationTokenNotFound
 		}
-		return false, fmt.Errorf("failed to get OTP from Redis: %v", err)
+		return record, fmt.Errorf("failed to get verification token from Redis: %v", err)
 	}
-
-	// Compare the stored OTP with the provided OTP.
-	if storedOTP != providedOTP {
-		return false, nil
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return record, fmt.Errorf("failed to unmarshal verification token: %v", err)
 	}
 
-	// Optionally delete the OTP from Redis after successful validation.
-	_, delErr := system.GetRedis().Del(ctx, key).Result()
-	if delErr != nil {
-		// Log a warning if deletion fails.
-		fmt.Printf("Warning: failed to delete OTP from Redis: %v\n", delErr)
+	return record, nil
+}
+
+// RevokeVerificationToken deletes a verification token once it has been used.
+func (s *otpService) RevokeVerificationToken(ctx context.Context, token string) error {
+	if err := s.rdb.Del(ctx, otpVerificationKey(token)).Err(); err != nil {
+		return fmt.Errorf("failed to delete verification token from Redis: %v", err)
 	}
+	return nil
+}
 
-	return true, nil
+// ResponseOTP strips the code from an OTP before it goes back to the caller, unless
+// application.otp.returnInResponse is enabled. The expiry is always kept.
+func ResponseOTP(otp model.Otp) model.Otp {
+	if config.GetConfig().Application.Otp.ReturnInResponse {
+		return otp
+	}
+	return model.Otp{OtpExpiry: otp.OtpExpiry}
 }
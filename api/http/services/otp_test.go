@@ -0,0 +1,157 @@
+package services_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"lbe/api/http/services"
+	"lbe/codes"
+	"lbe/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const otpIdentifier = "user@example.com"
+
+// newOtpService returns an OTPService backed by an in-process Redis.
+func newOtpService(t *testing.T) (services.OTPService, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return services.NewOTPService(rdb), mr
+}
+
+func TestOtpSendReservation(t *testing.T) {
+	otpConf := config.GetConfig().Application.Otp
+	ctx := context.Background()
+
+	t.Run("resend waits for the cooldown", func(t *testing.T) {
+		otpService, mr := newOtpService(t)
+
+		_, err := otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.NoError(t, err)
+
+		_, err = otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		var limitErr *services.OtpLimitError
+		if assert.ErrorAs(t, err, &limitErr) {
+			assert.ErrorIs(t, err, services.ErrOtpResendCooldown)
+			assert.Greater(t, limitErr.RetryAfter, otpConf.GetResendCooldown()/2)
+		}
+
+		mr.FastForward(otpConf.GetResendCooldown())
+		_, err = otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("daily cap", func(t *testing.T) {
+		otpService, mr := newOtpService(t)
+
+		for i := 0; i < otpConf.GetDailySendCap(); i++ {
+			_, err := otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+			assert.NoError(t, err)
+			mr.FastForward(otpConf.GetResendCooldown())
+		}
+
+		_, err := otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.ErrorIs(t, err, services.ErrOtpDailyLimitReached)
+	})
+
+	t.Run("released send can be retried straight away", func(t *testing.T) {
+		otpService, _ := newOtpService(t)
+
+		_, err := otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.NoError(t, err)
+		assert.NoError(t, otpService.ReleaseOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier))
+
+		// the otp it replaced is gone
+		_, err = otpService.ValidateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier, "000000")
+		assert.ErrorIs(t, err, services.ErrOtpNotFound)
+
+		_, err = otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.NoError(t, err)
+	})
+
+	t.Run("email case does not reset the throttling", func(t *testing.T) {
+		otpService, _ := newOtpService(t)
+
+		_, err := otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, " User@Example.com")
+		assert.NoError(t, err)
+
+		_, err = otpService.GenerateOTP(ctx, codes.OtpPurposeRegistration, otpIdentifier)
+		assert.ErrorIs(t, err, services.ErrOtpResendCooldown)
+	})
+}
+
+func TestValidateOTP(t *testing.T) {
+	maxAttempts := config.GetConfig().Application.Otp.GetMaxAttempts()
+	ctx := context.Background()
+
+	t.Run("match", func(t *testing.T) {
+		otpService, _ := newOtpService(t)
+		otp, err := otpService.GenerateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier)
+		assert.NoError(t, err)
+
+		validation, err := otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, *otp.Otp)
+		assert.NoError(t, err)
+		assert.True(t, validation.Valid)
+
+		// an otp is good for one verification only
+		_, err = otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, *otp.Otp)
+		assert.ErrorIs(t, err, services.ErrOtpNotFound)
+	})
+
+	t.Run("match ignores the email case", func(t *testing.T) {
+		otpService, _ := newOtpService(t)
+		otp, err := otpService.GenerateOTP(ctx, codes.OtpPurposeLogin, "User@Example.com")
+		assert.NoError(t, err)
+
+		validation, err := otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, *otp.Otp)
+		assert.NoError(t, err)
+		assert.True(t, validation.Valid)
+	})
+
+	t.Run("attempts run out", func(t *testing.T) {
+		otpService, _ := newOtpService(t)
+		otp, err := otpService.GenerateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier)
+		assert.NoError(t, err)
+		wrong := wrongOtp(*otp.Otp)
+
+		for i := 1; i < maxAttempts; i++ {
+			validation, err := otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, wrong)
+			assert.NoError(t, err)
+			assert.False(t, validation.Valid)
+			assert.Equal(t, maxAttempts-i, validation.RemainingAttempts)
+		}
+
+		_, err = otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, wrong)
+		assert.ErrorIs(t, err, services.ErrOtpAttemptsExceeded)
+
+		// the otp was invalidated with the last attempt
+		_, err = otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, *otp.Otp)
+		assert.ErrorIs(t, err, services.ErrOtpNotFound)
+	})
+
+	t.Run("correct otp refused once attempts are used up", func(t *testing.T) {
+		otpService, mr := newOtpService(t)
+		otp, err := otpService.GenerateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier)
+		assert.NoError(t, err)
+
+		// concurrent guesses used up the attempts before the otp was invalidated
+		mr.Set("otp_attempts:"+codes.OtpPurposeLogin+":"+otpIdentifier, strconv.Itoa(maxAttempts))
+
+		_, err = otpService.ValidateOTP(ctx, codes.OtpPurposeLogin, otpIdentifier, *otp.Otp)
+		assert.ErrorIs(t, err, services.ErrOtpAttemptsExceeded)
+	})
+}
+
+// wrongOtp returns a code of the same length that differs from otp.
+func wrongOtp(otp string) string {
+	if otp[0] == '0' {
+		return "1" + otp[1:]
+	}
+	return "0" + otp[1:]
+}
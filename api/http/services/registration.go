@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/responses"
+	mycache "lbe/cache"
+	"lbe/ciam"
+	"lbe/config"
+	"lbe/model"
+	"lbe/saga"
+	"lbe/utils"
+)
+
+// an in-progress attempt not touched for this long is considered stuck, e.g. after a pod restart
+const registrationStaleAfter = 10 * time.Minute
+
+var (
+	ErrRegistrationAttemptNotFound = errors.New("registration attempt not found")
+	ErrRegistrationNotResumable    = errors.New("registration attempt is not resumable")
+)
+
+// RegisterUser creates the member in CIAM and RLP. The progress is persisted in
+// registration_attempts: a step failing on a transient upstream error leaves the attempt
+// resumable through ResumeRegistration, any other failure undoes the completed steps in
+// reverse order so no orphaned CIAM user or half-built RLP profile is left behind.
+func RegisterUser(ctx context.Context, app *App, req *requests.RegisterUser, numbering *model.RLPUserNumbering) (*responses.GetUserResponse, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling registration payload: %w", err)
+	}
+
+	attempt := &model.RegistrationAttempt{
+		RlpID:      numbering.RLP_ID,
+		RlpNo:      numbering.RLP_NO,
+		SignUpType: req.SignUpType,
+		Payload:    string(payload),
+		Status:     model.RegistrationStatusInProgress,
+	}
+	if err := app.Registrations.Create(ctx, attempt); err != nil {
+		return nil, fmt.Errorf("persisting registration attempt: %w", err)
+	}
+
+	return runRegistration(ctx, app, attempt, &req.User)
+}
+
+// RegistrationLockKey is the lock serialising the registrations of email. Sign-ups
+// and resumes hold it while they run.
+func RegistrationLockKey(email string) string {
+	return "register:" + strings.ToLower(email)
+}
+
+// ResumeRegistration re-drives a stuck registration from its first unfinished step.
+// It holds the registration lock of the member and claims the attempt atomically, so
+// concurrent resumes and a sign-up of the same email do not run the steps twice.
+// mycache.ErrLockNotObtained is returned while another registration of the member runs.
+func ResumeRegistration(ctx context.Context, app *App, rlpId string) (*responses.GetUserResponse, error) {
+	attempt, err := app.Registrations.Get(ctx, rlpId)
+	if err != nil {
+		return nil, err
+	}
+
+	var req requests.RegisterUser
+	if err := json.Unmarshal([]byte(attempt.Payload), &req); err != nil {
+		return nil, fmt.Errorf("unmarshaling registration payload: %w", err)
+	}
+
+	lock, err := mycache.Obtain(ctx, app.Locker, RegistrationLockKey(req.User.Email))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+			utils.Logf(ctx, "error releasing lock %s (token %d): %v", lock.Key, lock.Token, err)
+		}
+	}()
+
+	claimed, err := app.Registrations.Claim(ctx, rlpId, time.Now().Add(-registrationStaleAfter))
+	if err != nil {
+		return nil, fmt.Errorf("claiming registration attempt: %w", err)
+	}
+	if !claimed {
+		return nil, ErrRegistrationNotResumable
+	}
+
+	// reloaded, a run that finished while we waited for the lock may have completed steps
+	attempt, err = app.Registrations.Get(ctx, rlpId)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Logf(ctx, "resuming registration %s, retry %d", rlpId, attempt.RetryCount)
+	return runRegistration(ctx, app, attempt, &req.User)
+}
+
+func runRegistration(ctx context.Context, app *App, attempt *model.RegistrationAttempt, user *model.User) (*responses.GetUserResponse, error) {
+	var profileResp *responses.GetUserResponse
+
+	steps := []saga.Step{
+		{
+			Name: model.RegistrationStepCiamCreate,
+			Action: func(ctx context.Context) error {
+				utils.Logf(ctx, "registering user: %v", user.Email)
+				ciamUser, err := app.Ciam.CreateUser(ctx, requests.GenerateInitialRegistrationRequest(user))
+				if err != nil {
+					return err
+				}
+				attempt.CiamUserID = ciamUser.ID
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				utils.Logf(ctx, "deleting CIAM user id: %v", attempt.CiamUserID)
+				return app.Ciam.DeleteUser(ctx, attempt.CiamUserID)
+			},
+		},
+		{
+			// removed together with the CIAM user, no compensation needed
+			Name: model.RegistrationStepSchemaExtension,
+			Action: func(ctx context.Context) error {
+				return app.Ciam.SetUserIdLink(ctx, attempt.CiamUserID, userIdLink(user, attempt))
+			},
+		},
+		{
+			Name: model.RegistrationStepRlpCreate,
+			Action: func(ctx context.Context) error {
+				rlpIntialUserCreationReq := requests.UserProfileRequest{
+					User: model.RlpUserReq{
+						ExternalID:     attempt.RlpID,
+						ExternalIDType: "RLP_ID",
+					},
+				}
+				_, err := app.Rlp.CreateProfile(ctx, rlpIntialUserCreationReq)
+				return err
+			},
+			Compensate: func(ctx context.Context) error {
+				// the email is only known to RLP once the update step went through
+				email := ""
+				if attempt.RlpUpdated {
+					email = user.Email
+				}
+				_, err := ArchiveProfile(ctx, app.Rlp, attempt.RlpID, email)
+				return err
+			},
+		},
+		{
+			Name: model.RegistrationStepRlpUpdate,
+			Action: func(ctx context.Context) error {
+				rlpUserModel := user.MapLbeToRlpUser()
+				rlpUserModel.PopulateRegistrationDefaults(attempt.RlpID)
+
+				resp, err := app.Rlp.UpdateProfile(ctx, attempt.RlpID, requests.UserProfileRequest{User: rlpUserModel})
+				if err != nil {
+					return err
+				}
+				profileResp = resp
+				return nil
+			},
+		},
+		{
+			Name: model.RegistrationStepTierEvent,
+			Action: func(ctx context.Context) error {
+				userTierReq := requests.UserTierUpdateEventRequest{
+					EventLookup: GetUserTierEventName(user.Tier),
+					UserId:      attempt.RlpID,
+					RetailerID:  config.GetConfig().Api.Rlp.RetailerID,
+				}
+				return app.Rlp.UpdateUserTier(ctx, userTierReq)
+			},
+		},
+	}
+
+	s := saga.New(registrationStepRecorder(ctx, app.Registrations, attempt.RlpID))
+	// numbering is allocated before the saga starts, only its release is tracked
+	s.Register(saga.Step{
+		Name: model.RegistrationStepRlpNumbering,
+		Compensate: func(ctx context.Context) error {
+			return app.Numbering.Release(ctx, attempt.RlpID)
+		},
+	})
+
+	for _, step := range steps {
+		if attempt.IsStepCompleted(step.Name) {
+			// finished by a previous attempt
+			s.Register(step)
+			continue
+		}
+
+		if err := s.Execute(ctx, step); err != nil {
+			attempt.LastError = err.Error()
+			if utils.IsTransientError(err) {
+				utils.Logf(ctx, "registration %s paused and can be resumed: %v", attempt.RlpID, err)
+				attempt.Status = model.RegistrationStatusPendingRetry
+			} else {
+				utils.Logf(ctx, "registration %s failed, rolling back: %v", attempt.RlpID, err)
+				// compensate even if the caller has gone away
+				s.Rollback(context.WithoutCancel(ctx))
+				attempt.Status = model.RegistrationStatusRolledBack
+			}
+			saveRegistrationAttempt(ctx, app.Registrations, attempt)
+			return nil, err
+		}
+
+		attempt.CompleteStep(step.Name)
+		saveRegistrationAttempt(ctx, app.Registrations, attempt)
+	}
+
+	attempt.Status = model.RegistrationStatusCompleted
+	saveRegistrationAttempt(ctx, app.Registrations, attempt)
+
+	// profile was updated by a previous attempt
+	if profileResp == nil {
+		resp, err := app.Rlp.GetProfile(ctx, attempt.RlpID)
+		if err != nil {
+			return nil, err
+		}
+		profileResp = resp
+	}
+	profileResp.User.Tier = user.Tier // update tier for response dto
+
+	return profileResp, nil
+}
+
+func userIdLink(user *model.User, attempt *model.RegistrationAttempt) ciam.UserIdLink {
+	grID := ""
+	if user.GrProfile != nil {
+		grID = user.GrProfile.Id
+	}
+
+	return ciam.UserIdLink{
+		RlpId: attempt.RlpID,
+		RlpNo: attempt.RlpNo,
+		GrId:  grID,
+	}
+}
+
+func saveRegistrationAttempt(ctx context.Context, store RegistrationStore, attempt *model.RegistrationAttempt) {
+	if err := store.Save(ctx, attempt); err != nil {
+		utils.Logf(ctx, "registration attempt persistence error: %v", err)
+	}
+}
+
+// registrationStepRecorder persists every step outcome of a registration into registration_step_logs.
+func registrationStepRecorder(ctx context.Context, store RegistrationStore, rlpId string) saga.Recorder {
+	return func(step, status string, err error) {
+		entry := model.RegistrationStepLog{
+			RlpID:  rlpId,
+			Step:   step,
+			Status: status,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		utils.Logf(ctx, "registration %s step %s: %s", rlpId, step, status)
+
+		// recorded even if the caller has gone away
+		if err := store.LogStep(context.WithoutCancel(ctx), &entry); err != nil {
+			utils.Logf(ctx, "registration step log persistence error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lbe/model"
+	"lbe/system"
+
+	"gorm.io/gorm"
+)
+
+// RegistrationStore persists registration attempts and the log of their steps.
+type RegistrationStore interface {
+	Create(ctx context.Context, attempt *model.RegistrationAttempt) error
+	// Get returns the attempt of rlpId, or ErrRegistrationAttemptNotFound.
+	Get(ctx context.Context, rlpId string) (*model.RegistrationAttempt, error)
+	Save(ctx context.Context, attempt *model.RegistrationAttempt) error
+	// Claim moves the attempt of rlpId back to in progress, counting a retry, if it
+	// is pending a retry or stuck in progress since before staleBefore. It reports
+	// whether this caller claimed it, so only one of concurrent resumes goes ahead.
+	Claim(ctx context.Context, rlpId string, staleBefore time.Time) (bool, error)
+	LogStep(ctx context.Context, entry *model.RegistrationStepLog) error
+}
+
+type dbRegistrationStore struct{}
+
+// NewRegistrationStore returns the RegistrationStore backed by registration_attempts
+// and registration_step_logs. Writes are skipped when no database is configured.
+func NewRegistrationStore() RegistrationStore {
+	return dbRegistrationStore{}
+}
+
+func (dbRegistrationStore) Create(ctx context.Context, attempt *model.RegistrationAttempt) error {
+	db := system.GetDb()
+	if db == nil {
+		return nil
+	}
+	return db.WithContext(ctx).Create(attempt).Error
+}
+
+func (dbRegistrationStore) Get(ctx context.Context, rlpId string) (*model.RegistrationAttempt, error) {
+	var attempt model.RegistrationAttempt
+	if err := system.GetDb().WithContext(ctx).Where("rlp_id = ?", rlpId).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRegistrationAttemptNotFound
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (dbRegistrationStore) Save(ctx context.Context, attempt *model.RegistrationAttempt) error {
+	db := system.GetDb()
+	if db == nil {
+		return nil
+	}
+	return db.WithContext(ctx).Save(attempt).Error
+}
+
+func (dbRegistrationStore) Claim(ctx context.Context, rlpId string, staleBefore time.Time) (bool, error) {
+	// a single conditional update, the row lock lets only one claim through
+	res := system.GetDb().WithContext(ctx).Model(&model.RegistrationAttempt{}).
+		Where("rlp_id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			rlpId, model.RegistrationStatusPendingRetry, model.RegistrationStatusInProgress, staleBefore).
+		Updates(map[string]any{
+			"status":      model.RegistrationStatusInProgress,
+			"retry_count": gorm.Expr("retry_count + 1"),
+			"last_error":  "",
+			"updated_at":  time.Now(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (dbRegistrationStore) LogStep(ctx context.Context, entry *model.RegistrationStepLog) error {
+	db := system.GetDb()
+	if db == nil {
+		return nil
+	}
+	return db.WithContext(ctx).Create(entry).Error
+}
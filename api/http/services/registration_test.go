@@ -0,0 +1,169 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/services"
+	"lbe/api/http/services/fakes"
+	"lbe/ciam"
+	"lbe/codes"
+	"lbe/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// registrationAttempt is an attempt of a NEW sign-up of rlpId's member in status,
+// last written at updatedAt.
+func registrationAttempt(t *testing.T, rlpId, status string, updatedAt time.Time) model.RegistrationAttempt {
+	t.Helper()
+
+	req := requests.RegisterUser{
+		SignUpType: codes.SignUpTypeNew,
+		User: model.User{
+			FirstName: "Sample",
+			LastName:  "Data",
+			Email:     rlpId + "@example.com",
+			Tier:      "Tier A",
+		},
+	}
+	payload, err := json.Marshal(req)
+	assert.NoError(t, err)
+
+	return model.RegistrationAttempt{
+		RlpID:      rlpId,
+		RlpNo:      "10000000001",
+		SignUpType: codes.SignUpTypeNew,
+		Payload:    string(payload),
+		Status:     status,
+		UpdatedAt:  updatedAt,
+	}
+}
+
+func TestResumeRegistration(t *testing.T) {
+	t.Parallel()
+
+	const rlpId = "2505230001"
+
+	tests := []struct {
+		name          string
+		attempt       func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt
+		expectedErr   error
+		expectedUsers int
+	}{
+		{
+			name: "SUCCESS - pending retry",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				return registrationAttempt(t, rlpId, model.RegistrationStatusPendingRetry, time.Now())
+			},
+			expectedUsers: 1,
+		},
+		{
+			name: "SUCCESS - finished steps are skipped",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				attempt := registrationAttempt(t, rlpId, model.RegistrationStatusPendingRetry, time.Now())
+				attempt.CiamUserID = u.Ciam.AddUser(ciam.User{Mail: rlpId + "@example.com"}).ID
+				attempt.CompleteStep(model.RegistrationStepCiamCreate)
+				return attempt
+			},
+			expectedUsers: 1,
+		},
+		{
+			name: "SUCCESS - stuck in progress",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				return registrationAttempt(t, rlpId, model.RegistrationStatusInProgress, time.Now().Add(-time.Hour))
+			},
+			expectedUsers: 1,
+		},
+		{
+			name: "CONFLICT - in progress",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				return registrationAttempt(t, rlpId, model.RegistrationStatusInProgress, time.Now())
+			},
+			expectedErr: services.ErrRegistrationNotResumable,
+		},
+		{
+			name: "CONFLICT - completed",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				return registrationAttempt(t, rlpId, model.RegistrationStatusCompleted, time.Now().Add(-time.Hour))
+			},
+			expectedErr: services.ErrRegistrationNotResumable,
+		},
+		{
+			name: "CONFLICT - not found",
+			attempt: func(t *testing.T, u *fakes.Upstreams) model.RegistrationAttempt {
+				return registrationAttempt(t, "2505230002", model.RegistrationStatusPendingRetry, time.Now())
+			},
+			expectedErr: services.ErrRegistrationAttemptNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			upstreams := fakes.New()
+			upstreams.Registrations.Add(tt.attempt(t, upstreams))
+
+			profileResp, err := services.ResumeRegistration(context.Background(), upstreams.App(), rlpId)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, upstreams.Ciam.Users())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, rlpId, profileResp.User.ExternalID)
+			assert.Len(t, upstreams.Ciam.Users(), tt.expectedUsers)
+			assert.Len(t, upstreams.Rlp.TierEvents(), 1)
+
+			attempt, _ := upstreams.Registrations.Attempt(rlpId)
+			assert.Equal(t, model.RegistrationStatusCompleted, attempt.Status)
+			assert.Equal(t, 1, attempt.RetryCount)
+
+			// the registration lock was given back
+			lock, err := upstreams.Locker.TryObtain(context.Background(), services.RegistrationLockKey(rlpId+"@example.com"), time.Minute)
+			assert.NoError(t, err)
+			assert.NoError(t, lock.Release(context.Background()))
+		})
+	}
+}
+
+func TestResumeRegistrationConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const rlpId = "2505230003"
+
+	upstreams := fakes.New()
+	upstreams.Registrations.Add(registrationAttempt(t, rlpId, model.RegistrationStatusPendingRetry, time.Now()))
+	app := upstreams.App()
+
+	const resumes = 5
+	errs := make([]error, resumes)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = services.ResumeRegistration(context.Background(), app, rlpId)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, services.ErrRegistrationNotResumable)
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Len(t, upstreams.Ciam.Users(), 1)
+	assert.Len(t, upstreams.Rlp.TierEvents(), 1)
+}
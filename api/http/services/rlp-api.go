@@ -3,9 +3,13 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"lbe/api/http/requests"
 	"lbe/api/http/responses"
 	"lbe/config"
 	"lbe/model"
@@ -26,24 +30,48 @@ const (
 	RlpEventNameMoveTierD  = "MOVE_TIER_D"
 )
 
-func CreateProfile(ctx context.Context, client *http.Client, payload any) (*responses.GetUserResponse, []byte, error) {
-	return profile(ctx, client, http.MethodPost, BuildRlpProfileURL(CreateProfileURL, "", ""), payload)
+// rlpErrorKinds classifies the error codes RLP answers failed calls with.
+var rlpErrorKinds = map[string]error{
+	responses.RlpErrorCodeUserNotFound:  ErrUpstreamNotFound,
+	responses.RlpErrorCodeUserSuspended: ErrUpstreamConflict,
+	responses.RlpErrorCodeValidation:    ErrUpstreamValidation,
 }
 
-func UpdateProfile(ctx context.Context, client *http.Client, externalId string, payload any) (*responses.GetUserResponse, []byte, error) {
-	return profile(ctx, client, http.MethodPut, BuildRlpProfileURL(ProfileURL, externalId, ""), payload)
+// RlpClient manages member profiles and tier events in RLP. Failed calls return
+// an *UpstreamError carrying RLP's error code.
+type RlpClient interface {
+	CreateProfile(ctx context.Context, payload any) (*responses.GetUserResponse, error)
+	GetProfile(ctx context.Context, externalId string) (*responses.GetUserResponse, error)
+	UpdateProfile(ctx context.Context, externalId string, payload any) (*responses.GetUserResponse, error)
+	UpdateUserTier(ctx context.Context, payload any) error
 }
 
-func GetProfile(ctx context.Context, client *http.Client, externalId string) (*responses.GetUserResponse, []byte, error) {
+type rlpClient struct {
+	client *http.Client
+}
+
+func NewRlpClient(client *http.Client) RlpClient {
+	return &rlpClient{client: client}
+}
+
+func (r *rlpClient) CreateProfile(ctx context.Context, payload any) (*responses.GetUserResponse, error) {
+	return r.profile(ctx, http.MethodPost, BuildRlpProfileURL(CreateProfileURL, "", ""), payload)
+}
+
+func (r *rlpClient) UpdateProfile(ctx context.Context, externalId string, payload any) (*responses.GetUserResponse, error) {
+	return r.profile(ctx, http.MethodPut, BuildRlpProfileURL(ProfileURL, externalId, ""), payload)
+}
+
+func (r *rlpClient) GetProfile(ctx context.Context, externalId string) (*responses.GetUserResponse, error) {
 	query := "user[user_profile]=true&expand_incentives=true&show_identifiers=true"
-	return profile(ctx, client, http.MethodGet, BuildRlpProfileURL(ProfileURL, externalId, query), nil)
+	return r.profile(ctx, http.MethodGet, BuildRlpProfileURL(ProfileURL, externalId, query), nil)
 }
 
-func UpdateUserTier(ctx context.Context, client *http.Client, payload any) (*struct{}, []byte, error) {
+func (r *rlpClient) UpdateUserTier(ctx context.Context, payload any) error {
 	conf := config.GetConfig()
 	urlWithParams := fmt.Sprintf("%s%s", conf.Api.Rlp.Offers.Host, EventUrl)
 
-	return utils.DoAPIRequest[struct{}](model.APIRequestOptions{
+	_, _, err := utils.DoAPIRequest[struct{}](model.APIRequestOptions{
 		Method: http.MethodPost,
 		URL:    urlWithParams,
 		Body:   payload,
@@ -52,16 +80,18 @@ func UpdateUserTier(ctx context.Context, client *http.Client, payload any) (*str
 			Password: conf.Api.Rlp.Offers.ApiSecret,
 		},
 		ExpectedStatus: http.StatusOK,
-		Client:         client,
+		Client:         r.client,
 		Context:        ctx,
 		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamRlpOffers,
 	})
+	return RlpError(model.UpstreamRlpOffers, err)
 }
 
-func profile(ctx context.Context, client *http.Client, operation, url string, payload any) (*responses.GetUserResponse, []byte, error) {
+func (r *rlpClient) profile(ctx context.Context, operation, url string, payload any) (*responses.GetUserResponse, error) {
 	conf := config.GetConfig()
 
-	return utils.DoAPIRequest[responses.GetUserResponse](model.APIRequestOptions{
+	profile, _, err := utils.DoAPIRequest[responses.GetUserResponse](model.APIRequestOptions{
 		Method: operation,
 		URL:    url,
 		Body:   payload,
@@ -70,10 +100,35 @@ func profile(ctx context.Context, client *http.Client, operation, url string, pa
 			Password: conf.Api.Rlp.Core.ApiSecret,
 		},
 		ExpectedStatus: http.StatusOK,
-		Client:         client,
+		Client:         r.client,
 		Context:        ctx,
 		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamRlpCore,
 	})
+	if err != nil {
+		return nil, RlpError(model.UpstreamRlpCore, err)
+	}
+	return profile, nil
+}
+
+// RlpError classifies an error of a call to an RLP upstream by the error code in
+// the response body.
+func RlpError(upstream string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var errResp responses.UserProfileErrorResponse
+	var statusErr *utils.UnexpectedStatusError
+	if errors.As(err, &statusErr) {
+		_ = json.Unmarshal(statusErr.Body, &errResp)
+	}
+	return upstreamError(upstream, err, errResp.Errors.Code, errResp.Errors.Message, rlpErrorKinds)
+}
+
+// ArchiveProfile deactivates an RLP profile and frees its email.
+func ArchiveProfile(ctx context.Context, rlp RlpClient, externalId, email string) (*responses.GetUserResponse, error) {
+	return rlp.UpdateProfile(ctx, externalId, requests.GenerateArchiveProfileRequest(email, time.Now()))
 }
 
 func BuildRlpProfileURL(basePath, externalId, queryParams string) string {
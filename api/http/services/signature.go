@@ -5,18 +5,18 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"lbe/api/http/requests"
+	"lbe/security/random"
 )
 
 func GenerateSignature(appID, secretKey string) (*requests.AuthRequest, error) {
-	// Initialize random seed.
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-
 	// Generate a random nonce (16 characters).
-	nonce := randomNonce(16)
+	nonce, err := randomNonce(16)
+	if err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
 	// Get the current Unix timestamp as a string.
 	timestamp := fmt.Sprintf("%d", time.Now().Unix())
 
@@ -51,11 +51,6 @@ func computeSignature(appID, nonce, timestamp, secretKey string) (*requests.Auth
 }
 
 // randomNonce returns a random alphanumeric string of length n.
-func randomNonce(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(b)
+func randomNonce(n int) (string, error) {
+	return random.Alphanumeric(n)
 }
@@ -0,0 +1,25 @@
+package interceptor
+
+import (
+	"lbe/api/http/responses"
+	"lbe/config"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminInterceptor is a Gin middleware that only lets through channels listed in
+// application.admin.appIds. It must run after HttpInterceptor, which stashes the app_id.
+func AdminInterceptor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appId := c.GetString("app_id")
+		if appId == "" || !slices.Contains(config.GetConfig().Application.Admin.AppIds, appId) {
+			c.JSON(http.StatusForbidden, responses.AccessDeniedErrorResponse())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,17 +1,25 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	general "lbe/api/http"
 	"lbe/api/http/middleware"
+	"lbe/api/http/services"
+	"lbe/audit"
+	mycache "lbe/cache"
 	"lbe/config"
 	"lbe/model"
 	"lbe/system"
+	"lbe/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,6 +33,9 @@ import (
 
 type Option func(*gin.RouterGroup)
 
+// shutdownTimeout bounds draining requests and flushing the audit log on shutdown
+const shutdownTimeout = 20 * time.Second
+
 var options = []Option{}
 var endpointList []map[string]string
 
@@ -32,7 +43,8 @@ func Include(opts ...Option) {
 	options = append(options, opts...)
 }
 func Init() *gin.Engine {
-	Include(general.Routers)
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	Include(general.Routers(services.NewApp(httpClient)))
 	var db *gorm.DB
 	if os.Getenv("RUN_UNIT_TESTS") == "true" {
 		log.Println("🧪  Test mode: skipping migrations")
@@ -42,22 +54,61 @@ func Init() *gin.Engine {
 		if err := model.MigrateAuditLog(db); err != nil {
 			log.Fatalf("audit log migration: %v", err)
 		}
+		if err := model.MigrateAuditChain(db); err != nil {
+			log.Fatalf("audit chain migration: %v", err)
+		}
+		if err := model.MigrateUpstreamCallLog(db); err != nil {
+			log.Fatalf("upstream call log migration: %v", err)
+		}
 		if err := model.MigrateRLPUserNumbering(db); err != nil {
 			log.Fatalf("rlp user numbering migration: %v", err)
 		}
+		if err := model.MigrateRLPNumberingCounter(db); err != nil {
+			log.Fatalf("rlp numbering counter migration: %v", err)
+		}
+		if err := model.MigrateRegistrationStepLog(db); err != nil {
+			log.Fatalf("registration step log migration: %v", err)
+		}
+		if err := model.MigrateRegistrationAttempt(db); err != nil {
+			log.Fatalf("registration attempt migration: %v", err)
+		}
 	}
 	r := gin.New()
-	r.Use(gin.Logger())
+	// services are handed the gin.Context, let it reach values of the request context
+	r.ContextWithFallback = true
+	// the request id goes first, every later log line and the audit log carry it
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
 	r.Use(gin.Recovery())
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
-	r.Use(middleware.HttpClientMiddleware(httpClient))
-
 	// only wire AuditLogger if we have a real DB
+	var shutdown []func(ctx context.Context)
 	if db != nil {
-		r.Use(middleware.AuditLogger(db))
+		auditConf := config.GetConfig().Application.Audit
+		auditWriter := audit.NewWriter("audit log", audit.ChainedInsert(db, auditConf.GetBatchSize()), auditConf)
+		audit.SetDefaultWriter(auditWriter)
+		shutdown = append(shutdown, closeWriter(auditWriter))
+
+		if auditConf.UpstreamCalls {
+			upstreamWriter := audit.NewWriter("upstream call log", audit.BatchInsert[*model.UpstreamCallLog](db, auditConf.GetBatchSize()), auditConf)
+			utils.SetOutboundAuditHook(middleware.OutboundAudit(upstreamWriter))
+			shutdown = append(shutdown, closeWriter(upstreamWriter))
+		}
+
+		anchorer := audit.StartAnchoring(db, auditConf)
+		shutdown = append(shutdown, func(context.Context) { anchorer.Stop() })
+
+		if auditConf.Retention > 0 {
+			sink := audit.DirSink{Dir: auditConf.GetArchiveDir()}
+			retention := audit.StartRetention(db, sink, auditConf, retentionGuard(auditConf.GetRetentionInterval()))
+			shutdown = append(shutdown, func(context.Context) { retention.Stop() })
+		}
+		r.Use(middleware.AuditLogger(auditWriter))
 	}
 
+	// innermost, so the audit log sees the responses it writes
+	r.Use(middleware.ErrorHandler())
+
 	// mount your API routes
 	apiGroup := r.Group("/api")
 	for _, opt := range options {
@@ -97,6 +148,66 @@ func Init() *gin.Engine {
 		c.Redirect(http.StatusTemporaryRedirect, "/swagger/index.html")
 	})
 
-	r.Run(fmt.Sprintf(":%d", config.GetConfig().Http.Port))
+	run(r, shutdown...)
 	return r
 }
+
+// retentionGuard lets one replica at a time run audit retention. The lock is
+// held for up to an interval, so a replica ticking just after another finished
+// finds nothing left to archive.
+func retentionGuard(ttl time.Duration) audit.RunGuard {
+	return func(ctx context.Context) (func(), bool) {
+		lock, err := mycache.GetLocker().TryObtain(ctx, "audit_retention", ttl)
+		if err != nil {
+			if !errors.Is(err, mycache.ErrLockNotObtained) {
+				log.Printf("audit retention lock error: %v", err)
+			}
+			return nil, false
+		}
+		return func() {
+			if err := lock.Release(context.Background()); err != nil {
+				log.Printf("audit retention lock release error: %v", err)
+			}
+		}, true
+	}
+}
+
+// closeWriter flushes what w still has queued.
+func closeWriter[T any](w *audit.Writer[T]) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if err := w.Close(ctx); err != nil {
+			log.Printf("audit writer flush: %v (%+v)", err, w.Stats())
+		}
+	}
+}
+
+// run serves r until SIGINT or SIGTERM, then drains in-flight requests and runs
+// the shutdown steps in order: flushing the audit writers, stopping anchoring
+// and retention.
+func run(r *gin.Engine, shutdown ...func(ctx context.Context)) {
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", config.GetConfig().Http.Port),
+		Handler: r,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("listen: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	for _, step := range shutdown {
+		step(ctx)
+	}
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"lbe/config"
+	"lbe/model"
+
+	"gorm.io/gorm"
+)
+
+// Anchor copies the current head of the audit_logs chain to audit_chain_anchors
+// and, when file is set, appends it there as a JSON line. It returns nil without
+// anchoring when the head has not moved since the last anchor.
+func Anchor(ctx context.Context, db *gorm.DB, file string) (*model.AuditChainAnchor, error) {
+	db = db.WithContext(ctx)
+
+	var head model.AuditChainHead
+	if err := db.Where("name = ?", model.AuditLogChain).First(&head).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChainHeadMissing
+		}
+		return nil, err
+	}
+	if head.Seq == 0 {
+		return nil, nil
+	}
+
+	var last model.AuditChainAnchor
+	err := db.Where("chain = ?", model.AuditLogChain).Order("seq DESC").First(&last).Error
+	if err == nil && last.Seq >= head.Seq {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	anchor := &model.AuditChainAnchor{
+		Chain:      model.AuditLogChain,
+		Seq:        head.Seq,
+		Hash:       head.Hash,
+		AnchoredAt: time.Now(),
+	}
+	if err := db.Create(anchor).Error; err != nil {
+		return nil, err
+	}
+
+	if file != "" {
+		if err := appendAnchor(file, anchor); err != nil {
+			return anchor, err
+		}
+	}
+	return anchor, nil
+}
+
+func appendAnchor(file string, anchor *model.AuditChainAnchor) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(anchor)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Anchorer anchors the chain head every application.audit.anchorInterval.
+type Anchorer struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func StartAnchoring(db *gorm.DB, conf config.AuditConfig) *Anchorer {
+	a := &Anchorer{stop: make(chan struct{}), done: make(chan struct{})}
+
+	go func() {
+		defer close(a.done)
+
+		ticker := time.NewTicker(conf.GetAnchorInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+				if _, err := Anchor(context.Background(), db, conf.AnchorFile); err != nil {
+					log.Printf("audit chain anchoring error: %v", err)
+				}
+			}
+		}
+	}()
+
+	return a
+}
+
+// Stop ends anchoring and waits for a running anchor to finish.
+func (a *Anchorer) Stop() {
+	close(a.stop)
+	<-a.done
+}
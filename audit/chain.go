@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lbe/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrChainHeadMissing = errors.New("audit chain head missing, run the migrations")
+
+// Hash returns the chain hash of entry appended after an entry hashed to prevHash.
+// Every field is length-prefixed, so moving text from one field to the next
+// changes the hash. The database id is left out, the chain sequence orders entries.
+func Hash(prevHash string, entry *model.AuditLog) string {
+	var seq int64
+	if entry.ChainSeq != nil {
+		seq = *entry.ChainSeq
+	}
+
+	fields := []string{
+		prevHash,
+		strconv.FormatInt(seq, 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.ActorID,
+		entry.Method,
+		entry.Path,
+		entry.RequestPath,
+		strconv.Itoa(entry.StatusCode),
+		entry.ClientIP,
+		entry.UserAgent,
+		entry.RequestBody,
+		entry.ResponseBody,
+		strconv.FormatInt(entry.LatencyMs, 10),
+	}
+	// entries from before request ids keep their hash
+	if entry.RequestID != "" {
+		fields = append(fields, entry.RequestID)
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChainedInsert returns an InsertFunc that appends each batch to the audit_logs
+// hash chain. The chain head is locked for the length of the transaction, so
+// batches from every replica are chained one after another.
+func ChainedInsert(db *gorm.DB, batchSize int) InsertFunc[*model.AuditLog] {
+	return func(entries []*model.AuditLog) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			var head model.AuditChainHead
+			err := tx.Raw("SELECT name, seq, hash, updated_at FROM "+head.TableName()+" WITH (UPDLOCK, HOLDLOCK) WHERE name = ?", model.AuditLogChain).
+				Scan(&head).Error
+			if err != nil {
+				return err
+			}
+			if head.Name == "" {
+				return ErrChainHeadMissing
+			}
+
+			for _, entry := range entries {
+				normalize(entry)
+				seq := head.Seq + 1
+				entry.ChainSeq = &seq
+				entry.Hash = Hash(head.Hash, entry)
+				head.Seq, head.Hash = seq, entry.Hash
+			}
+
+			if err := tx.CreateInBatches(entries, batchSize).Error; err != nil {
+				return err
+			}
+			return tx.Model(&head).Updates(map[string]any{
+				"seq":        head.Seq,
+				"hash":       head.Hash,
+				"updated_at": time.Now(),
+			}).Error
+		})
+	}
+}
+
+// normalize makes entry read back from the database exactly as it was hashed:
+// the timestamp fits the column precision and strings are valid UTF-8.
+func normalize(entry *model.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	for _, field := range []*string{
+		&entry.RequestID, &entry.ActorID, &entry.Method, &entry.Path, &entry.RequestPath,
+		&entry.ClientIP, &entry.UserAgent, &entry.RequestBody, &entry.ResponseBody,
+	} {
+		*field = strings.ToValidUTF8(*field, "\uFFFD")
+	}
+}
+
+// live leaves out entries restored from an archive.
+func live(db *gorm.DB) *gorm.DB {
+	return db.Where("restored_from IS NULL OR restored_from = ''")
+}
+
+// BrokenLink is the first entry that does not follow from the one before it.
+type BrokenLink struct {
+	Seq    int64  `json:"seq"`
+	ID     uint   `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (b *BrokenLink) Error() string {
+	if b.ID != 0 {
+		return fmt.Sprintf("audit chain broken at seq %d (id %d): %s", b.Seq, b.ID, b.Reason)
+	}
+	return fmt.Sprintf("audit chain broken at seq %d: %s", b.Seq, b.Reason)
+}
+
+// chainChecker follows the chain entry by entry.
+type chainChecker struct {
+	prevSeq  int64
+	prevHash string
+}
+
+func (c *chainChecker) check(entry *model.AuditLog) *BrokenLink {
+	seq := *entry.ChainSeq
+	switch {
+	case seq <= c.prevSeq:
+		return &BrokenLink{Seq: seq, ID: entry.ID, Reason: fmt.Sprintf("duplicate of seq %d", seq)}
+	case seq != c.prevSeq+1:
+		return &BrokenLink{Seq: c.prevSeq + 1, Reason: fmt.Sprintf("entries %d to %d are missing", c.prevSeq+1, seq-1)}
+	}
+
+	if hash := Hash(c.prevHash, entry); hash != entry.Hash {
+		return &BrokenLink{Seq: seq, ID: entry.ID, Reason: "contents or previous entry changed, hash does not match"}
+	}
+
+	c.prevSeq, c.prevHash = seq, entry.Hash
+	return nil
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"lbe/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chain builds n hashed entries the way ChainedInsert does.
+func chain(n int) []*model.AuditLog {
+	entries := make([]*model.AuditLog, n)
+	prevHash := model.AuditChainGenesisHash
+	for i := range entries {
+		seq := int64(i + 1)
+		entries[i] = &model.AuditLog{
+			ID:          uint(100 + i),
+			CreatedAt:   time.Date(2025, 5, 23, 9, 0, i, 123456000, time.UTC),
+			ActorID:     "app1234",
+			Method:      "GET",
+			Path:        "/api/v1/user/:external_id",
+			RequestPath: "/api/v1/user/25052300047",
+			StatusCode:  200,
+			LatencyMs:   int64(i),
+			ChainSeq:    &seq,
+		}
+		entries[i].Hash = Hash(prevHash, entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestHash(t *testing.T) {
+	entry := chain(1)[0]
+
+	// the timestamp's zone does not matter, only the instant
+	local := *entry
+	local.CreatedAt = entry.CreatedAt.In(time.FixedZone("SGT", 8*60*60))
+	assert.Equal(t, entry.Hash, Hash(model.AuditChainGenesisHash, &local))
+
+	// text moved between fields changes the hash
+	shifted := *entry
+	shifted.ActorID, shifted.Method = "app1234G", "ET"
+	assert.NotEqual(t, entry.Hash, Hash(model.AuditChainGenesisHash, &shifted))
+
+	assert.NotEqual(t, entry.Hash, Hash(entry.Hash, entry))
+
+	// the request id is covered once set
+	withRequestID := *entry
+	withRequestID.RequestID = "3f1c9a52-7d7e-4c53-9a0e-2b1f6c8d4e10"
+	assert.NotEqual(t, entry.Hash, Hash(model.AuditChainGenesisHash, &withRequestID))
+}
+
+func TestChainChecker(t *testing.T) {
+	tests := []struct {
+		name           string
+		tamper         func(entries []*model.AuditLog) []*model.AuditLog
+		expectedBroken *BrokenLink
+	}{
+		{
+			name:   "intact",
+			tamper: func(entries []*model.AuditLog) []*model.AuditLog { return entries },
+		},
+		{
+			name: "edited entry",
+			tamper: func(entries []*model.AuditLog) []*model.AuditLog {
+				entries[2].StatusCode = 500
+				return entries
+			},
+			expectedBroken: &BrokenLink{Seq: 3, ID: 102, Reason: "contents or previous entry changed, hash does not match"},
+		},
+		{
+			name: "edited entry with recomputed hash",
+			tamper: func(entries []*model.AuditLog) []*model.AuditLog {
+				entries[2].StatusCode = 500
+				entries[2].Hash = Hash(entries[1].Hash, entries[2])
+				return entries
+			},
+			expectedBroken: &BrokenLink{Seq: 4, ID: 103, Reason: "contents or previous entry changed, hash does not match"},
+		},
+		{
+			name: "deleted entry",
+			tamper: func(entries []*model.AuditLog) []*model.AuditLog {
+				return append(entries[:1], entries[2:]...)
+			},
+			expectedBroken: &BrokenLink{Seq: 2, Reason: "entries 2 to 2 are missing"},
+		},
+		{
+			name: "duplicated entry",
+			tamper: func(entries []*model.AuditLog) []*model.AuditLog {
+				copied := *entries[1]
+				copied.ID = 200
+				return append(entries[:2], append([]*model.AuditLog{&copied}, entries[2:]...)...)
+			},
+			expectedBroken: &BrokenLink{Seq: 2, ID: 200, Reason: "duplicate of seq 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &chainChecker{prevHash: model.AuditChainGenesisHash}
+
+			var broken *BrokenLink
+			for _, entry := range tt.tamper(chain(5)) {
+				if broken = checker.check(entry); broken != nil {
+					break
+				}
+			}
+			assert.Equal(t, tt.expectedBroken, broken)
+		})
+	}
+}
+
+func TestStartChecker(t *testing.T) {
+	entries := chain(5)
+
+	// entries 1 and 2 archived, anchored at seq 2
+	checker := &chainChecker{prevHash: model.AuditChainGenesisHash}
+	assert.Nil(t, startChecker(checker, 3, map[int64]string{2: entries[1].Hash}))
+	for _, entry := range entries[2:] {
+		assert.Nil(t, checker.check(entry))
+	}
+
+	// without the anchor the start of the chain cannot be trusted
+	checker = &chainChecker{prevHash: model.AuditChainGenesisHash}
+	assert.NotNil(t, startChecker(checker, 3, map[int64]string{}))
+}
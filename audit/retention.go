@@ -0,0 +1,422 @@
+package audit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lbe/config"
+	"lbe/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// archiveSize is the number of entries written to one archive
+	archiveSize = 10000
+	// deleteBatchSize keeps the id list of a delete under the 2100 parameter limit
+	deleteBatchSize  = 1000
+	restoreBatchSize = 500
+)
+
+var (
+	ErrArchiveRestored = errors.New("archive already restored")
+	ErrArchiveName     = errors.New("invalid archive name")
+)
+
+// Sink stores archives of expired audit entries.
+type Sink interface {
+	// Create returns a writer for a new archive; the archive only counts as
+	// stored once Close returned nil.
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// DirSink keeps archives as files in a local directory.
+type DirSink struct {
+	Dir string
+}
+
+func (s DirSink) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{File: f, path: path}, nil
+}
+
+func (s DirSink) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s DirSink) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %q", ErrArchiveName, name)
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+// dirFile is written under a temporary name and renamed once synced, so a
+// crash never leaves a truncated archive behind that looks complete.
+type dirFile struct {
+	*os.File
+	path string
+}
+
+func (f *dirFile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.File.Name(), f.path)
+}
+
+// ArchiveResult lists what one archiving run moved out of audit_logs.
+type ArchiveResult struct {
+	Archives []string `json:"archives"`
+	Entries  int64    `json:"entries"`
+}
+
+// ArchiveExpired moves the entries created before cutoff into gzip compressed
+// JSON Lines archives in sink, oldest first, deleting each chunk only after its
+// archive is stored. Chained entries are archived in chain order and only below
+// the oldest entry that is kept, and the last archived link is anchored, so the
+// remaining chain still verifies.
+func ArchiveExpired(ctx context.Context, db *gorm.DB, sink Sink, cutoff time.Time) (ArchiveResult, error) {
+	db = db.WithContext(ctx)
+	var result ArchiveResult
+
+	var boundary sql.NullInt64
+	err := db.Model(&model.AuditLog{}).
+		Scopes(live).
+		Where("created_at >= ? AND chain_seq IS NOT NULL", cutoff).
+		Select("MIN(chain_seq)").
+		Scan(&boundary).Error
+	if err != nil {
+		return result, err
+	}
+
+	expired := func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(live).Where("created_at < ?", cutoff)
+		if boundary.Valid {
+			db = db.Where("chain_seq IS NULL OR chain_seq < ?", boundary.Int64)
+		}
+		return db
+	}
+
+	for ctx.Err() == nil {
+		var entries []*model.AuditLog
+		// legacy entries without a seq sort first
+		err := db.Scopes(expired).Order("chain_seq, id").Limit(archiveSize).Find(&entries).Error
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		name := archiveName(entries)
+		if err := writeArchive(ctx, sink, name, entries); err != nil {
+			return result, fmt.Errorf("writing archive %s: %w", name, err)
+		}
+		if err := deleteArchived(db, entries); err != nil {
+			return result, fmt.Errorf("deleting entries archived to %s: %w", name, err)
+		}
+
+		result.Archives = append(result.Archives, name)
+		result.Entries += int64(len(entries))
+
+		if len(entries) < archiveSize {
+			break
+		}
+	}
+	return result, ctx.Err()
+}
+
+func archiveName(entries []*model.AuditLog) string {
+	first, last := entries[0], entries[len(entries)-1]
+	return fmt.Sprintf("audit_logs_%s_%d-%d.jsonl.gz",
+		first.CreatedAt.UTC().Format("20060102"), first.ID, last.ID)
+}
+
+func writeArchive(ctx context.Context, sink Sink, name string, entries []*model.AuditLog) error {
+	w, err := sink.Create(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// deleteArchived removes entries and anchors the last chained one, in one
+// transaction so the chain never loses entries without the anchor it resumes from.
+func deleteArchived(db *gorm.DB, entries []*model.AuditLog) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if last := entries[len(entries)-1]; last.ChainSeq != nil {
+			anchor := &model.AuditChainAnchor{
+				Chain:      model.AuditLogChain,
+				Seq:        *last.ChainSeq,
+				Hash:       last.Hash,
+				AnchoredAt: time.Now(),
+			}
+			if err := tx.Create(anchor).Error; err != nil {
+				return err
+			}
+		}
+
+		for start := 0; start < len(entries); start += deleteBatchSize {
+			end := min(start+deleteBatchSize, len(entries))
+			ids := make([]uint, 0, end-start)
+			for _, entry := range entries[start:end] {
+				ids = append(ids, entry.ID)
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&model.AuditLog{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadArchive decodes every entry of an archive.
+func ReadArchive(ctx context.Context, sink Sink, name string) ([]*model.AuditLog, error) {
+	r, err := sink.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var entries []*model.AuditLog
+	dec := json.NewDecoder(gz)
+	for {
+		var entry model.AuditLog
+		if err := dec.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+}
+
+// checkArchive follows the hash chain through the chained entries of an
+// archive. The first link is checked against the anchor of the entry before
+// it when there is one, the last against the anchor taken when archiving.
+func checkArchive(entries []*model.AuditLog, anchored map[int64]string) *BrokenLink {
+	var checker *chainChecker
+	var last *model.AuditLog
+
+	for _, entry := range entries {
+		if entry.ChainSeq == nil {
+			continue
+		}
+
+		if checker == nil {
+			seq := *entry.ChainSeq
+			switch hash, ok := anchored[seq-1]; {
+			case seq == 1:
+				checker = &chainChecker{prevHash: model.AuditChainGenesisHash}
+			case ok:
+				checker = &chainChecker{prevSeq: seq - 1, prevHash: hash}
+			default:
+				// nothing vouches for the first link, take it as given
+				checker = &chainChecker{prevSeq: seq, prevHash: entry.Hash}
+				last = entry
+				continue
+			}
+		}
+
+		if broken := checker.check(entry); broken != nil {
+			return broken
+		}
+		last = entry
+	}
+
+	if last != nil {
+		if hash, ok := anchored[*last.ChainSeq]; ok && hash != last.Hash {
+			return &BrokenLink{Seq: *last.ChainSeq, ID: last.ID, Reason: "hash differs from the anchored hash"}
+		}
+	}
+	return nil
+}
+
+// Restore loads an archive back into audit_logs for an investigation. The
+// archive is checked against its hashes and anchors first. Restored entries get
+// new ids and keep the archive name in restored_from, which keeps them out of
+// the chain and of retention until DropRestored removes them again.
+func Restore(ctx context.Context, db *gorm.DB, sink Sink, name string) (int, error) {
+	db = db.WithContext(ctx)
+
+	var restored int64
+	if err := db.Model(&model.AuditLog{}).Where("restored_from = ?", name).Count(&restored).Error; err != nil {
+		return 0, err
+	}
+	if restored > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrArchiveRestored, name)
+	}
+
+	entries, err := ReadArchive(ctx, sink, name)
+	if err != nil {
+		return 0, fmt.Errorf("reading archive %s: %w", name, err)
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	var anchors []model.AuditChainAnchor
+	if err := db.Where("chain = ?", model.AuditLogChain).Find(&anchors).Error; err != nil {
+		return 0, err
+	}
+	anchored := make(map[int64]string, len(anchors))
+	for _, anchor := range anchors {
+		anchored[anchor.Seq] = anchor.Hash
+	}
+	if broken := checkArchive(entries, anchored); broken != nil {
+		return 0, fmt.Errorf("archive %s: %w", name, broken)
+	}
+
+	for _, entry := range entries {
+		entry.ID = 0
+		entry.RestoredFrom = name
+	}
+	if err := db.CreateInBatches(entries, restoreBatchSize).Error; err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// DropRestored deletes the entries restored from an archive.
+func DropRestored(ctx context.Context, db *gorm.DB, name string) (int64, error) {
+	if name == "" {
+		return 0, ErrArchiveName
+	}
+	res := db.WithContext(ctx).Where("restored_from = ?", name).Delete(&model.AuditLog{})
+	return res.RowsAffected, res.Error
+}
+
+// PurgeUpstreamCalls deletes the upstream calls made before cutoff. They help
+// diagnose failed requests but are not part of the audit trail, so they are not
+// archived.
+func PurgeUpstreamCalls(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	db = db.WithContext(ctx)
+	table := (&model.UpstreamCallLog{}).TableName()
+
+	var purged int64
+	for ctx.Err() == nil {
+		res := db.Exec("DELETE TOP (?) FROM "+table+" WHERE created_at < ?", deleteBatchSize, cutoff)
+		if res.Error != nil {
+			return purged, res.Error
+		}
+		purged += res.RowsAffected
+		if res.RowsAffected < deleteBatchSize {
+			break
+		}
+	}
+	return purged, ctx.Err()
+}
+
+// RunGuard keeps a retention run from overlapping one on another replica. It
+// reports whether this replica may run, and release is called when it is done.
+type RunGuard func(ctx context.Context) (release func(), ok bool)
+
+// Retention archives expired entries every application.audit.retentionInterval.
+type Retention struct {
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func StartRetention(db *gorm.DB, sink Sink, conf config.AuditConfig, guard RunGuard) *Retention {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Retention{stop: make(chan struct{}), done: make(chan struct{}), cancel: cancel}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(conf.GetRetentionInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+
+			release, ok := guard(ctx)
+			if !ok {
+				continue
+			}
+			cutoff := time.Now().Add(-conf.Retention)
+			result, err := ArchiveExpired(ctx, db, sink, cutoff)
+			if err != nil {
+				log.Printf("audit retention error: %v", err)
+			}
+			if result.Entries > 0 {
+				log.Printf("audit retention archived %d entries to %v", result.Entries, result.Archives)
+			}
+
+			purged, err := PurgeUpstreamCalls(ctx, db, cutoff)
+			release()
+			if err != nil {
+				log.Printf("upstream call retention error: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("upstream call retention deleted %d calls", purged)
+			}
+		}
+	}()
+
+	return r
+}
+
+// Stop ends retention, cancelling a running archive between chunks.
+func (r *Retention) Stop() {
+	close(r.stop)
+	r.cancel()
+	<-r.done
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lbe/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	sink := DirSink{Dir: filepath.Join(t.TempDir(), "archive")}
+	entries := chain(5)
+	name := archiveName(entries)
+	assert.Equal(t, "audit_logs_20250523_100-104.jsonl.gz", name)
+
+	err := writeArchive(context.Background(), sink, name, entries)
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(sink.Dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "temporary file left behind")
+
+	read, err := ReadArchive(context.Background(), sink, name)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, read)
+}
+
+func TestDirSinkName(t *testing.T) {
+	sink := DirSink{Dir: t.TempDir()}
+	for _, name := range []string{"", "../audit_logs.jsonl.gz", "sub/audit_logs.jsonl.gz", ".hidden"} {
+		_, err := sink.Open(context.Background(), name)
+		assert.ErrorIs(t, err, ErrArchiveName, name)
+	}
+}
+
+func TestCheckArchive(t *testing.T) {
+	entries := chain(6)
+
+	tests := []struct {
+		name           string
+		entries        []*model.AuditLog
+		anchored       map[int64]string
+		expectedBroken *BrokenLink
+	}{
+		{
+			name:    "from genesis",
+			entries: entries[:3],
+		},
+		{
+			name:     "resumes from the anchor of an earlier archive",
+			entries:  entries[3:],
+			anchored: map[int64]string{3: entries[2].Hash, 6: entries[5].Hash},
+		},
+		{
+			name:    "first link taken as given without an anchor",
+			entries: entries[3:],
+		},
+		{
+			name:           "anchor of an earlier archive does not match",
+			entries:        entries[3:],
+			anchored:       map[int64]string{3: entries[1].Hash},
+			expectedBroken: &BrokenLink{Seq: 4, ID: 103, Reason: "contents or previous entry changed, hash does not match"},
+		},
+		{
+			name:           "last entry differs from the anchor taken when archiving",
+			entries:        entries[:3],
+			anchored:       map[int64]string{3: entries[4].Hash},
+			expectedBroken: &BrokenLink{Seq: 3, ID: 102, Reason: "hash differs from the anchored hash"},
+		},
+		{
+			name: "edited entry",
+			entries: func() []*model.AuditLog {
+				edited := *entries[1]
+				edited.StatusCode = 500
+				return []*model.AuditLog{entries[0], &edited, entries[2]}
+			}(),
+			expectedBroken: &BrokenLink{Seq: 2, ID: 101, Reason: "contents or previous entry changed, hash does not match"},
+		},
+		{
+			name:    "legacy entries are skipped",
+			entries: append([]*model.AuditLog{{ID: 1, Method: "GET"}}, entries[:2]...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedBroken, checkArchive(tt.entries, tt.anchored))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"lbe/model"
+
+	"gorm.io/gorm"
+)
+
+const verifyBatchSize = 1000
+
+// VerifyReport summarises a walk over the audit_logs hash chain.
+type VerifyReport struct {
+	FirstSeq       int64 `json:"first_seq"`
+	HeadSeq        int64 `json:"head_seq"`
+	Checked        int64 `json:"checked"`
+	AnchorsChecked int   `json:"anchors_checked"`
+	// Broken is the first link that failed, nil when the chain is intact
+	Broken *BrokenLink `json:"broken,omitempty"`
+}
+
+// Verify walks the audit_logs chain from its oldest remaining entry up to the
+// current head, recomputing every hash and comparing it with the anchors. Entries
+// appended while it runs are left for the next run. The returned error is only
+// set when the walk itself failed; a broken chain is reported in VerifyReport.Broken.
+func Verify(ctx context.Context, db *gorm.DB) (VerifyReport, error) {
+	db = db.WithContext(ctx)
+
+	var head model.AuditChainHead
+	if err := db.Where("name = ?", model.AuditLogChain).First(&head).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return VerifyReport{}, ErrChainHeadMissing
+		}
+		return VerifyReport{}, err
+	}
+	report := VerifyReport{HeadSeq: head.Seq}
+
+	var anchors []model.AuditChainAnchor
+	if err := db.Where("chain = ? AND seq <= ?", model.AuditLogChain, head.Seq).Find(&anchors).Error; err != nil {
+		return report, err
+	}
+	anchored := make(map[int64]string, len(anchors))
+	for _, anchor := range anchors {
+		anchored[anchor.Seq] = anchor.Hash
+	}
+
+	checker := &chainChecker{prevHash: model.AuditChainGenesisHash}
+	var lastSeq int64
+	var lastId uint
+
+	for {
+		var batch []*model.AuditLog
+		// ordered by id as well, so a copied row with a duplicate seq is visited too
+		err := db.Scopes(live).
+			Where("chain_seq <= ? AND (chain_seq > ? OR (chain_seq = ? AND id > ?))", head.Seq, lastSeq, lastSeq, lastId).
+			Order("chain_seq, id").
+			Limit(verifyBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return report, err
+		}
+
+		for _, entry := range batch {
+			if report.Checked == 0 {
+				report.FirstSeq = *entry.ChainSeq
+				if broken := startChecker(checker, *entry.ChainSeq, anchored); broken != nil {
+					report.Broken = broken
+					return report, nil
+				}
+			}
+
+			if broken := checker.check(entry); broken != nil {
+				report.Broken = broken
+				return report, nil
+			}
+			report.Checked++
+
+			if hash, ok := anchored[*entry.ChainSeq]; ok {
+				if hash != entry.Hash {
+					report.Broken = &BrokenLink{Seq: *entry.ChainSeq, ID: entry.ID, Reason: "hash differs from the anchored hash"}
+					return report, nil
+				}
+				report.AnchorsChecked++
+			}
+		}
+
+		if len(batch) < verifyBatchSize {
+			break
+		}
+		last := batch[len(batch)-1]
+		lastSeq, lastId = *last.ChainSeq, last.ID
+	}
+
+	// every entry archived, the anchor taken when archiving vouches for the head
+	if report.Checked == 0 && head.Seq > 0 && anchored[head.Seq] == head.Hash {
+		return report, nil
+	}
+
+	// entries removed from the end leave the head pointing past the last entry
+	switch {
+	case checker.prevSeq != head.Seq:
+		report.Broken = &BrokenLink{Seq: checker.prevSeq + 1, Reason: fmt.Sprintf("entries %d to %d are missing", checker.prevSeq+1, head.Seq)}
+	case checker.prevHash != head.Hash && head.Seq > 0:
+		report.Broken = &BrokenLink{Seq: head.Seq, Reason: "hash differs from the chain head"}
+	}
+	return report, nil
+}
+
+// startChecker lets the walk begin after entries removed by archiving: the
+// entry before the oldest remaining one must have been anchored.
+func startChecker(checker *chainChecker, firstSeq int64, anchored map[int64]string) *BrokenLink {
+	if firstSeq == 1 {
+		return nil
+	}
+
+	hash, ok := anchored[firstSeq-1]
+	if !ok {
+		return &BrokenLink{Seq: 1, Reason: fmt.Sprintf("entries 1 to %d are missing and no anchor marks where the chain resumes", firstSeq-1)}
+	}
+	checker.prevSeq, checker.prevHash = firstSeq-1, hash
+	return nil
+}
@@ -0,0 +1,207 @@
+// Package audit persists the audit trail of API requests and the upstream calls
+// they make.
+package audit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lbe/config"
+	"lbe/model"
+
+	"gorm.io/gorm"
+)
+
+// InsertFunc persists one batch of entries.
+type InsertFunc[T any] func(entries []T) error
+
+// BatchInsert returns an InsertFunc that creates the entries with db, batchSize rows per statement.
+func BatchInsert[T any](db *gorm.DB, batchSize int) InsertFunc[T] {
+	return func(entries []T) error {
+		return db.CreateInBatches(entries, batchSize).Error
+	}
+}
+
+// Stats is a snapshot of the writer counters, for alerting when audit
+// persistence falls behind.
+type Stats struct {
+	QueueDepth    int   `json:"queue_depth"`
+	QueueCapacity int   `json:"queue_capacity"`
+	Enqueued      int64 `json:"enqueued"`
+	Written       int64 `json:"written"`
+	// Dropped entries found the queue full for longer than the enqueue timeout
+	Dropped int64 `json:"dropped"`
+	// Failed entries were part of a batch the database rejected
+	Failed        int64 `json:"failed"`
+	FailedBatches int64 `json:"failed_batches"`
+}
+
+// Writer queues audit entries in a bounded channel and inserts them in batches
+// from a single goroutine, so request handling neither waits for the database
+// nor spawns a goroutine per entry.
+type Writer[T any] struct {
+	name           string
+	insert         InsertFunc[T]
+	queue          chan T
+	batchSize      int
+	flushInterval  time.Duration
+	enqueueTimeout time.Duration
+
+	// mu guards closed: Enqueue sends under the read lock, Close closes the queue under the write lock
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	enqueued      atomic.Int64
+	written       atomic.Int64
+	dropped       atomic.Int64
+	failed        atomic.Int64
+	failedBatches atomic.Int64
+}
+
+// NewWriter starts a writer sized by application.audit, name tells its entries
+// apart in the logs. Close must be called on shutdown to flush what is still queued.
+func NewWriter[T any](name string, insert InsertFunc[T], conf config.AuditConfig) *Writer[T] {
+	w := &Writer[T]{
+		name:           name,
+		insert:         insert,
+		queue:          make(chan T, conf.GetQueueSize()),
+		batchSize:      conf.GetBatchSize(),
+		flushInterval:  conf.GetFlushInterval(),
+		enqueueTimeout: conf.GetEnqueueTimeout(),
+		done:           make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Enqueue hands entry to the writer. When the queue is full it waits up to the
+// enqueue timeout, slowing the caller down, then drops the entry and returns false.
+func (w *Writer[T]) Enqueue(entry T) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case w.queue <- entry:
+		w.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(w.enqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case w.queue <- entry:
+		w.enqueued.Add(1)
+		return true
+	case <-timer.C:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting entries and waits until the queued ones are written or
+// ctx is done.
+func (w *Writer[T]) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer[T]) Stats() Stats {
+	return Stats{
+		QueueDepth:    len(w.queue),
+		QueueCapacity: cap(w.queue),
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Dropped:       w.dropped.Load(),
+		Failed:        w.failed.Load(),
+		FailedBatches: w.failedBatches.Load(),
+	}
+}
+
+func (w *Writer[T]) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, w.batchSize)
+	var reportedDrops int64
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+
+			if dropped := w.dropped.Load(); dropped > reportedDrops {
+				log.Printf("%s queue full, dropped %d entries (%d in total)", w.name, dropped-reportedDrops, dropped)
+				reportedDrops = dropped
+			}
+		}
+	}
+}
+
+func (w *Writer[T]) flush(batch []T) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := w.insert(batch); err != nil {
+		w.failed.Add(int64(len(batch)))
+		w.failedBatches.Add(1)
+		log.Printf("%s persistence error, lost %d entries: %v", w.name, len(batch), err)
+		return
+	}
+	w.written.Add(int64(len(batch)))
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultWriter *Writer[*model.AuditLog]
+)
+
+// SetDefaultWriter registers the writer used by the API, for the stats endpoint.
+func SetDefaultWriter(w *Writer[*model.AuditLog]) {
+	defaultMu.Lock()
+	defaultWriter = w
+	defaultMu.Unlock()
+}
+
+// DefaultWriter returns the writer registered with SetDefaultWriter, or nil.
+func DefaultWriter() *Writer[*model.AuditLog] {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultWriter
+}
@@ -0,0 +1,125 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lbe/audit"
+	"lbe/config"
+	"lbe/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]*model.AuditLog
+	block   chan struct{}
+	err     error
+}
+
+func (r *recorder) insert(entries []*model.AuditLog) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]*model.AuditLog(nil), entries...))
+	return r.err
+}
+
+func (r *recorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, b := range r.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		name          string
+		conf          config.AuditConfig
+		entries       int
+		insertErr     error
+		expectedSizes []int
+		expectedStats audit.Stats
+	}{
+		{
+			name:          "full batches and the remainder flushed on close",
+			conf:          config.AuditConfig{QueueSize: 100, BatchSize: 4, FlushInterval: time.Hour},
+			entries:       10,
+			expectedSizes: []int{4, 4, 2},
+			expectedStats: audit.Stats{QueueCapacity: 100, Enqueued: 10, Written: 10},
+		},
+		{
+			name:          "failed batches are counted",
+			conf:          config.AuditConfig{QueueSize: 100, BatchSize: 5, FlushInterval: time.Hour},
+			entries:       7,
+			insertErr:     errors.New("db down"),
+			expectedSizes: []int{5, 2},
+			expectedStats: audit.Stats{QueueCapacity: 100, Enqueued: 7, Failed: 7, FailedBatches: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{err: tt.insertErr}
+			w := audit.NewWriter("audit log", rec.insert, tt.conf)
+
+			for i := 0; i < tt.entries; i++ {
+				assert.True(t, w.Enqueue(&model.AuditLog{Path: "/api/v1/user"}))
+			}
+			assert.NoError(t, w.Close(context.Background()))
+
+			assert.Equal(t, tt.expectedSizes, rec.sizes())
+			assert.Equal(t, tt.expectedStats, w.Stats())
+		})
+	}
+}
+
+func TestWriter_FlushInterval(t *testing.T) {
+	rec := &recorder{}
+	w := audit.NewWriter("audit log", rec.insert, config.AuditConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer w.Close(context.Background())
+
+	w.Enqueue(&model.AuditLog{})
+
+	assert.Eventually(t, func() bool { return w.Stats().Written == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWriter_DropsWhenFull(t *testing.T) {
+	rec := &recorder{block: make(chan struct{})}
+	w := audit.NewWriter("audit log", rec.insert, config.AuditConfig{
+		QueueSize:      2,
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		EnqueueTimeout: 5 * time.Millisecond,
+	})
+
+	// the first entry is taken by the blocked insert, two more fill the queue
+	assert.True(t, w.Enqueue(&model.AuditLog{}))
+	assert.Eventually(t, func() bool { return w.Stats().QueueDepth == 0 }, time.Second, time.Millisecond)
+
+	accepted := 1
+	for i := 0; i < 4; i++ {
+		if w.Enqueue(&model.AuditLog{}) {
+			accepted++
+		}
+	}
+
+	stats := w.Stats()
+	assert.Equal(t, 3, accepted)
+	assert.Equal(t, int64(2), stats.Dropped)
+	assert.Equal(t, 2, stats.QueueDepth)
+
+	close(rec.block)
+	assert.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, int64(3), w.Stats().Written)
+	assert.False(t, w.Enqueue(&model.AuditLog{}), "closed writer accepts no entries")
+}
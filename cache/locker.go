@@ -0,0 +1,145 @@
+package mycache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"lbe/config"
+	"lbe/system"
+	"lbe/utils"
+)
+
+var (
+	ErrLockNotObtained = errors.New("lock not obtained")
+	ErrLockNotHeld     = errors.New("lock not held")
+)
+
+// Locker hands out mutual exclusion locks by key.
+type Locker interface {
+	// TryObtain takes the lock on key for ttl, or returns ErrLockNotObtained
+	// straight away when it is held by someone else.
+	TryObtain(ctx context.Context, key string, ttl time.Duration) (*Lock, error)
+}
+
+// Lock is a held lock. It expires on its own after the ttl it was obtained with,
+// unless its lease is extended.
+type Lock struct {
+	Key string
+	// Token is a fencing token, greater than the token of every earlier holder of Key.
+	// Writers can pass it along so stale holders whose lock expired are rejected.
+	Token int64
+
+	release func(ctx context.Context) error
+	refresh func(ctx context.Context, ttl time.Duration) error
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Release gives the lock back. ErrLockNotHeld means it expired and may have been
+// taken by someone else in the meantime.
+func (l *Lock) Release(ctx context.Context) error {
+	if l.stop != nil {
+		l.stopOnce.Do(func() { close(l.stop) })
+	}
+	return l.release(ctx)
+}
+
+// Refresh extends the lease of the lock to ttl from now. ErrLockNotHeld means it
+// already expired.
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	return l.refresh(ctx, ttl)
+}
+
+// keepAlive extends the lease every third of ttl until the lock is released or lost,
+// so a holder running longer than ttl keeps the lock. The ttl then only bounds how
+// long a replica that died while holding it blocks the key.
+func (l *Lock) keepAlive(ctx context.Context, ttl time.Duration) {
+	// the lease outlives the request that took it, its request id stays in the logs
+	ctx = context.WithoutCancel(ctx)
+	l.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				if err := l.refresh(ctx, ttl); err != nil {
+					utils.Logf(ctx, "error extending lock %s (token %d): %v", l.Key, l.Token, err)
+					if errors.Is(err, ErrLockNotHeld) {
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+var (
+	lockerMu sync.Mutex
+	locker   Locker
+)
+
+// GetLocker returns the Redis locker shared by all replicas. Without Redis it falls
+// back to an in-memory locker, which does not hold across replicas and is only fit
+// for a single-instance run, so the fallback is logged as a warning.
+func GetLocker() Locker {
+	lockerMu.Lock()
+	defer lockerMu.Unlock()
+
+	if locker == nil {
+		if rdb := system.GetRedis(); rdb != nil {
+			locker = NewRedisLocker(rdb)
+		} else {
+			log.Println("⚠️  WARNING: redis is not configured, locks only hold within this process and do not serialise requests across replicas")
+			locker = NewMemoryLocker()
+		}
+	}
+	return locker
+}
+
+// SetLocker replaces the locker returned by GetLocker.
+func SetLocker(l Locker) {
+	lockerMu.Lock()
+	locker = l
+	lockerMu.Unlock()
+}
+
+// Obtain takes the lock on key from l, waiting up to application.lock.waitTimeout
+// while it is held elsewhere.
+func Obtain(ctx context.Context, l Locker, key string) (*Lock, error) {
+	conf := config.GetConfig().Application.Lock
+	return ObtainWith(ctx, l, key, conf.GetTtl(), conf.GetWaitTimeout(), conf.GetRetryInterval())
+}
+
+// ObtainWith retries TryObtain every retryInterval until the lock is obtained, wait
+// has passed or ctx is done, in which case ErrLockNotObtained is returned. The lease
+// of the obtained lock is extended until it is released.
+func ObtainWith(ctx context.Context, l Locker, key string, ttl, wait, retryInterval time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		lock, err := l.TryObtain(ctx, key, ttl)
+		if err == nil {
+			lock.keepAlive(ctx, ttl)
+			return lock, nil
+		}
+		if !errors.Is(err, ErrLockNotObtained) {
+			return nil, err
+		}
+
+		if time.Now().Add(retryInterval).After(deadline) {
+			return nil, ErrLockNotObtained
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockNotObtained
+		case <-time.After(retryInterval):
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package mycache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	mycache "lbe/cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	tests := []struct {
+		name            string
+		ttl             time.Duration
+		sleep           time.Duration
+		expectedObtain  error
+		expectedRelease error
+	}{
+		{name: "held lock blocks the second caller", ttl: time.Minute, expectedObtain: mycache.ErrLockNotObtained},
+		{name: "expired lock is taken over", ttl: 10 * time.Millisecond, sleep: 20 * time.Millisecond, expectedRelease: mycache.ErrLockNotHeld},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			locker := mycache.NewMemoryLocker()
+
+			first, err := locker.TryObtain(ctx, "member", tt.ttl)
+			assert.NoError(t, err)
+
+			time.Sleep(tt.sleep)
+
+			second, err := locker.TryObtain(ctx, "member", tt.ttl)
+			assert.ErrorIs(t, err, tt.expectedObtain)
+			if second != nil {
+				assert.Greater(t, second.Token, first.Token)
+			}
+
+			assert.ErrorIs(t, first.Release(ctx), tt.expectedRelease)
+		})
+	}
+}
+
+func TestObtainWith_SerialisesHolders(t *testing.T) {
+	ctx := context.Background()
+	locker := mycache.NewMemoryLocker()
+
+	var mu sync.Mutex
+	inside, maxInside, lastToken := 0, 0, int64(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := mycache.ObtainWith(ctx, locker, "member", time.Minute, 5*time.Second, time.Millisecond)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			mu.Lock()
+			inside++
+			maxInside = max(maxInside, inside)
+			assert.Greater(t, lock.Token, lastToken)
+			lastToken = lock.Token
+			mu.Unlock()
+
+			time.Sleep(2 * time.Millisecond)
+
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			assert.NoError(t, lock.Release(ctx))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, maxInside)
+}
+
+func TestObtainWith_GivesUpAfterWait(t *testing.T) {
+	ctx := context.Background()
+	locker := mycache.NewMemoryLocker()
+
+	_, err := locker.TryObtain(ctx, "member", time.Minute)
+	assert.NoError(t, err)
+
+	_, err = mycache.ObtainWith(ctx, locker, "member", time.Minute, 20*time.Millisecond, 5*time.Millisecond)
+	assert.ErrorIs(t, err, mycache.ErrLockNotObtained)
+}
+
+func TestObtainWith_KeepsLeaseUntilRelease(t *testing.T) {
+	ctx := context.Background()
+	locker := mycache.NewMemoryLocker()
+
+	lock, err := mycache.ObtainWith(ctx, locker, "member", 30*time.Millisecond, time.Second, time.Millisecond)
+	assert.NoError(t, err)
+
+	// held well past its ttl while the holder runs
+	time.Sleep(100 * time.Millisecond)
+	_, err = locker.TryObtain(ctx, "member", time.Minute)
+	assert.ErrorIs(t, err, mycache.ErrLockNotObtained)
+
+	assert.NoError(t, lock.Release(ctx))
+	_, err = locker.TryObtain(ctx, "member", time.Minute)
+	assert.NoError(t, err)
+}
+
+func TestMemoryLockerSemantics(t *testing.T) {
+	testLockerSemantics(t, mycache.NewMemoryLocker(), "member")
+}
+
+// testLockerSemantics checks what every Locker guarantees, using key and keys
+// derived from it.
+func testLockerSemantics(t *testing.T, locker mycache.Locker, key string) {
+	ctx := context.Background()
+
+	t.Run("release frees the key", func(t *testing.T) {
+		first, err := locker.TryObtain(ctx, key+":release", time.Minute)
+		assert.NoError(t, err)
+		assert.NoError(t, first.Release(ctx))
+
+		second, err := locker.TryObtain(ctx, key+":release", time.Minute)
+		assert.NoError(t, err)
+		assert.Greater(t, second.Token, first.Token)
+		assert.NoError(t, second.Release(ctx))
+	})
+
+	t.Run("stale holder cannot release the next holder", func(t *testing.T) {
+		stale, err := locker.TryObtain(ctx, key+":stale", 20*time.Millisecond)
+		assert.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+
+		current, err := locker.TryObtain(ctx, key+":stale", time.Minute)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, stale.Release(ctx), mycache.ErrLockNotHeld)
+		assert.ErrorIs(t, stale.Refresh(ctx, time.Minute), mycache.ErrLockNotHeld)
+		_, err = locker.TryObtain(ctx, key+":stale", time.Minute)
+		assert.ErrorIs(t, err, mycache.ErrLockNotObtained)
+
+		assert.NoError(t, current.Release(ctx))
+	})
+
+	t.Run("refresh extends the lease", func(t *testing.T) {
+		lock, err := locker.TryObtain(ctx, key+":refresh", 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Refresh(ctx, time.Minute))
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = locker.TryObtain(ctx, key+":refresh", time.Minute)
+		assert.ErrorIs(t, err, mycache.ErrLockNotObtained)
+		assert.NoError(t, lock.Release(ctx))
+	})
+}
@@ -0,0 +1,71 @@
+package mycache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryHold struct {
+	token     int64
+	expiresAt time.Time
+}
+
+type memoryLocker struct {
+	mu     sync.Mutex
+	held   map[string]memoryHold
+	fences map[string]int64
+}
+
+// NewMemoryLocker returns a Locker that only holds within this process, meant for
+// tests and single-instance runs.
+func NewMemoryLocker() Locker {
+	return &memoryLocker{
+		held:   make(map[string]memoryHold),
+		fences: make(map[string]int64),
+	}
+}
+
+func (l *memoryLocker) TryObtain(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if hold, ok := l.held[key]; ok && time.Now().Before(hold.expiresAt) {
+		return nil, ErrLockNotObtained
+	}
+
+	l.fences[key]++
+	token := l.fences[key]
+	l.held[key] = memoryHold{token: token, expiresAt: time.Now().Add(ttl)}
+
+	return &Lock{
+		Key:   key,
+		Token: token,
+		release: func(ctx context.Context) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			hold, ok := l.held[key]
+			if !ok || hold.token != token {
+				return ErrLockNotHeld
+			}
+			delete(l.held, key)
+			if !time.Now().Before(hold.expiresAt) {
+				return ErrLockNotHeld
+			}
+			return nil
+		},
+		refresh: func(ctx context.Context, ttl time.Duration) error {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			hold, ok := l.held[key]
+			if !ok || hold.token != token || !time.Now().Before(hold.expiresAt) {
+				return ErrLockNotHeld
+			}
+			hold.expiresAt = time.Now().Add(ttl)
+			l.held[key] = hold
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,86 @@
+package mycache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fenceTtl keeps the fencing counter of a key around well past any lock ttl, so the
+// token only restarts once no holder can be left over.
+const fenceTtl = 24 * time.Hour
+
+// releaseScript deletes the lock only while it still carries the caller's token,
+// so a holder whose lock expired cannot release the next holder's lock.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// refreshScript extends the lock only while it still carries the caller's token.
+var refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type redisLocker struct {
+	rdb *redis.Client
+}
+
+// NewRedisLocker returns a Locker that holds across every replica using rdb.
+func NewRedisLocker(rdb *redis.Client) Locker {
+	return &redisLocker{rdb: rdb}
+}
+
+func (l *redisLocker) TryObtain(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	lockKey := "lock:" + key
+	fenceKey := "lock_fence:" + key
+
+	// a token is drawn on every attempt, failed attempts only leave gaps
+	pipe := l.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, fenceKey)
+	pipe.Expire(ctx, fenceKey, fenceTtl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	token := strconv.FormatInt(incr.Val(), 10)
+
+	ok, err := l.rdb.SetNX(ctx, lockKey, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotObtained
+	}
+
+	return &Lock{
+		Key:   key,
+		Token: incr.Val(),
+		release: func(ctx context.Context) error {
+			deleted, err := releaseScript.Run(ctx, l.rdb, []string{lockKey}, token).Int()
+			if err != nil {
+				return err
+			}
+			if deleted == 0 {
+				return ErrLockNotHeld
+			}
+			return nil
+		},
+		refresh: func(ctx context.Context, ttl time.Duration) error {
+			extended, err := refreshScript.Run(ctx, l.rdb, []string{lockKey}, token, ttl.Milliseconds()).Int()
+			if err != nil {
+				return err
+			}
+			if extended == 0 {
+				return ErrLockNotHeld
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,24 @@
+package mycache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mycache "lbe/cache"
+	"lbe/system"
+)
+
+func TestRedisLockerSemantics(t *testing.T) {
+	rdb := system.GetRedis()
+	if rdb == nil {
+		t.Skip("redis not configured")
+	}
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis not reachable: %v", err)
+	}
+
+	// the fence and lock keys expire on their own
+	testLockerSemantics(t, mycache.NewRedisLocker(rdb), fmt.Sprintf("locker-test-%d", time.Now().UnixNano()))
+}
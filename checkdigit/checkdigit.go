@@ -0,0 +1,132 @@
+// Package checkdigit computes and verifies single decimal check digits that catch
+// the typing mistakes people make when keying in card numbers.
+package checkdigit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Algorithm names a check digit scheme as written in configuration.
+type Algorithm string
+
+const (
+	// None disables the check digit.
+	None Algorithm = ""
+	// Luhn catches every single-digit error and most adjacent transpositions.
+	Luhn Algorithm = "luhn"
+	// Damm catches every single-digit error and every adjacent transposition.
+	Damm Algorithm = "damm"
+)
+
+var ErrNotDigits = errors.New("checkdigit: input must be a non-empty string of digits")
+
+// Parse returns the algorithm for a configured name, ignoring case.
+func Parse(name string) (Algorithm, error) {
+	switch alg := Algorithm(strings.ToLower(strings.TrimSpace(name))); alg {
+	case None, Luhn, Damm:
+		return alg, nil
+	default:
+		return None, fmt.Errorf("checkdigit: unknown algorithm %q", name)
+	}
+}
+
+// Compute returns the check digit of digits. With None it returns 0 and no error.
+func Compute(alg Algorithm, digits string) (byte, error) {
+	if !isDigits(digits) {
+		return 0, ErrNotDigits
+	}
+
+	switch alg {
+	case None:
+		return 0, nil
+	case Luhn:
+		return luhn(digits), nil
+	case Damm:
+		return damm(digits), nil
+	default:
+		return 0, fmt.Errorf("checkdigit: unknown algorithm %q", alg)
+	}
+}
+
+// Append returns digits followed by its check digit, or digits unchanged with None.
+func Append(alg Algorithm, digits string) (string, error) {
+	if alg == None {
+		if !isDigits(digits) {
+			return "", ErrNotDigits
+		}
+		return digits, nil
+	}
+
+	check, err := Compute(alg, digits)
+	if err != nil {
+		return "", err
+	}
+	return digits + string(check), nil
+}
+
+// Valid reports whether the last digit of number is the check digit of the rest.
+func Valid(alg Algorithm, number string) bool {
+	if alg == None || len(number) < 2 {
+		return false
+	}
+
+	check, err := Compute(alg, number[:len(number)-1])
+	if err != nil {
+		return false
+	}
+	return number[len(number)-1] == check
+}
+
+func luhn(digits string) byte {
+	sum := 0
+	// the digit left of the check digit is doubled, then every second one going left
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// dammTable is a totally anti-symmetric quasigroup of order 10.
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+func damm(digits string) byte {
+	var interim byte
+	for i := 0; i < len(digits); i++ {
+		interim = dammTable[interim][digits[i]-'0']
+	}
+	return '0' + interim
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
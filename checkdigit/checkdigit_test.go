@@ -0,0 +1,78 @@
+package checkdigit_test
+
+import (
+	"testing"
+
+	"lbe/checkdigit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name        string
+		alg         checkdigit.Algorithm
+		digits      string
+		expected    string
+		expectedErr error
+	}{
+		{name: "luhn", alg: checkdigit.Luhn, digits: "7992739871", expected: "79927398713"},
+		{name: "luhn rlp_no", alg: checkdigit.Luhn, digits: "70000000001", expected: "700000000013"},
+		{name: "damm", alg: checkdigit.Damm, digits: "572", expected: "5724"},
+		{name: "none", alg: checkdigit.None, digits: "70000000001", expected: "70000000001"},
+		{name: "not digits", alg: checkdigit.Luhn, digits: "7000A", expectedErr: checkdigit.ErrNotDigits},
+		{name: "empty", alg: checkdigit.Damm, digits: "", expectedErr: checkdigit.ErrNotDigits},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := checkdigit.Append(tt.alg, tt.digits)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestValid_DetectsTypos(t *testing.T) {
+	for _, alg := range []checkdigit.Algorithm{checkdigit.Luhn, checkdigit.Damm} {
+		t.Run(string(alg), func(t *testing.T) {
+			number, err := checkdigit.Append(alg, "70000012345")
+			assert.NoError(t, err)
+			assert.True(t, checkdigit.Valid(alg, number))
+
+			// every single-digit substitution is caught
+			for i := 0; i < len(number); i++ {
+				for d := byte('0'); d <= '9'; d++ {
+					if d == number[i] {
+						continue
+					}
+					typo := number[:i] + string(d) + number[i+1:]
+					assert.False(t, checkdigit.Valid(alg, typo), typo)
+				}
+			}
+		})
+	}
+
+	// Damm also catches every adjacent transposition
+	number, _ := checkdigit.Append(checkdigit.Damm, "70000012345")
+	for i := 0; i+1 < len(number); i++ {
+		if number[i] == number[i+1] {
+			continue
+		}
+		swapped := number[:i] + string(number[i+1]) + string(number[i]) + number[i+2:]
+		assert.False(t, checkdigit.Valid(checkdigit.Damm, swapped), swapped)
+	}
+}
+
+func TestParse(t *testing.T) {
+	alg, err := checkdigit.Parse(" Luhn ")
+	assert.NoError(t, err)
+	assert.Equal(t, checkdigit.Luhn, alg)
+
+	alg, err = checkdigit.Parse("")
+	assert.NoError(t, err)
+	assert.Equal(t, checkdigit.None, alg)
+
+	_, err = checkdigit.Parse("verhoeff")
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+// Package ciam is a typed Microsoft Graph client for the CIAM (Entra External ID) tenant.
+package ciam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"lbe/model"
+	"lbe/utils"
+)
+
+// UsersPath is the Graph users collection.
+const UsersPath = "/v1.0/users"
+
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	extensionKey string
+	tokens       *utils.TokenProvider
+}
+
+// NewClient creates a Graph client authenticating with the tokens of tokens.
+// extensionKey is the schema extension holding the UserIdLink, e.g. ext123_userIdLink.
+func NewClient(httpClient *http.Client, baseURL, extensionKey string, tokens *utils.TokenProvider) *Client {
+	return &Client{
+		httpClient:   httpClient,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		extensionKey: extensionKey,
+		tokens:       tokens,
+	}
+}
+
+type userPage struct {
+	Value    []json.RawMessage `json:"value"`
+	NextLink string            `json:"@odata.nextLink"`
+}
+
+// ListUsers returns every user matching the query, following @odata.nextLink.
+func (c *Client) ListUsers(ctx context.Context, q Query) ([]User, error) {
+	if len(q.Select) == 0 {
+		q.Select = c.userSelect()
+	}
+
+	var users []User
+	next := c.baseURL + UsersPath + "?" + q.Encode()
+	for next != "" {
+		page, err := doRequest[userPage](ctx, c, http.MethodGet, next, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, raw := range page.Value {
+			user, err := c.decodeUser(raw)
+			if err != nil {
+				return nil, err
+			}
+			users = append(users, user)
+		}
+		next = page.NextLink
+	}
+
+	return users, nil
+}
+
+// FindUsersByEmail returns the users whose mail equals email.
+func (c *Client) FindUsersByEmail(ctx context.Context, email string) ([]User, error) {
+	return c.ListUsers(ctx, Query{Filter: Eq("mail", email)})
+}
+
+// FindUsersByGrId returns the users whose UserIdLink carries the GR member id.
+func (c *Client) FindUsersByGrId(ctx context.Context, grId string) ([]User, error) {
+	return c.ListUsers(ctx, Query{Filter: Eq(c.extensionKey+"/grid", grId)})
+}
+
+// GetUser returns a single user, ErrNotFound matches when it does not exist.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	q := Query{Select: c.userSelect()}
+	userURL, err := c.userURL(id)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := doRequest[json.RawMessage](ctx, c, http.MethodGet, userURL+"?"+q.Encode(), nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.decodeUser(*raw)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser creates a user, ErrObjectConflict matches when the sign-in identity is taken.
+func (c *Client) CreateUser(ctx context.Context, payload any) (*User, error) {
+	return doRequest[User](ctx, c, http.MethodPost, c.baseURL+UsersPath, payload, http.StatusCreated)
+}
+
+// UpdateUser patches the given properties of a user.
+func (c *Client) UpdateUser(ctx context.Context, id string, payload any) error {
+	userURL, err := c.userURL(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = doRequest[struct{}](ctx, c, http.MethodPatch, userURL, payload, http.StatusNoContent)
+	return err
+}
+
+// DeleteUser removes a user.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	userURL, err := c.userURL(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = doRequest[struct{}](ctx, c, http.MethodDelete, userURL, nil, http.StatusNoContent)
+	return err
+}
+
+// SetUserIdLink writes the UserIdLink schema extension of a user.
+func (c *Client) SetUserIdLink(ctx context.Context, id string, link UserIdLink) error {
+	return c.UpdateUser(ctx, id, map[string]any{c.extensionKey: link})
+}
+
+// userURL is the Graph URL of the user with object id id, which must be a GUID.
+func (c *Client) userURL(id string) (string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s/%s", c.baseURL, UsersPath, url.PathEscape(id)), nil
+}
+
+func (c *Client) userSelect() []string {
+	return append(append([]string{}, defaultUserSelect...), c.extensionKey)
+}
+
+func (c *Client) decodeUser(raw json.RawMessage) (User, error) {
+	var user User
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return user, fmt.Errorf("decoding graph user: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return user, fmt.Errorf("decoding graph user: %w", err)
+	}
+	if ext, ok := fields[c.extensionKey]; ok && string(ext) != "null" {
+		var link UserIdLink
+		if err := json.Unmarshal(ext, &link); err != nil {
+			return user, fmt.Errorf("decoding %s: %w", c.extensionKey, err)
+		}
+		user.UserIdLink = &link
+	}
+
+	return user, nil
+}
+
+func doRequest[T any](ctx context.Context, c *Client, method, url string, body any, expectedStatus int) (*T, error) {
+	resp, _, err := utils.DoAuthorizedRequest[T](c.tokens, model.APIRequestOptions{
+		Method:         method,
+		URL:            url,
+		Body:           body,
+		ExpectedStatus: expectedStatus,
+		Client:         c.httpClient,
+		Context:        ctx,
+		ContentType:    model.ContentTypeJson,
+		Upstream:       model.UpstreamCiam,
+	})
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return resp, nil
+}
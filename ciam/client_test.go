@@ -0,0 +1,98 @@
+package ciam_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lbe/ciam"
+	"lbe/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   ciam.Filter
+		expected string
+	}{
+		{name: "eq", filter: ciam.Eq("mail", "user@example.com"), expected: "mail eq 'user@example.com'"},
+		{name: "apostrophe is doubled", filter: ciam.Eq("mail", "o'brien@example.com"), expected: "mail eq 'o''brien@example.com'"},
+		{name: "and", filter: ciam.And(ciam.Eq("mail", "a@example.com"), ciam.StartsWith("displayName", "A")), expected: "(mail eq 'a@example.com') and (startswith(displayName,'A'))"},
+		{name: "or skips empty", filter: ciam.Or("", ciam.Eq("mail", "a@example.com")), expected: "mail eq 'a@example.com'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(tt.filter))
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	const extensionKey = "ext123_userIdLink"
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("GET /v1.0/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer mockToken", r.Header.Get("Authorization"))
+
+		if r.URL.Query().Get("$skiptoken") == "" {
+			assert.Equal(t, "mail eq 'o''brien@example.com'", r.URL.Query().Get("$filter"))
+			json.NewEncoder(w).Encode(map[string]any{
+				"value":           []any{map[string]any{"id": "1", "mail": "o'brien@example.com"}},
+				"@odata.nextLink": server.URL + "/v1.0/users?$skiptoken=page2",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"value": []any{map[string]any{
+				"id":         "2",
+				extensionKey: map[string]any{"rlpid": "RLP1", "rlpno": "70000000001", "grid": "GR1"},
+			}},
+		})
+	})
+	mux.HandleFunc("POST /v1.0/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"Request_BadRequest","message":"Another object with the same value for property userPrincipalName already exists.","details":[{"code":"ObjectConflict","target":"userPrincipalName"}]}}`))
+	})
+	mux.HandleFunc("DELETE /v1.0/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":"Request_ResourceNotFound","message":"Resource does not exist."}}`))
+	})
+
+	client := ciam.NewClient(server.Client(), server.URL, extensionKey, utils.NewTokenProvider("ciam-test", func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+		return utils.AccessToken{Token: "mockToken"}, nil
+	}))
+
+	users, err := client.FindUsersByEmail(context.Background(), "o'brien@example.com")
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Nil(t, users[0].UserIdLink)
+		assert.Equal(t, &ciam.UserIdLink{RlpId: "RLP1", RlpNo: "70000000001", GrId: "GR1"}, users[1].UserIdLink)
+	}
+
+	_, err = client.CreateUser(context.Background(), map[string]any{"mail": "o'brien@example.com"})
+	assert.ErrorIs(t, err, ciam.ErrObjectConflict)
+	assert.NotErrorIs(t, err, ciam.ErrNotFound)
+
+	err = client.DeleteUser(context.Background(), "03f1e0b8-df1a-4349-bbb7-2f641676f095")
+	assert.ErrorIs(t, err, ciam.ErrNotFound)
+
+	var graphErr *ciam.GraphError
+	if assert.True(t, errors.As(err, &graphErr)) {
+		assert.Equal(t, http.StatusNotFound, graphErr.StatusCode)
+	}
+
+	// ids are checked before they end up in the Graph path
+	_, err = client.GetUser(context.Background(), "03f1e0b8-df1a-4349-bbb7-2f641676f095/memberOf")
+	assert.ErrorIs(t, err, ciam.ErrInvalidID)
+	err = client.DeleteUser(context.Background(), "../groups")
+	assert.ErrorIs(t, err, ciam.ErrInvalidID)
+}
@@ -0,0 +1,115 @@
+package ciam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"lbe/utils"
+
+	"github.com/google/uuid"
+)
+
+// Graph error codes
+const (
+	CodeObjectConflict   = "ObjectConflict"
+	CodeResourceNotFound = "Request_ResourceNotFound"
+)
+
+var (
+	// ErrObjectConflict matches a *GraphError raised because another object already
+	// holds a unique value, e.g. creating a user whose email is taken.
+	ErrObjectConflict = errors.New("ciam: object conflict")
+	// ErrNotFound matches a *GraphError for a missing object.
+	ErrNotFound = errors.New("ciam: not found")
+	// ErrInvalidID is returned for an object id that is not a GUID, before calling Graph.
+	ErrInvalidID = errors.New("ciam: invalid object id")
+)
+
+// ValidateID checks that id is a Graph object id, a GUID.
+func ValidateID(id string) error {
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		return fmt.Errorf("%w %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// GraphError is a Microsoft Graph error response.
+type GraphError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []ErrorDetail
+	RequestID  string
+	// Err is the underlying *utils.UnexpectedStatusError
+	Err error
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target"`
+}
+
+type errorPayload struct {
+	Error struct {
+		Code       string        `json:"code"`
+		Message    string        `json:"message"`
+		Details    []ErrorDetail `json:"details"`
+		InnerError struct {
+			RequestID string `json:"request-id"`
+		} `json:"innerError"`
+	} `json:"error"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("graph error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *GraphError) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match the sentinel errors of this package.
+func (e *GraphError) Is(target error) bool {
+	switch target {
+	case ErrObjectConflict:
+		return e.hasCode(CodeObjectConflict)
+	case ErrNotFound:
+		return e.hasCode(CodeResourceNotFound)
+	default:
+		return false
+	}
+}
+
+func (e *GraphError) hasCode(code string) bool {
+	if e.Code == code {
+		return true
+	}
+	for _, d := range e.Details {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// parseError turns an unexpected status from DoAPIRequest into a *GraphError,
+// other errors are returned unchanged.
+func parseError(err error) error {
+	var statusErr *utils.UnexpectedStatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	graphErr := &GraphError{StatusCode: statusErr.StatusCode, Err: statusErr}
+
+	var payload errorPayload
+	if json.Unmarshal(statusErr.Body, &payload) == nil {
+		graphErr.Code = payload.Error.Code
+		graphErr.Message = payload.Error.Message
+		graphErr.Details = payload.Error.Details
+		graphErr.RequestID = payload.Error.InnerError.RequestID
+	}
+	return graphErr
+}
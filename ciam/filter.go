@@ -0,0 +1,75 @@
+package ciam
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Filter is an OData $filter expression.
+type Filter string
+
+// Quote renders s as an OData string literal, doubling embedded apostrophes.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Eq matches property equal to the string value.
+func Eq(property, value string) Filter {
+	return Filter(property + " eq " + Quote(value))
+}
+
+// StartsWith matches property starting with the string value.
+func StartsWith(property, value string) Filter {
+	return Filter("startswith(" + property + "," + Quote(value) + ")")
+}
+
+// And joins the non-empty filters with "and".
+func And(filters ...Filter) Filter {
+	return join("and", filters)
+}
+
+// Or joins the non-empty filters with "or".
+func Or(filters ...Filter) Filter {
+	return join("or", filters)
+}
+
+func join(op string, filters []Filter) Filter {
+	var parts []string
+	for _, f := range filters {
+		if f != "" {
+			parts = append(parts, string(f))
+		}
+	}
+	if len(parts) == 1 {
+		return Filter(parts[0])
+	}
+	for i := range parts {
+		parts[i] = "(" + parts[i] + ")"
+	}
+	return Filter(strings.Join(parts, " "+op+" "))
+}
+
+// Query holds the OData system query options of a Graph request.
+type Query struct {
+	Filter Filter
+	Select []string
+	// Top is the page size, Graph's default applies when zero
+	Top int
+}
+
+// Encode renders the query string. The $ of the option names is kept literal
+// as shown in the Graph documentation, only the values are escaped.
+func (q Query) Encode() string {
+	var params []string
+	if q.Filter != "" {
+		params = append(params, "$filter="+url.QueryEscape(string(q.Filter)))
+	}
+	if len(q.Select) > 0 {
+		params = append(params, "$select="+url.QueryEscape(strings.Join(q.Select, ",")))
+	}
+	if q.Top > 0 {
+		params = append(params, "$top="+strconv.Itoa(q.Top))
+	}
+	return strings.Join(params, "&")
+}
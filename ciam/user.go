@@ -0,0 +1,41 @@
+package ciam
+
+import "time"
+
+// User is a Graph user as returned by the default $select of this package.
+type User struct {
+	ID                string     `json:"id"`
+	DisplayName       string     `json:"displayName"`
+	GivenName         string     `json:"givenName"`
+	Surname           string     `json:"surname"`
+	Mail              string     `json:"mail"`
+	UserPrincipalName string     `json:"userPrincipalName"`
+	AccountEnabled    *bool      `json:"accountEnabled,omitempty"`
+	Identities        []Identity `json:"identities,omitempty"`
+	CreatedDateTime   *time.Time `json:"createdDateTime,omitempty"`
+
+	// UserIdLink is read from the schema extension configured as UserIdLinkExtensionKey,
+	// nil when the user has none.
+	UserIdLink *UserIdLink `json:"-"`
+}
+
+// Identity represents one sign-in identity of a Graph user.
+type Identity struct {
+	SignInType       string `json:"signInType"`
+	Issuer           string `json:"issuer"`
+	IssuerAssignedID string `json:"issuerAssignedId"`
+}
+
+// UserIdLink is the schema extension linking a CIAM user to its RLP and GR identifiers.
+type UserIdLink struct {
+	RlpId string `json:"rlpid"`
+	RlpNo string `json:"rlpno"`
+	GrId  string `json:"grid"`
+}
+
+// defaultUserSelect are the properties read unless a query selects otherwise,
+// the user id link extension is added by the client.
+var defaultUserSelect = []string{
+	"id", "displayName", "givenName", "surname", "mail", "userPrincipalName",
+	"accountEnabled", "identities", "createdDateTime",
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lbe/audit"
+	"lbe/config"
+	"lbe/system"
+)
+
+const auditUsage = "usage: lbe-api audit verify|anchor|archive|restore <archive>|drop-restored <archive>"
+
+func auditCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(auditUsage)
+	}
+	switch args[0] {
+	case "restore", "drop-restored":
+		if len(args) != 2 {
+			return errors.New(auditUsage)
+		}
+	default:
+		if len(args) != 1 {
+			return errors.New(auditUsage)
+		}
+	}
+
+	db := system.GetDb()
+	if db == nil {
+		return errors.New("database not configured")
+	}
+
+	switch args[0] {
+	case "verify":
+		report, err := audit.Verify(context.Background(), db)
+		if err != nil {
+			return fmt.Errorf("verifying audit chain: %w", err)
+		}
+
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		if report.Broken != nil {
+			return report.Broken
+		}
+		fmt.Printf("audit chain intact, %d entries and %d anchors checked\n", report.Checked, report.AnchorsChecked)
+		return nil
+
+	case "anchor":
+		anchor, err := audit.Anchor(context.Background(), db, config.GetConfig().Application.Audit.AnchorFile)
+		if err != nil {
+			return fmt.Errorf("anchoring audit chain: %w", err)
+		}
+		if anchor == nil {
+			fmt.Println("audit chain head already anchored")
+			return nil
+		}
+		fmt.Printf("anchored audit chain at seq %d, hash %s\n", anchor.Seq, anchor.Hash)
+		return nil
+
+	case "archive":
+		conf := config.GetConfig().Application.Audit
+		if conf.Retention <= 0 {
+			return errors.New("application.audit.retention is not set, nothing expires")
+		}
+
+		sink := audit.DirSink{Dir: conf.GetArchiveDir()}
+		cutoff := time.Now().Add(-conf.Retention)
+		result, err := audit.ArchiveExpired(context.Background(), db, sink, cutoff)
+		for _, name := range result.Archives {
+			fmt.Println(name)
+		}
+		if err != nil {
+			return fmt.Errorf("archiving audit logs: %w", err)
+		}
+		fmt.Printf("archived %d audit log entries to %s\n", result.Entries, sink.Dir)
+
+		purged, err := audit.PurgeUpstreamCalls(context.Background(), db, cutoff)
+		if err != nil {
+			return fmt.Errorf("purging upstream call logs: %w", err)
+		}
+		fmt.Printf("deleted %d upstream call log entries\n", purged)
+		return nil
+
+	case "restore":
+		sink := audit.DirSink{Dir: config.GetConfig().Application.Audit.GetArchiveDir()}
+		n, err := audit.Restore(context.Background(), db, sink, args[1])
+		if err != nil {
+			return fmt.Errorf("restoring audit archive: %w", err)
+		}
+		fmt.Printf("restored %d audit log entries from %s, remove them with: lbe-api audit drop-restored %s\n", n, args[1], args[1])
+		return nil
+
+	case "drop-restored":
+		n, err := audit.DropRestored(context.Background(), db, args[1])
+		if err != nil {
+			return fmt.Errorf("dropping restored audit logs: %w", err)
+		}
+		fmt.Printf("deleted %d audit log entries restored from %s\n", n, args[1])
+		return nil
+
+	default:
+		return errors.New(auditUsage)
+	}
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"lbe/api/http/services"
+)
+
+const usage = `usage:
+  lbe-api                                   start the http server
+  lbe-api registration retry <rlp_id>       re-drive a stuck registration
+  lbe-api audit verify                      check the audit log hash chain, reporting the first broken link
+  lbe-api audit anchor                      record the current audit chain head
+  lbe-api audit archive                     archive and delete audit logs older than application.audit.retention, delete upstream call logs
+  lbe-api audit restore <archive>           load an archive back into audit_logs for an investigation
+  lbe-api audit drop-restored <archive>     delete the audit logs restored from an archive`
+
+// Run executes an operator subcommand given the arguments after the binary name.
+func Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", usage)
+	}
+
+	switch args[0] {
+	case "registration":
+		return registration(services.NewApp(newHttpClient()), args[1:])
+	case "audit":
+		return auditCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func newHttpClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
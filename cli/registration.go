@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"lbe/api/http/services"
+)
+
+func registration(app *services.App, args []string) error {
+	if len(args) != 2 || args[0] != "retry" {
+		return fmt.Errorf("usage: lbe-api registration retry <rlp_id>")
+	}
+
+	rlpId := args[1]
+	profileResp, err := services.ResumeRegistration(context.Background(), app, rlpId)
+	if err != nil {
+		return fmt.Errorf("resuming registration %s: %w", rlpId, err)
+	}
+
+	out, err := json.MarshalIndent(profileResp.User.MapRlpToLbeUser(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("registration %s completed\n%s\n", rlpId, out)
+	return nil
+}
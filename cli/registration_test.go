@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lbe/api/http/requests"
+	"lbe/api/http/services"
+	"lbe/api/http/services/fakes"
+	"lbe/codes"
+	"lbe/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationRetry(t *testing.T) {
+	const rlpId = "2505230001"
+
+	payload, err := json.Marshal(requests.RegisterUser{
+		SignUpType: codes.SignUpTypeNew,
+		User:       model.User{Email: "retry@example.com", Tier: "Tier A"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		args        []string
+		status      string
+		expectedErr error
+	}{
+		{
+			name:   "SUCCESS - registration completed",
+			args:   []string{"retry", rlpId},
+			status: model.RegistrationStatusPendingRetry,
+		},
+		{
+			name:        "ERROR - not resumable",
+			args:        []string{"retry", rlpId},
+			status:      model.RegistrationStatusCompleted,
+			expectedErr: services.ErrRegistrationNotResumable,
+		},
+		{
+			name:        "ERROR - not found",
+			args:        []string{"retry", "2505230002"},
+			status:      model.RegistrationStatusPendingRetry,
+			expectedErr: services.ErrRegistrationAttemptNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreams := fakes.New()
+			upstreams.Registrations.Add(model.RegistrationAttempt{
+				RlpID:      rlpId,
+				SignUpType: codes.SignUpTypeNew,
+				Payload:    string(payload),
+				Status:     tt.status,
+				UpdatedAt:  time.Now(),
+			})
+
+			err := registration(upstreams.App(), tt.args)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			attempt, _ := upstreams.Registrations.Attempt(rlpId)
+			assert.Equal(t, model.RegistrationStatusCompleted, attempt.Status)
+		})
+	}
+
+	t.Run("ERROR - usage", func(t *testing.T) {
+		assert.Error(t, registration(fakes.New().App(), []string{"retry"}))
+	})
+}
@@ -9,6 +9,18 @@ const (
 	SignUpTypeGRCMS = "GR_CMS"
 	SignUpTypeTM    = "TM"
 
+	// otp purpose enums
+	OtpPurposeRegistration   = "REGISTRATION"
+	OtpPurposeGrRegistration = "GR_REGISTRATION"
+	OtpPurposeLogin          = "LOGIN"
+
+	// member lookup identifier types
+	LookupTypeRlpId  = "rlp_id"
+	LookupTypeRlpNo  = "rlp_no"
+	LookupTypeGrId   = "gr_id"
+	LookupTypeEmail  = "email"
+	LookupTypeCiamId = "ciam_id"
+
 	// codes
 
 	CODE_SUCCESS              = 0
@@ -38,8 +50,37 @@ const (
 	GR_MEMBER_LINKED         int64 = 4012
 	GR_MEMBER_NOT_FOUND      int64 = 4013
 	INVALID_GR_MEMBER_CLASS  int64 = 4014
+	ACCESS_DENIED            int64 = 4015
+
+	REGISTRATION_ATTEMPT_NOT_FOUND int64 = 4016
+	REGISTRATION_NOT_RESUMABLE     int64 = 4017
+	INVALID_OTP                    int64 = 4018
+	OTP_NOT_FOUND                  int64 = 4019
+	OTP_ATTEMPTS_EXCEEDED          int64 = 4020
+	OTP_RESEND_COOLDOWN            int64 = 4021
+	OTP_DAILY_LIMIT_REACHED        int64 = 4022
+	INVALID_VERIFICATION_TOKEN     int64 = 4023
+	REQUEST_IN_PROGRESS            int64 = 4024
+	MEMBER_SUSPENDED               int64 = 4025
+	UPSTREAM_CONFLICT              int64 = 4026
+	UPSTREAM_VALIDATION_FAILED     int64 = 4027
+	UPSTREAM_UNAVAILABLE           int64 = 4028
 )
 
+const (
+	AuditExportFormatCsv   = "csv"
+	AuditExportFormatJsonl = "jsonl"
+)
+
+func IsValidAuditExportFormat(f string) bool {
+	switch f {
+	case AuditExportFormatCsv, AuditExportFormatJsonl:
+		return true
+	default:
+		return false
+	}
+}
+
 func IsValidSignUpType(t string) bool {
 	switch t {
 	case SignUpTypeNew, SignUpTypeGRCMS, SignUpTypeGR, SignUpTypeTM:
@@ -48,3 +89,46 @@ func IsValidSignUpType(t string) bool {
 		return false
 	}
 }
+
+func IsValidOtpPurpose(p string) bool {
+	switch p {
+	case OtpPurposeRegistration, OtpPurposeGrRegistration, OtpPurposeLogin:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidLookupType(t string) bool {
+	switch t {
+	case LookupTypeRlpId, LookupTypeRlpNo, LookupTypeGrId, LookupTypeEmail, LookupTypeCiamId:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegistrationOtpPurpose returns the otp purpose that proves the identifier of a sign-up type.
+// GR_CMS is proven by the reg_id sent by email, TM has no otp step.
+func RegistrationOtpPurpose(signUpType string) (string, bool) {
+	switch signUpType {
+	case SignUpTypeNew:
+		return OtpPurposeRegistration, true
+	case SignUpTypeGR:
+		return OtpPurposeGrRegistration, true
+	default:
+		return "", false
+	}
+}
+
+// OtpPurposeSignUpType is the inverse of RegistrationOtpPurpose, empty for non-registration purposes.
+func OtpPurposeSignUpType(purpose string) string {
+	switch purpose {
+	case OtpPurposeRegistration:
+		return SignUpTypeNew
+	case OtpPurposeGrRegistration:
+		return SignUpTypeGR
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,56 @@
+package codes
+
+// ProblemTypePrefix prefixes the type URI of the v2 problem details.
+const ProblemTypePrefix = "urn:lbe:problem:"
+
+type problem struct {
+	slug  string
+	title string
+}
+
+// problems names the error codes in the v2 problem details. Clients match on the
+// type URI built from slug, a slug must never change once released.
+var problems = map[int64]problem{
+	INTERNAL_ERROR:                 {"internal-error", "internal error"},
+	INVALID_REQUEST_BODY:           {"invalid-request-body", "invalid request body"},
+	INVALID_AUTH_TOKEN:             {"invalid-auth-token", "invalid authentication token"},
+	MISSING_AUTH_TOKEN:             {"missing-auth-token", "missing authentication token"},
+	INVALID_SIGNATURE:              {"invalid-signature", "invalid signature"},
+	MISSING_SIGNATURE:              {"missing-signature", "missing signature"},
+	INVALID_APP_ID:                 {"invalid-app-id", "invalid appid"},
+	MISSING_APP_ID:                 {"missing-app-id", "missing appid"},
+	INVALID_QUERY_PARAMETERS:       {"invalid-query-parameters", "invalid query parameters"},
+	EXISTING_USER_NOT_FOUND:        {"existing-user-not-found", "existing user not found"},
+	EXISTING_USER_FOUND:            {"existing-user-found", "existing user found"},
+	CACHED_PROFILE_NOT_FOUND:       {"cached-profile-not-found", "cached profile not found"},
+	GR_MEMBER_LINKED:               {"gr-member-linked", "gr member linked"},
+	GR_MEMBER_NOT_FOUND:            {"gr-member-not-found", "gr member not found"},
+	INVALID_GR_MEMBER_CLASS:        {"invalid-gr-member-class", "invalid gr member class"},
+	ACCESS_DENIED:                  {"access-denied", "access denied"},
+	REGISTRATION_ATTEMPT_NOT_FOUND: {"registration-attempt-not-found", "registration attempt not found"},
+	REGISTRATION_NOT_RESUMABLE:     {"registration-not-resumable", "registration not resumable"},
+	INVALID_OTP:                    {"invalid-otp", "invalid otp"},
+	OTP_NOT_FOUND:                  {"otp-not-found", "otp not found or expired"},
+	OTP_ATTEMPTS_EXCEEDED:          {"otp-attempts-exceeded", "otp attempts exceeded"},
+	OTP_RESEND_COOLDOWN:            {"otp-resend-cooldown", "otp resend cooldown"},
+	OTP_DAILY_LIMIT_REACHED:        {"otp-daily-limit-reached", "otp daily limit reached"},
+	INVALID_VERIFICATION_TOKEN:     {"invalid-verification-token", "invalid verification token"},
+	REQUEST_IN_PROGRESS:            {"request-in-progress", "request in progress"},
+	MEMBER_SUSPENDED:               {"member-suspended", "member suspended"},
+	UPSTREAM_CONFLICT:              {"upstream-conflict", "upstream conflict"},
+	UPSTREAM_VALIDATION_FAILED:     {"upstream-validation-failed", "upstream validation failed"},
+	UPSTREAM_UNAVAILABLE:           {"upstream-unavailable", "upstream unavailable"},
+}
+
+// ProblemType returns the type URI of an error code, about:blank for codes without one.
+func ProblemType(code int64) string {
+	if p, ok := problems[code]; ok {
+		return ProblemTypePrefix + p.slug
+	}
+	return "about:blank"
+}
+
+// ProblemTitle returns the short summary of an error code, empty for codes without one.
+func ProblemTitle(code int64) string {
+	return problems[code].title
+}
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	system "lbe/log"
 
@@ -75,13 +76,385 @@ type Config struct {
 		} `yaml:"acs"`
 	} `yaml:"api"`
 	Application struct {
-		RLPNumberingFormat struct {
-			MaxAttempts  int    `yaml:"maxAttempts"`
-			RLPNODefault string `yaml:"rlpNoDefault"`
-		} `yaml:"rlpNumberingFormat"`
+		RLPNumberingFormat RLPNumberingFormatConfig `yaml:"rlpNumberingFormat"`
+		Admin              struct {
+			AppIds []string `yaml:"appIds"`
+		} `yaml:"admin"`
+		Otp         OtpConfig         `yaml:"otp"`
+		AccessToken AccessTokenConfig `yaml:"accessToken"`
+		Lock        LockConfig        `yaml:"lock"`
+		Audit       AuditConfig       `yaml:"audit"`
+		Upstreams   UpstreamsConfig   `yaml:"upstreams"`
 	} `yaml:"application"`
 }
 
+// RLPNumberingFormatConfig lays out the RLP_NO and RLP_ID issued to new members.
+type RLPNumberingFormatConfig struct {
+	RLPNODefault string `yaml:"rlpNoDefault"`
+	// CheckDigit appends a check digit to newly issued RLP_NOs: "luhn", "damm" or
+	// empty for none. Numbers issued before it was enabled remain valid; once
+	// enabled it must not be switched to another algorithm.
+	CheckDigit string `yaml:"checkDigit"`
+	// RlpIdTemplate builds the RLP_ID from the tokens {yyyy}, {yy}, {MM}, {dd} and
+	// {seq}, any other text is kept as is, e.g. "R{yy}{MM}{dd}{seq}". The counter
+	// behind {seq} restarts whenever the text around it changes, i.e. daily when
+	// {dd} is part of the template.
+	RlpIdTemplate string `yaml:"rlpIdTemplate"`
+	// RlpIdCounterWidth is the number of digits {seq} is zero-padded to, and so
+	// bounds the number of RLP_IDs per counter period
+	RlpIdCounterWidth int `yaml:"rlpIdCounterWidth"`
+	// TimeZone the date tokens are rendered in, independent of the server's zone
+	TimeZone string `yaml:"timeZone"`
+}
+
+func (t RLPNumberingFormatConfig) GetRlpIdTemplate() string {
+	if t.RlpIdTemplate != "" {
+		return t.RlpIdTemplate
+	}
+	return "{yy}{MM}{dd}{seq}"
+}
+
+func (t RLPNumberingFormatConfig) GetRlpIdCounterWidth() int {
+	if t.RlpIdCounterWidth > 0 {
+		return t.RlpIdCounterWidth
+	}
+	return 5
+}
+
+func (t RLPNumberingFormatConfig) GetTimeZone() string {
+	if t.TimeZone != "" {
+		return t.TimeZone
+	}
+	return "Asia/Singapore"
+}
+
+// AuditConfig controls what the audit log keeps of request and response bodies.
+type AuditConfig struct {
+	// MaxBodySize is the largest body in bytes that is captured, larger bodies are
+	// only recorded with their size
+	MaxBodySize int `yaml:"maxBodySize"`
+	// HashKey keys the HMAC of fields redacted with the hash action
+	HashKey string `yaml:"hashKey"`
+	// Redact rules apply to every route, on top of the built-in defaults
+	Redact []RedactRule `yaml:"redact"`
+	// Routes add rules for single routes
+	Routes []AuditRouteConfig `yaml:"routes"`
+
+	// QueueSize bounds the entries waiting to be written
+	QueueSize int `yaml:"queueSize"`
+	// BatchSize is the number of entries written per INSERT
+	BatchSize int `yaml:"batchSize"`
+	// FlushInterval is the longest an entry waits for its batch to fill up
+	FlushInterval time.Duration `yaml:"flushInterval"`
+	// EnqueueTimeout is how long a request waits for room in a full queue before
+	// its entry is dropped
+	EnqueueTimeout time.Duration `yaml:"enqueueTimeout"`
+
+	// AnchorInterval is how often the head of the audit hash chain is anchored
+	AnchorInterval time.Duration `yaml:"anchorInterval"`
+	// AnchorFile additionally receives every anchor as a JSON line, ideally on
+	// storage the database credentials cannot modify
+	AnchorFile string `yaml:"anchorFile"`
+
+	// UpstreamCalls stores every upstream call made through utils.DoAPIRequest in upstream_call_logs
+	UpstreamCalls bool `yaml:"upstreamCalls"`
+
+	// Retention is the age after which entries are archived and deleted, zero keeps them forever
+	Retention time.Duration `yaml:"retention"`
+	// RetentionInterval is how often expired entries are looked for
+	RetentionInterval time.Duration `yaml:"retentionInterval"`
+	// ArchiveDir receives the gzip compressed JSON Lines archives
+	ArchiveDir string `yaml:"archiveDir"`
+}
+
+// RedactRule selects body fields by JSON path, e.g. "**.otp" or
+// "user.gr_profile.pin", and masks, hashes or drops them.
+type RedactRule struct {
+	Path string `yaml:"path"`
+	// Action is one of "mask", "hash" or "drop"
+	Action string `yaml:"action"`
+}
+
+// AuditRouteConfig holds the extra redaction rules of one route.
+type AuditRouteConfig struct {
+	// Method of the route, empty for any
+	Method string `yaml:"method"`
+	// Path is the route as registered, e.g. /api/v1/user/:external_id
+	Path   string       `yaml:"path"`
+	Redact []RedactRule `yaml:"redact"`
+}
+
+func (t AuditConfig) GetMaxBodySize() int {
+	if t.MaxBodySize > 0 {
+		return t.MaxBodySize
+	}
+	return 64 * 1024
+}
+
+func (t AuditConfig) GetQueueSize() int {
+	if t.QueueSize > 0 {
+		return t.QueueSize
+	}
+	return 10000
+}
+
+func (t AuditConfig) GetBatchSize() int {
+	if t.BatchSize > 0 {
+		return t.BatchSize
+	}
+	return 100
+}
+
+func (t AuditConfig) GetFlushInterval() time.Duration {
+	if t.FlushInterval > 0 {
+		return t.FlushInterval
+	}
+	return time.Second
+}
+
+func (t AuditConfig) GetEnqueueTimeout() time.Duration {
+	if t.EnqueueTimeout > 0 {
+		return t.EnqueueTimeout
+	}
+	return 50 * time.Millisecond
+}
+
+func (t AuditConfig) GetRetentionInterval() time.Duration {
+	if t.RetentionInterval > 0 {
+		return t.RetentionInterval
+	}
+	return 24 * time.Hour
+}
+
+func (t AuditConfig) GetArchiveDir() string {
+	if t.ArchiveDir != "" {
+		return t.ArchiveDir
+	}
+	return "archive/audit"
+}
+
+func (t AuditConfig) GetAnchorInterval() time.Duration {
+	if t.AnchorInterval > 0 {
+		return t.AnchorInterval
+	}
+	return time.Hour
+}
+
+// LockConfig controls the distributed locks serialising requests on the same member.
+type LockConfig struct {
+	// Ttl bounds how long a lock outlives a replica that died while holding it
+	Ttl time.Duration `yaml:"ttl"`
+	// WaitTimeout is how long a request waits for a lock held by another request
+	WaitTimeout time.Duration `yaml:"waitTimeout"`
+	// RetryInterval is the pause between attempts while waiting
+	RetryInterval time.Duration `yaml:"retryInterval"`
+}
+
+func (t LockConfig) GetTtl() time.Duration {
+	if t.Ttl > 0 {
+		return t.Ttl
+	}
+	return 2 * time.Minute
+}
+
+func (t LockConfig) GetWaitTimeout() time.Duration {
+	if t.WaitTimeout > 0 {
+		return t.WaitTimeout
+	}
+	return 10 * time.Second
+}
+
+func (t LockConfig) GetRetryInterval() time.Duration {
+	if t.RetryInterval > 0 {
+		return t.RetryInterval
+	}
+	return 100 * time.Millisecond
+}
+
+// UpstreamsConfig holds the call policy of every upstream the API talks to.
+type UpstreamsConfig struct {
+	Ciam      UpstreamPolicy `yaml:"ciam"`
+	RlpCore   UpstreamPolicy `yaml:"rlpCore"`
+	RlpOffers UpstreamPolicy `yaml:"rlpOffers"`
+	Cms       UpstreamPolicy `yaml:"cms"`
+	Acs       UpstreamPolicy `yaml:"acs"`
+	Member    UpstreamPolicy `yaml:"member"`
+}
+
+// UpstreamPolicy bounds, retries and short-circuits the calls to one upstream.
+type UpstreamPolicy struct {
+	// Timeout bounds a single attempt, including reading the response
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxRetries is the number of attempts after the first, -1 for none
+	MaxRetries int `yaml:"maxRetries"`
+	// BackoffBase is the backoff before the first retry, doubling on every further retry
+	BackoffBase time.Duration `yaml:"backoffBase"`
+	// BackoffMax caps the backoff between two attempts
+	BackoffMax time.Duration `yaml:"backoffMax"`
+	// RetryAfterMax is the longest Retry-After waited for, a longer one ends the retries
+	RetryAfterMax time.Duration `yaml:"retryAfterMax"`
+	// BreakerThreshold is the number of consecutive failures that opens the circuit
+	BreakerThreshold int `yaml:"breakerThreshold"`
+	// BreakerCooldown is how long an open circuit fails fast before letting a probe through
+	BreakerCooldown time.Duration `yaml:"breakerCooldown"`
+}
+
+func (t UpstreamPolicy) GetTimeout() time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	return 10 * time.Second
+}
+
+func (t UpstreamPolicy) GetMaxRetries() int {
+	switch {
+	case t.MaxRetries < 0:
+		return 0
+	case t.MaxRetries > 0:
+		return t.MaxRetries
+	}
+	return 2
+}
+
+func (t UpstreamPolicy) GetBackoffBase() time.Duration {
+	if t.BackoffBase > 0 {
+		return t.BackoffBase
+	}
+	return 100 * time.Millisecond
+}
+
+func (t UpstreamPolicy) GetBackoffMax() time.Duration {
+	if t.BackoffMax > 0 {
+		return t.BackoffMax
+	}
+	return 2 * time.Second
+}
+
+func (t UpstreamPolicy) GetRetryAfterMax() time.Duration {
+	if t.RetryAfterMax > 0 {
+		return t.RetryAfterMax
+	}
+	return 5 * time.Second
+}
+
+func (t UpstreamPolicy) GetBreakerThreshold() int {
+	if t.BreakerThreshold > 0 {
+		return t.BreakerThreshold
+	}
+	return 5
+}
+
+func (t UpstreamPolicy) GetBreakerCooldown() time.Duration {
+	if t.BreakerCooldown > 0 {
+		return t.BreakerCooldown
+	}
+	return 30 * time.Second
+}
+
+// AccessTokenConfig controls the caching of upstream (CIAM, ACS, member service) access tokens.
+type AccessTokenConfig struct {
+	// RefreshBefore is how long before expiry a cached token is replaced
+	RefreshBefore time.Duration `yaml:"refreshBefore"`
+	// DefaultTtl applies to tokens whose auth response carries no expiry
+	DefaultTtl time.Duration `yaml:"defaultTtl"`
+	// ShareViaRedis shares tokens across replicas
+	ShareViaRedis bool `yaml:"shareViaRedis"`
+}
+
+func (t AccessTokenConfig) GetRefreshBefore() time.Duration {
+	if t.RefreshBefore > 0 {
+		return t.RefreshBefore
+	}
+	return time.Minute
+}
+
+func (t AccessTokenConfig) GetDefaultTtl() time.Duration {
+	if t.DefaultTtl > 0 {
+		return t.DefaultTtl
+	}
+	return 10 * time.Minute
+}
+
+// OtpConfig holds the one-time password settings.
+type OtpConfig struct {
+	Expiry                  time.Duration `yaml:"expiry"`
+	VerificationTokenExpiry time.Duration `yaml:"verificationTokenExpiry"`
+	// ReturnInResponse echoes the OTP in API responses, only meant for non-production environments
+	ReturnInResponse bool `yaml:"returnInResponse"`
+	// MaxAttempts is the number of failed verifications after which the OTP is invalidated
+	MaxAttempts int `yaml:"maxAttempts"`
+	// ResendCooldown is the minimum time between two OTPs sent to the same identifier
+	ResendCooldown time.Duration `yaml:"resendCooldown"`
+	// DailySendCap is the maximum number of OTPs sent to the same identifier per day
+	DailySendCap int `yaml:"dailySendCap"`
+	// Length and Alphabet of generated OTPs, defaults to 6 digits
+	Length   int    `yaml:"length"`
+	Alphabet string `yaml:"alphabet"`
+	// Purposes overrides Length and Alphabet per OTP purpose, keyed by lower-cased purpose
+	Purposes map[string]OtpPurposeConfig `yaml:"purposes"`
+}
+
+// OtpPurposeConfig overrides the OTP format for a single purpose, e.g. longer codes for login.
+type OtpPurposeConfig struct {
+	Length   int    `yaml:"length"`
+	Alphabet string `yaml:"alphabet"`
+}
+
+func (t OtpConfig) GetLength(purpose string) int {
+	if p, ok := t.Purposes[strings.ToLower(purpose)]; ok && p.Length > 0 {
+		return p.Length
+	}
+	if t.Length > 0 {
+		return t.Length
+	}
+	return 6
+}
+
+func (t OtpConfig) GetAlphabet(purpose string) string {
+	if p, ok := t.Purposes[strings.ToLower(purpose)]; ok && p.Alphabet != "" {
+		return p.Alphabet
+	}
+	if t.Alphabet != "" {
+		return t.Alphabet
+	}
+	return "0123456789"
+}
+
+func (t OtpConfig) GetExpiry() time.Duration {
+	if t.Expiry > 0 {
+		return t.Expiry
+	}
+	return 30 * time.Minute
+}
+
+func (t OtpConfig) GetMaxAttempts() int {
+	if t.MaxAttempts > 0 {
+		return t.MaxAttempts
+	}
+	return 5
+}
+
+func (t OtpConfig) GetResendCooldown() time.Duration {
+	if t.ResendCooldown > 0 {
+		return t.ResendCooldown
+	}
+	return time.Minute
+}
+
+func (t OtpConfig) GetDailySendCap() int {
+	if t.DailySendCap > 0 {
+		return t.DailySendCap
+	}
+	return 10
+}
+
+func (t OtpConfig) GetVerificationTokenExpiry() time.Duration {
+	if t.VerificationTokenExpiry > 0 {
+		return t.VerificationTokenExpiry
+	}
+	return 10 * time.Minute
+}
+
 // DatabaseConfig holds the database connection parameters.
 type DatabaseConfig struct {
 	Type     string `yaml:"type"`
@@ -11,7 +11,9 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var logger *logrus.Logger
+// logger writes to stderr until InitLogger replaces it, e.g. when the config,
+// and with it the logger set up, is skipped under RUN_UNIT_TESTS.
+var logger = logrus.StandardLogger()
 
 // InitLogger initializes Logger, supporting daily automatic backups and retaining logs for the past three days.
 func InitLogger(path string) {
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	router "lbe/api"
+	"lbe/cli"
 )
 
 // @title           LBE API
@@ -31,8 +35,27 @@ import (
 // @description | 4011   | cached profile not found      |
 // @description | 4012   | gr member linked              |
 // @description | 4013   | gr member not found           |
+// @description | 4014   | invalid gr member class       |
+// @description | 4015   | access denied                 |
+// @description | 4016   | registration attempt not found|
+// @description | 4017   | registration not resumable    |
+// @description | 4018   | invalid otp                   |
+// @description | 4019   | otp not found or expired      |
+// @description | 4020   | otp attempts exceeded         |
+// @description | 4021   | otp resend cooldown           |
+// @description | 4022   | otp daily limit reached       |
+// @description | 4023   | invalid verification token    |
+// @description | 4024   | request in progress           |
+// @description | 4025   | member suspended              |
+// @description | 4026   | upstream conflict             |
+// @description | 4027   | upstream validation failed    |
+// @description | 4028   | upstream unavailable          |
 // @description
 // @description </details>
+// @description
+// @description The same endpoints are served under /api/v2, where failed requests are answered with
+// @description RFC 7807 problem details (application/problem+json, see responses.Problem) instead of the
+// @description message code envelope. The type of a problem is urn:lbe:problem: followed by a slug of its code.
 // @host            localhost:18080
 // @BasePath        /api/v1
 
@@ -48,5 +71,14 @@ func main() {
 	//}
 	//topic.StartSubscription()
 	//gin.SetMode(gin.ReleaseMode)
+
+	// operator subcommands, e.g. `lbe-api registration retry <rlp_id>`
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	router.Init()
 }
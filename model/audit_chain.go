@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// AuditLogChain names the hash chain over audit_logs.
+	AuditLogChain = "audit_logs"
+	// AuditChainGenesisHash is the previous hash of the first entry in a chain.
+	AuditChainGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// AuditChainHead is the last entry appended to a hash chain. Writers lock it
+// while appending, which gives every entry its place in the chain.
+type AuditChainHead struct {
+	Name      string    `gorm:"column:name;primaryKey;size:32" json:"name"`
+	Seq       int64     `gorm:"column:seq" json:"seq"`
+	Hash      string    `gorm:"column:hash;size:64" json:"hash"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AuditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
+
+// AuditChainAnchor is a copy of a chain head taken at some point in time. Rows
+// removed or edited afterwards no longer lead to the anchored hash.
+type AuditChainAnchor struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Chain      string    `gorm:"column:chain;size:32;index" json:"chain"`
+	Seq        int64     `gorm:"column:seq" json:"seq"`
+	Hash       string    `gorm:"column:hash;size:64" json:"hash"`
+	AnchoredAt time.Time `gorm:"column:anchored_at" json:"anchored_at"`
+}
+
+func (AuditChainAnchor) TableName() string {
+	return "audit_chain_anchors"
+}
+
+// MigrateAuditChain creates the chain tables and the head of the audit_logs chain.
+func MigrateAuditChain(db *gorm.DB) error {
+	if err := db.AutoMigrate(&AuditChainHead{}, &AuditChainAnchor{}); err != nil {
+		return err
+	}
+
+	// every replica migrates on start, the first one creates the head
+	head := AuditChainHead{Name: AuditLogChain, Hash: AuditChainGenesisHash, UpdatedAt: time.Now()}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error
+}
@@ -8,17 +8,27 @@ import (
 )
 
 type AuditLog struct {
-	ID           uint      `gorm:"primaryKey;autoIncrement"`
-	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt    time.Time `gorm:"column:created_at;index" json:"created_at"`
+	RequestID    string    `gorm:"column:request_id;size:64;index" json:"request_id,omitempty"` // shared with the request's UpstreamCallLog rows
 	ActorID      string    `gorm:"column:actor_id" json:"actor_id"`
 	Method       string    `gorm:"column:method" json:"method"`
-	Path         string    `gorm:"column:path" json:"path"`
+	Path         string    `gorm:"column:path" json:"path"`                 // route pattern, e.g. /api/v1/user/:external_id
+	RequestPath  string    `gorm:"column:request_path" json:"request_path"` // path as requested, e.g. /api/v1/user/25052300047
 	StatusCode   int       `gorm:"column:status_code" json:"status_code"`
 	ClientIP     string    `gorm:"column:client_ip" json:"client_ip"`
 	UserAgent    string    `gorm:"column:user_agent" json:"user_agent"`
-	RequestBody  string    `gorm:"type:NVARCHAR(MAX);column:request_body" json:"request_body"`   // consider redaction for sensitive fields
-	ResponseBody string    `gorm:"type:NVARCHAR(MAX);column:response_body" json:"response_body"` // optional
+	RequestBody  string    `gorm:"type:NVARCHAR(MAX);column:request_body" json:"request_body"`   // redacted, see middleware.AuditLogger
+	ResponseBody string    `gorm:"type:NVARCHAR(MAX);column:response_body" json:"response_body"` // redacted, see middleware.AuditLogger
 	LatencyMs    int64     `gorm:"column:latency_ms" json:"latency_ms"`
+
+	// ChainSeq orders the entry in the hash chain, nil for entries written before it existed
+	ChainSeq *int64 `gorm:"column:chain_seq;index" json:"chain_seq,omitempty"`
+	// Hash covers the entry's contents and the hash of the entry before it, see audit.Hash
+	Hash string `gorm:"column:hash;size:64" json:"hash,omitempty"`
+	// RestoredFrom names the archive a restored entry was loaded from; restored
+	// entries are outside the hash chain and retention
+	RestoredFrom string `gorm:"column:restored_from;size:255;index" json:"restored_from,omitempty"`
 }
 
 func MigrateAuditLog(db *gorm.DB) error {
@@ -12,8 +12,11 @@ const (
 	ContentTypeJson = "application/json"
 	ContentTypeForm = "application/x-www-form-urlencoded"
 
+	// HeaderRequestID carries the request id in and out of the API and on upstream calls
+	HeaderRequestID = "X-Request-ID"
+
 	// context keys
-	HttpClientCtxKey ctxKey = "httpClient"
+	RequestIDCtxKey ctxKey = "requestID"
 )
 
 type APIRequestOptions struct {
@@ -27,6 +30,8 @@ type APIRequestOptions struct {
 	Client         *http.Client
 	Context        context.Context
 	ContentType    string
+	// Upstream names the service called in upstream_call_logs, e.g. UpstreamCiam
+	Upstream string
 }
 
 type BasicAuthCredentials struct {
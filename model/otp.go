@@ -16,3 +16,20 @@ type Otp struct {
 	// example: 1744176000
 	OtpExpiry *int64 `json:"otp_expiry" example:"1744176000"`
 }
+
+// OtpVerification holds the token proving an OTP was verified and its expiry timestamp.
+//
+// Example:
+// {
+//   "verification_token": "2b1f0c6e-8a4e-4d8e-9a51-3f4b0f6c9d2a",
+//   "verification_token_expiry": 1744176000
+// }
+type OtpVerification struct {
+	// VerificationToken is handed to follow-up requests to prove the OTP was entered.
+	// example: "2b1f0c6e-8a4e-4d8e-9a51-3f4b0f6c9d2a"
+	VerificationToken string `json:"verification_token" example:"2b1f0c6e-8a4e-4d8e-9a51-3f4b0f6c9d2a"`
+
+	// VerificationTokenExpiry is the Unix timestamp (seconds since epoch) when the token expires.
+	// example: 1744176000
+	VerificationTokenExpiry int64 `json:"verification_token_expiry" example:"1744176000"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// registration attempt statuses
+const (
+	RegistrationStatusInProgress   = "IN_PROGRESS"
+	RegistrationStatusCompleted    = "COMPLETED"
+	RegistrationStatusPendingRetry = "PENDING_RETRY"
+	RegistrationStatusRolledBack   = "ROLLED_BACK"
+)
+
+// RegistrationAttempt keeps the state of a registration so a failed one can be
+// re-driven from the first unfinished step.
+type RegistrationAttempt struct {
+	RlpID      string `gorm:"column:rlp_id;primaryKey;size:20" json:"rlp_id"`
+	RlpNo      string `gorm:"column:rlp_no" json:"rlp_no"`
+	SignUpType string `gorm:"column:sign_up_type" json:"sign_up_type"`
+	// Payload is the RegisterUser request as JSON, after tier and identifiers were assigned
+	Payload    string `gorm:"type:NVARCHAR(MAX);column:payload" json:"-"`
+	CiamUserID string `gorm:"column:ciam_user_id" json:"ciam_user_id"`
+
+	CiamCreated          bool `gorm:"column:ciam_created" json:"ciam_created"`
+	SchemaExtensionAdded bool `gorm:"column:schema_extension_added" json:"schema_extension_added"`
+	RlpCreated           bool `gorm:"column:rlp_created" json:"rlp_created"`
+	RlpUpdated           bool `gorm:"column:rlp_updated" json:"rlp_updated"`
+	TierEventSent        bool `gorm:"column:tier_event_sent" json:"tier_event_sent"`
+
+	Status     string    `gorm:"column:status;index" json:"status"`
+	LastError  string    `gorm:"type:NVARCHAR(MAX);column:last_error" json:"last_error"`
+	RetryCount int       `gorm:"column:retry_count" json:"retry_count"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// IsStepCompleted reports whether the given registration step already went through.
+func (a *RegistrationAttempt) IsStepCompleted(step string) bool {
+	switch step {
+	case RegistrationStepCiamCreate:
+		return a.CiamCreated
+	case RegistrationStepSchemaExtension:
+		return a.SchemaExtensionAdded
+	case RegistrationStepRlpCreate:
+		return a.RlpCreated
+	case RegistrationStepRlpUpdate:
+		return a.RlpUpdated
+	case RegistrationStepTierEvent:
+		return a.TierEventSent
+	default:
+		return false
+	}
+}
+
+// CompleteStep flags the given registration step as finished.
+func (a *RegistrationAttempt) CompleteStep(step string) {
+	switch step {
+	case RegistrationStepCiamCreate:
+		a.CiamCreated = true
+	case RegistrationStepSchemaExtension:
+		a.SchemaExtensionAdded = true
+	case RegistrationStepRlpCreate:
+		a.RlpCreated = true
+	case RegistrationStepRlpUpdate:
+		a.RlpUpdated = true
+	case RegistrationStepTierEvent:
+		a.TierEventSent = true
+	}
+}
+
+func MigrateRegistrationAttempt(db *gorm.DB) error {
+	return db.AutoMigrate(&RegistrationAttempt{})
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// registration steps
+const (
+	RegistrationStepRlpNumbering    = "RLP_NUMBERING"
+	RegistrationStepCiamCreate      = "CIAM_CREATE"
+	RegistrationStepSchemaExtension = "CIAM_SCHEMA_EXTENSION"
+	RegistrationStepRlpCreate       = "RLP_CREATE"
+	RegistrationStepRlpUpdate       = "RLP_UPDATE"
+	RegistrationStepTierEvent       = "RLP_TIER_EVENT"
+)
+
+// RegistrationStepLog records the outcome of each step of a registration,
+// including compensations run after a failure.
+type RegistrationStepLog struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	RlpID     string    `gorm:"column:rlp_id;index" json:"rlp_id"`
+	Step      string    `gorm:"column:step" json:"step"`
+	Status    string    `gorm:"column:status" json:"status"`
+	Error     string    `gorm:"type:NVARCHAR(MAX);column:error" json:"error"`
+}
+
+func MigrateRegistrationStepLog(db *gorm.DB) error {
+	return db.AutoMigrate(&RegistrationStepLog{})
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// RLPNumberingCounter holds the last value handed out by a numbering sequence,
+// e.g. "RLP_NO" or the RLP_ID counter of the current period.
+type RLPNumberingCounter struct {
+	Name  string `gorm:"column:name;primaryKey;size:64" json:"name"`
+	Value int64  `gorm:"column:value" json:"value"`
+}
+
+func (RLPNumberingCounter) TableName() string {
+	return "rlp_numbering_counters"
+}
+
+func MigrateRLPNumberingCounter(db *gorm.DB) error {
+	return db.AutoMigrate(&RLPNumberingCounter{})
+}
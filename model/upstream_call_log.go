@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// upstreams named in upstream_call_logs and application.upstreams
+	UpstreamCiam      = "ciam"
+	UpstreamRlpCore   = "rlp_core"
+	UpstreamRlpOffers = "rlp_offers"
+	UpstreamCms       = "cms"
+	UpstreamAcs       = "acs"
+	UpstreamMember    = "member"
+)
+
+// UpstreamCallLog is one call made to an upstream while serving a request. It
+// shares RequestID with the AuditLog entry of that request.
+type UpstreamCallLog struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt    time.Time `gorm:"column:created_at;index" json:"created_at"`
+	RequestID    string    `gorm:"column:request_id;size:64;index" json:"request_id"`
+	Upstream     string    `gorm:"column:upstream;size:32" json:"upstream"`
+	Method       string    `gorm:"column:method;size:16" json:"method"`
+	URL          string    `gorm:"column:url;size:2048" json:"url"`       // secrets stripped and personal data masked, see middleware.OutboundAudit
+	StatusCode   int       `gorm:"column:status_code" json:"status_code"` // 0 when no response arrived
+	LatencyMs    int64     `gorm:"column:latency_ms" json:"latency_ms"`
+	RequestBody  string    `gorm:"type:NVARCHAR(MAX);column:request_body" json:"request_body"`   // redacted like AuditLog bodies
+	ResponseBody string    `gorm:"type:NVARCHAR(MAX);column:response_body" json:"response_body"` // redacted like AuditLog bodies
+	Error        string    `gorm:"column:error;size:1024" json:"error,omitempty"`
+}
+
+func (UpstreamCallLog) TableName() string {
+	return "upstream_call_logs"
+}
+
+func MigrateUpstreamCallLog(db *gorm.DB) error {
+	return db.AutoMigrate(&UpstreamCallLog{})
+}
@@ -0,0 +1,96 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+
+	"lbe/utils"
+)
+
+// step outcome statuses
+const (
+	StatusSucceeded          = "SUCCEEDED"
+	StatusFailed             = "FAILED"
+	StatusCompensated        = "COMPENSATED"
+	StatusCompensationFailed = "COMPENSATION_FAILED"
+)
+
+// Step is a single unit of work together with the action that undoes it.
+// Action may be nil for work that was already done before the step was
+// registered; Compensate may be nil for work that needs no undo.
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// Recorder persists the outcome of a step so operators can see which step broke.
+type Recorder func(step, status string, err error)
+
+// StepError identifies the step whose action failed.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Saga runs steps in order and keeps the compensations of the completed ones,
+// so they can be undone in reverse order when a later step fails.
+type Saga struct {
+	completed []Step
+	recorder  Recorder
+}
+
+func New(recorder Recorder) *Saga {
+	if recorder == nil {
+		recorder = func(string, string, error) {}
+	}
+	return &Saga{recorder: recorder}
+}
+
+// Execute runs the step action. On success the step is remembered for rollback,
+// on failure a *StepError is returned and nothing is compensated yet.
+func (s *Saga) Execute(ctx context.Context, step Step) error {
+	if step.Action != nil {
+		if err := step.Action(ctx); err != nil {
+			s.recorder(step.Name, StatusFailed, err)
+			return &StepError{Step: step.Name, Err: err}
+		}
+	}
+
+	s.recorder(step.Name, StatusSucceeded, nil)
+	s.completed = append(s.completed, step)
+	return nil
+}
+
+// Register adds a step that already completed earlier, e.g. in a previous attempt,
+// so that it still takes part in a rollback.
+func (s *Saga) Register(step Step) {
+	s.completed = append(s.completed, step)
+}
+
+// Rollback runs the compensations of all completed steps in reverse order.
+// A failed compensation is recorded and does not stop the remaining ones.
+func (s *Saga) Rollback(ctx context.Context) {
+	for i := len(s.completed) - 1; i >= 0; i-- {
+		step := s.completed[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := step.Compensate(ctx); err != nil {
+			utils.Logf(ctx, "compensation for step %s failed: %v", step.Name, err)
+			s.recorder(step.Name, StatusCompensationFailed, err)
+			continue
+		}
+		s.recorder(step.Name, StatusCompensated, nil)
+	}
+	s.completed = nil
+}
@@ -0,0 +1,56 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lbe/saga"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSagaRollback(t *testing.T) {
+	var compensated []string
+	var outcomes []string
+
+	recorder := func(step, status string, err error) {
+		outcomes = append(outcomes, step+":"+status)
+	}
+
+	step := func(name string, actionErr, compensateErr error) saga.Step {
+		return saga.Step{
+			Name:   name,
+			Action: func(ctx context.Context) error { return actionErr },
+			Compensate: func(ctx context.Context) error {
+				compensated = append(compensated, name)
+				return compensateErr
+			},
+		}
+	}
+
+	s := saga.New(recorder)
+	ctx := context.Background()
+
+	assert.NoError(t, s.Execute(ctx, step("A", nil, nil)))
+	assert.NoError(t, s.Execute(ctx, step("B", nil, errors.New("boom"))))
+	assert.NoError(t, s.Execute(ctx, saga.Step{Name: "C"}))
+
+	err := s.Execute(ctx, step("D", errors.New("upstream down"), nil))
+	var stepErr *saga.StepError
+	assert.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, "D", stepErr.Step)
+
+	s.Rollback(ctx)
+
+	// failed step is not compensated, the rest run in reverse order
+	assert.Equal(t, []string{"B", "A"}, compensated)
+	assert.Equal(t, []string{
+		"A:" + saga.StatusSucceeded,
+		"B:" + saga.StatusSucceeded,
+		"C:" + saga.StatusSucceeded,
+		"D:" + saga.StatusFailed,
+		"B:" + saga.StatusCompensationFailed,
+		"A:" + saga.StatusCompensated,
+	}, outcomes)
+}
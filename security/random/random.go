@@ -0,0 +1,57 @@
+// Package random generates codes, nonces and tokens from crypto/rand.
+package random
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+)
+
+const (
+	DigitAlphabet        = "0123456789"
+	AlphanumericAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+var ErrEmptyAlphabet = errors.New("random: empty alphabet")
+
+// String returns n characters picked uniformly from alphabet. The alphabet is
+// taken as characters rather than bytes, so it may hold multi-byte characters.
+func String(n int, alphabet string) (string, error) {
+	chars := []rune(alphabet)
+	if len(chars) == 0 {
+		return "", ErrEmptyAlphabet
+	}
+
+	max := big.NewInt(int64(len(chars)))
+	b := make([]rune, n)
+	for i := range b {
+		// rand.Int rejects out-of-range samples, so there is no modulo bias
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// Digits returns an n-digit numeric code. Leading zeros are allowed, so every
+// code of the given length is equally likely.
+func Digits(n int) (string, error) {
+	return String(n, DigitAlphabet)
+}
+
+// Alphanumeric returns an n-character nonce of [a-zA-Z0-9].
+func Alphanumeric(n int) (string, error) {
+	return String(n, AlphanumericAlphabet)
+}
+
+// Token returns nBytes of randomness encoded as unpadded URL-safe base64.
+func Token(nBytes int) (string, error) {
+	b := make([]byte, nBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
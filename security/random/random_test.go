@@ -0,0 +1,39 @@
+package random_test
+
+import (
+	"strings"
+	"testing"
+
+	"lbe/security/random"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		length   int
+		alphabet string
+	}{
+		{name: "digits", generate: func() (string, error) { return random.Digits(8) }, length: 8, alphabet: random.DigitAlphabet},
+		{name: "alphanumeric", generate: func() (string, error) { return random.Alphanumeric(16) }, length: 16, alphabet: random.AlphanumericAlphabet},
+		{name: "custom alphabet", generate: func() (string, error) { return random.String(6, "AB") }, length: 6, alphabet: "AB"},
+		{name: "multi-byte alphabet", generate: func() (string, error) { return random.String(6, "αβγ") }, length: 6, alphabet: "αβγ"},
+		{name: "token", generate: func() (string, error) { return random.Token(32) }, length: 43, alphabet: random.AlphanumericAlphabet + "-_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.generate()
+			assert.NoError(t, err)
+			assert.Len(t, []rune(value), tt.length)
+			for _, r := range value {
+				assert.True(t, strings.ContainsRune(tt.alphabet, r), "unexpected character %q", r)
+			}
+		})
+	}
+
+	_, err := random.String(4, "")
+	assert.ErrorIs(t, err, random.ErrEmptyAlphabet)
+}
@@ -0,0 +1,100 @@
+package redact_test
+
+import (
+	"testing"
+
+	"lbe/security/redact"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_JSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []redact.Rule
+		body        string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "mask nested field",
+			rules:    []redact.Rule{{Path: "user.gr_profile.pin", Action: redact.Mask}},
+			body:     `{"user":{"gr_profile":{"pin":"1234","class":"1"}}}`,
+			expected: `{"user":{"gr_profile":{"pin":"****","class":"1"}}}`,
+		},
+		{
+			name:     "any depth",
+			rules:    []redact.Rule{{Path: "**.otp", Action: redact.Mask}},
+			body:     `{"otp":"111111","data":{"otp":"222222","otp_expiry":1744176000}}`,
+			expected: `{"otp":"****","data":{"otp":"****","otp_expiry":1744176000}}`,
+		},
+		{
+			name:     "arrays are transparent",
+			rules:    []redact.Rule{{Path: "user.phone_numbers.phone_number", Action: redact.Mask}},
+			body:     `{"user":{"phone_numbers":[{"phone_number":"87654321","phone_type":"mobile"},{"phone_number":"91234567"}]}}`,
+			expected: `{"user":{"phone_numbers":[{"phone_number":"****","phone_type":"mobile"},{"phone_number":"****"}]}}`,
+		},
+		{
+			name:     "wildcard key",
+			rules:    []redact.Rule{{Path: "data.*.pin", Action: redact.Drop}},
+			body:     `{"data":{"a":{"pin":"1"},"b":{"pin":"2","id":"x"}}}`,
+			expected: `{"data":{"a":{},"b":{"id":"x"}}}`,
+		},
+		{
+			name:     "drop field",
+			rules:    []redact.Rule{{Path: "**.burn_pin", Action: redact.Drop}},
+			body:     `{"email":"user@example.com","burn_pin":4321}`,
+			expected: `{"email":"user@example.com"}`,
+		},
+		{
+			name:     "hash is keyed and stable",
+			rules:    []redact.Rule{{Path: "email", Action: redact.Hash}},
+			body:     `{"email":"user@example.com"}`,
+			expected: `{"email":"sha256:80d471d8524b667fae82276f8767831e3dbad5f7b70d4ee3a6893b1a34c8f7eb"}`,
+		},
+		{
+			name:     "null values stay null",
+			rules:    []redact.Rule{{Path: "dob", Action: redact.Mask}},
+			body:     `{"dob":null}`,
+			expected: `{"dob":null}`,
+		},
+		{
+			name:     "large numbers are kept verbatim",
+			rules:    []redact.Rule{{Path: "otp", Action: redact.Mask}},
+			body:     `{"otp":"1","rlp_no":700000000013456789}`,
+			expected: `{"otp":"****","rlp_no":700000000013456789}`,
+		},
+		{
+			name:        "not json",
+			rules:       []redact.Rule{{Path: "otp", Action: redact.Mask}},
+			body:        `otp=123456`,
+			expectedErr: redact.ErrNotJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := redact.New([]byte("test-key"), tt.rules...)
+			assert.NoError(t, err)
+
+			actual, err := r.JSON([]byte(tt.body))
+			assert.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				assert.JSONEq(t, tt.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	tests := []redact.Rule{
+		{Path: "otp", Action: "encrypt"},
+		{Path: "user..pin", Action: redact.Mask},
+		{Path: "user.**", Action: redact.Mask},
+	}
+
+	for _, rule := range tests {
+		_, err := redact.New(nil, rule)
+		assert.Error(t, err, rule.Path)
+	}
+}
@@ -1,19 +1,7 @@
 package system
 
-import (
-	"math/rand"
-	"time"
-)
+import "lbe/security/random"
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
-func GenerateNonce(length int) string {
-	letters := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(b)
+func GenerateNonce(length int) (string, error) {
+	return random.Alphanumeric(length)
 }
@@ -0,0 +1,117 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit open")
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// BreakerState reports the circuit breaker of one upstream.
+type BreakerState struct {
+	Upstream            string     `json:"upstream" example:"rlp_core"`
+	State               string     `json:"state" example:"closed"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"0"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+type outcome int
+
+const (
+	success outcome = iota
+	failure
+	// ignored calls say nothing about the upstream, e.g. the caller gave up
+	ignored
+)
+
+// breaker opens after threshold consecutive failures and fails calls fast for
+// cooldown. It then lets a single probe through, whose outcome closes the
+// circuit or opens it again.
+type breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(name string, threshold int, cooldown time.Duration) *breaker {
+	return &breaker{name: name, threshold: threshold, cooldown: cooldown, now: time.Now, state: StateClosed}
+}
+
+// allow reports whether a call may go out, returning an ErrCircuitOpen error when not.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		retryIn := b.openedAt.Add(b.cooldown).Sub(b.now())
+		if retryIn > 0 {
+			return fmt.Errorf("%s %w, retrying in %s", b.name, ErrCircuitOpen, retryIn.Round(time.Millisecond))
+		}
+		b.state = StateHalfOpen
+		fallthrough
+	case StateHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s %w, waiting for a probe", b.name, ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *breaker) record(o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.probing = false
+		switch o {
+		case success:
+			log.Printf("upstream %s circuit closed", b.name)
+			b.state, b.failures = StateClosed, 0
+		case failure:
+			log.Printf("upstream %s circuit opened again, probe failed", b.name)
+			b.state, b.openedAt = StateOpen, b.now()
+		}
+		return
+	}
+
+	switch o {
+	case success:
+		b.failures = 0
+	case failure:
+		b.failures++
+		if b.state == StateClosed && b.failures >= b.threshold {
+			log.Printf("upstream %s circuit opened after %d consecutive failures", b.name, b.failures)
+			b.state, b.openedAt = StateOpen, b.now()
+		}
+	}
+}
+
+func (b *breaker) snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{Upstream: b.name, State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		state.OpenedAt = &openedAt
+	}
+	return state
+}
@@ -0,0 +1,171 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transport makes the attempts of a call through base, or
+// http.DefaultTransport when base is nil.
+type transport struct {
+	upstream *Upstream
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	retries := t.upstream.policy.GetMaxRetries()
+	// a body that cannot be replayed allows a single attempt
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.upstream.breaker.allow(); err != nil {
+			return nil, err
+		}
+
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				t.upstream.breaker.record(ignored)
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.attempt(base, r)
+		t.upstream.breaker.record(classify(req, resp, err))
+
+		if attempt >= retries || !retryable(req, resp, err) {
+			return resp, err
+		}
+		wait, ok := t.upstream.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt bounds one attempt, reading the body included, by the policy timeout.
+func (t *transport) attempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.upstream.policy.GetTimeout())
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// classify tells the breaker whether the upstream looked unavailable. Other
+// error statuses come from an upstream that is up and do not count.
+func classify(req *http.Request, resp *http.Response, err error) outcome {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return ignored
+		}
+		return failure
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return failure
+	}
+	return success
+}
+
+// retryable allows retrying any method on statuses saying the request was not
+// processed, and idempotent methods on transport errors and gateway failures too.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return idempotent(req.Method) && !errors.Is(err, ErrCircuitOpen)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the pause before the retry following attempt: exponential
+// with equal jitter, or longer when the upstream asked for it with Retry-After.
+// It reports false when Retry-After asks for more than the policy waits.
+func (u *Upstream) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	wait := u.policy.GetBackoffBase() << attempt
+	if ceiling := u.policy.GetBackoffMax(); wait > ceiling || wait <= 0 {
+		wait = ceiling
+	}
+	wait = wait/2 + rand.N(wait/2+1)
+
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if retryAfter > u.policy.GetRetryAfterMax() {
+				return 0, false
+			}
+			wait = max(wait, retryAfter)
+		}
+	}
+	return wait, true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,126 @@
+// Package upstream applies the per-upstream call policies of
+// application.upstreams: a timeout per attempt, retries with jittered
+// exponential backoff and a circuit breaker.
+package upstream
+
+import (
+	"net/http"
+	"sync"
+
+	"lbe/config"
+	"lbe/model"
+)
+
+// Names lists the upstreams with a policy, in the order States reports them.
+var Names = []string{
+	model.UpstreamCiam,
+	model.UpstreamRlpCore,
+	model.UpstreamRlpOffers,
+	model.UpstreamCms,
+	model.UpstreamAcs,
+	model.UpstreamMember,
+}
+
+// Upstream applies one upstream's policy to the calls made to it.
+type Upstream struct {
+	name    string
+	policy  config.UpstreamPolicy
+	breaker *breaker
+	client  *http.Client
+}
+
+func New(name string, policy config.UpstreamPolicy) *Upstream {
+	u := &Upstream{
+		name:    name,
+		policy:  policy,
+		breaker: newBreaker(name, policy.GetBreakerThreshold(), policy.GetBreakerCooldown()),
+	}
+	u.client = &http.Client{Transport: &transport{upstream: u}}
+	return u
+}
+
+// Client returns a client shared by every call to the upstream.
+func (u *Upstream) Client() *http.Client {
+	return u.client
+}
+
+// Wrap returns a copy of client whose calls follow the upstream's policy. The
+// transport of client still makes the calls.
+func (u *Upstream) Wrap(client *http.Client) *http.Client {
+	wrapped := *client
+	wrapped.Transport = &transport{upstream: u, base: client.Transport}
+	return &wrapped
+}
+
+func (u *Upstream) State() BreakerState {
+	return u.breaker.snapshot()
+}
+
+var (
+	mu        sync.Mutex
+	upstreams = map[string]*Upstream{}
+)
+
+// Get returns the upstream called name, set up from application.upstreams on
+// first use, or nil for an upstream without a policy.
+func Get(name string) *Upstream {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if u, ok := upstreams[name]; ok {
+		return u
+	}
+	policy, ok := policyOf(name)
+	if !ok {
+		return nil
+	}
+	u := New(name, policy)
+	upstreams[name] = u
+	return u
+}
+
+// Client returns the shared client of the upstream called name, or a plain
+// client for an upstream without a policy.
+func Client(name string) *http.Client {
+	if u := Get(name); u != nil {
+		return u.Client()
+	}
+	return http.DefaultClient
+}
+
+// Wrap applies the policy of the upstream called name to client, or returns
+// client as is for an upstream without a policy.
+func Wrap(client *http.Client, name string) *http.Client {
+	if u := Get(name); u != nil {
+		return u.Wrap(client)
+	}
+	return client
+}
+
+// States reports the circuit breaker of every upstream.
+func States() []BreakerState {
+	states := make([]BreakerState, 0, len(Names))
+	for _, name := range Names {
+		states = append(states, Get(name).State())
+	}
+	return states
+}
+
+func policyOf(name string) (config.UpstreamPolicy, bool) {
+	conf := config.GetConfig().Application.Upstreams
+	switch name {
+	case model.UpstreamCiam:
+		return conf.Ciam, true
+	case model.UpstreamRlpCore:
+		return conf.RlpCore, true
+	case model.UpstreamRlpOffers:
+		return conf.RlpOffers, true
+	case model.UpstreamCms:
+		return conf.Cms, true
+	case model.UpstreamAcs:
+		return conf.Acs, true
+	case model.UpstreamMember:
+		return conf.Member, true
+	}
+	return config.UpstreamPolicy{}, false
+}
@@ -0,0 +1,193 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"lbe/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replies serves the given statuses in turn, repeating the last one, and records the bodies received.
+type replies struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (r *replies) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.statuses[min(len(r.bodies), len(r.statuses)-1)]
+	r.bodies = append(r.bodies, string(body))
+
+	if r.retryAfter != "" {
+		w.Header().Set("Retry-After", r.retryAfter)
+	}
+	w.WriteHeader(status)
+}
+
+func (r *replies) calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+var testPolicy = config.UpstreamPolicy{
+	MaxRetries:       2,
+	BackoffBase:      time.Millisecond,
+	BackoffMax:       5 * time.Millisecond,
+	RetryAfterMax:    time.Second,
+	BreakerThreshold: 100,
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		statuses       []int
+		retryAfter     string
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{name: "success needs one call", method: http.MethodGet, statuses: []int{200}, expectedStatus: 200, expectedCalls: 1},
+		{name: "get retried on 502", method: http.MethodGet, statuses: []int{502, 200}, expectedStatus: 200, expectedCalls: 2},
+		{name: "retries run out", method: http.MethodGet, statuses: []int{503}, expectedStatus: 503, expectedCalls: 3},
+		{name: "post not retried on 502", method: http.MethodPost, statuses: []int{502, 200}, expectedStatus: 502, expectedCalls: 1},
+		{name: "post retried on 429", method: http.MethodPost, statuses: []int{429, 201}, retryAfter: "0", expectedStatus: 201, expectedCalls: 2},
+		{name: "post retried on 503", method: http.MethodPost, statuses: []int{503, 201}, expectedStatus: 201, expectedCalls: 2},
+		{name: "500 not retried", method: http.MethodGet, statuses: []int{500, 200}, expectedStatus: 500, expectedCalls: 1},
+		{name: "retry-after beyond the policy ends retries", method: http.MethodGet, statuses: []int{503, 200}, retryAfter: "120", expectedStatus: 503, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := &replies{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			srv := httptest.NewServer(rep)
+			defer srv.Close()
+
+			client := New("test", testPolicy).Wrap(srv.Client())
+			req, _ := http.NewRequest(tt.method, srv.URL, bytes.NewBufferString(`{"id":1}`))
+			resp, err := client.Do(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			}
+
+			assert.Equal(t, tt.expectedCalls, rep.calls())
+			for _, body := range rep.bodies {
+				assert.Equal(t, `{"id":1}`, body, "body replayed on retry")
+			}
+		})
+	}
+}
+
+func TestTransportTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	policy := testPolicy
+	policy.Timeout = 20 * time.Millisecond
+	policy.MaxRetries = -1
+
+	start := time.Now()
+	_, err := New("test", policy).Wrap(srv.Client()).Get(srv.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC)
+	b := newBreaker("test", 3, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.allow())
+		b.record(failure)
+	}
+	assert.NoError(t, b.allow())
+	b.record(success)
+	assert.Equal(t, 0, b.snapshot().ConsecutiveFailures, "success resets the count")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.allow())
+		b.record(failure)
+	}
+	assert.Equal(t, StateOpen, b.snapshot().State)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// after the cooldown a single probe goes out
+	now = now.Add(31 * time.Second)
+	assert.NoError(t, b.allow())
+	assert.Equal(t, StateHalfOpen, b.snapshot().State)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	b.record(failure)
+	assert.Equal(t, StateOpen, b.snapshot().State)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	now = now.Add(31 * time.Second)
+	assert.NoError(t, b.allow())
+	b.record(success)
+	assert.Equal(t, BreakerState{Upstream: "test", State: StateClosed}, b.snapshot())
+}
+
+func TestBreakerFailsFast(t *testing.T) {
+	rep := &replies{statuses: []int{503}}
+	srv := httptest.NewServer(rep)
+	defer srv.Close()
+
+	policy := testPolicy
+	policy.MaxRetries = -1
+	policy.BreakerThreshold = 2
+	client := New("test", policy).Wrap(srv.Client())
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	_, err := client.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, rep.calls())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", ok: false},
+		{value: "3", expected: 3 * time.Second, ok: true},
+		{value: "Fri, 23 May 2025 09:00:10 GMT", expected: 10 * time.Second, ok: true},
+		{value: "Fri, 23 May 2025 08:59:00 GMT", expected: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			wait, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, wait)
+		})
+	}
+}
@@ -8,31 +8,172 @@ import (
 	"fmt"
 	"io"
 	"lbe/model"
+	"lbe/upstream"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
-func WithHttpClient(ctx context.Context, client *http.Client) context.Context {
-	return context.WithValue(ctx, model.HttpClientCtxKey, client)
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, model.RequestIDCtxKey, id)
 }
 
-func GetHttpClient(ctx context.Context) *http.Client {
-	if client, ok := ctx.Value(model.HttpClientCtxKey).(*http.Client); ok {
-		return client
+// GetRequestID returns the id of the inbound request ctx belongs to, or "".
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
 	}
-	return http.DefaultClient // fallback
+	id, _ := ctx.Value(model.RequestIDCtxKey).(string)
+	return id
+}
+
+// ForwardRequestID passes the request id of ctx on to an upstream call.
+func ForwardRequestID(ctx context.Context, req *http.Request) {
+	if id := GetRequestID(ctx); id != "" && req.Header.Get(model.HeaderRequestID) == "" {
+		req.Header.Set(model.HeaderRequestID, id)
+	}
+}
+
+// Logf logs like log.Printf, prefixed with the request id of ctx so the lines
+// of one request can be told apart.
+func Logf(ctx context.Context, format string, args ...any) {
+	if id := GetRequestID(ctx); id != "" {
+		format = "[" + id + "] " + format
+	}
+	log.Output(2, fmt.Sprintf(format, args...))
+}
+
+// OutboundCall is one upstream call made by DoAPIRequest.
+type OutboundCall struct {
+	Upstream  string
+	RequestID string
+	Method    string
+	// URL has its secrets stripped, see StripURLSecrets
+	URL          string
+	StartedAt    time.Time
+	Latency      time.Duration
+	StatusCode   int
+	RequestBody  []byte
+	ResponseBody []byte
+	// Err is set when no response was read
+	Err error
+}
+
+// OutboundAuditHook is told about every call DoAPIRequest makes. It runs on the
+// caller's goroutine and must not block.
+type OutboundAuditHook func(ctx context.Context, call OutboundCall)
+
+var (
+	outboundMu   sync.RWMutex
+	outboundHook OutboundAuditHook
+)
+
+// SetOutboundAuditHook installs hook, nil turns outbound auditing off.
+func SetOutboundAuditHook(hook OutboundAuditHook) {
+	outboundMu.Lock()
+	outboundHook = hook
+	outboundMu.Unlock()
+}
+
+func auditOutbound(opts model.APIRequestOptions, call OutboundCall) {
+	outboundMu.RLock()
+	hook := outboundHook
+	outboundMu.RUnlock()
+	if hook == nil {
+		return
+	}
+
+	call.Upstream = opts.Upstream
+	call.RequestID = GetRequestID(opts.Context)
+	call.Method = opts.Method
+	call.URL = StripURLSecrets(opts.URL)
+	call.Latency = time.Since(call.StartedAt)
+	hook(opts.Context, call)
+}
+
+// secretQueryKeys are parts of query parameter names whose values StripURLSecrets masks.
+var secretQueryKeys = []string{"token", "secret", "password", "key", "sig", "code", "otp", "pin", "auth"}
+
+// StripURLSecrets masks the password of the user info and the values of query
+// parameters that look like credentials.
+func StripURLSecrets(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "[unparsable url]"
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "****")
+	}
+
+	query := u.Query()
+	masked := false
+	for name, values := range query {
+		lower := strings.ToLower(name)
+		for _, key := range secretQueryKeys {
+			if strings.Contains(lower, key) {
+				for i := range values {
+					values[i] = "****"
+				}
+				masked = true
+				break
+			}
+		}
+	}
+	if masked {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// logURL is raw as it is logged: without user info and query, which may carry
+// credentials or personal data such as the email of a Graph $filter.
+func logURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "[unparsable url]"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// UnexpectedStatusError is returned by DoAPIRequest when the upstream replies
+// with a status other than the expected one.
+type UnexpectedStatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, string(e.Body))
+}
+
+// IsTransientError reports whether a DoAPIRequest error is likely to go away on retry:
+// transport failures, upstream 5xx and 429 responses.
+func IsTransientError(err error) bool {
+	var statusErr *UnexpectedStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
 }
 
 func DoAPIRequest[T any](opts model.APIRequestOptions) (*T, []byte, error) {
-	var bodyReader io.Reader
+	var reqBody []byte
 	if opts.Body != nil {
 		switch opts.ContentType {
 		case model.ContentTypeForm:
 			// Expect Body to be url.Values
 			if form, ok := opts.Body.(url.Values); ok {
-				bodyReader = strings.NewReader(form.Encode())
+				reqBody = []byte(form.Encode())
 			} else {
 				return nil, nil, fmt.Errorf("body must be url.Values for content type %s", opts.ContentType)
 			}
@@ -44,11 +185,16 @@ func DoAPIRequest[T any](opts model.APIRequestOptions) (*T, []byte, error) {
 			if err != nil {
 				return nil, nil, fmt.Errorf("marshaling body: %w", err)
 			}
-			bodyReader = bytes.NewReader(b)
+			reqBody = b
 			opts.ContentType = model.ContentTypeJson // ensure default if empty
 		}
 	}
 
+	var bodyReader io.Reader
+	if reqBody != nil {
+		bodyReader = bytes.NewReader(reqBody)
+	}
+
 	req, err := http.NewRequestWithContext(opts.Context, opts.Method, opts.URL, bodyReader)
 	if err != nil {
 		return nil, nil, fmt.Errorf("creating request: %w", err)
@@ -75,33 +221,37 @@ func DoAPIRequest[T any](opts model.APIRequestOptions) (*T, []byte, error) {
 	for k, v := range opts.Headers {
 		req.Header.Set(k, v)
 	}
+	ForwardRequestID(opts.Context, req)
 
 	// --- LOG REQUEST HERE ---
-	log.Printf("[API REQUEST] %s %s; Content-Type: %s; Body: %s", opts.Method, opts.URL, opts.ContentType, func() string {
-		if opts.Body == nil {
-			return "<empty>"
-		}
-		b, err := json.Marshal(opts.Body)
-		if err != nil {
-			return "<error marshaling body>"
-		}
-		return string(b)
-	}())
+	// bodies and query values carry personal data and credentials, they are only
+	// kept redacted in the upstream call log, see SetOutboundAuditHook
+	Logf(opts.Context, "[API REQUEST] %s %s; Content-Type: %s; Body: %d bytes", opts.Method, logURL(opts.URL), opts.ContentType, len(reqBody))
+
+	// the upstream's policy adds timeouts, retries and its circuit breaker
+	client := opts.Client
+	if opts.Upstream != "" {
+		client = upstream.Wrap(client, opts.Upstream)
+	}
 
-	resp, err := opts.Client.Do(req)
+	call := OutboundCall{StartedAt: time.Now(), RequestBody: reqBody}
+	resp, err := client.Do(req)
 	if err != nil {
+		call.Err = err
+		auditOutbound(opts, call)
 		return nil, nil, fmt.Errorf("executing request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	raw, err := io.ReadAll(resp.Body)
+	call.StatusCode, call.ResponseBody, call.Err = resp.StatusCode, raw, err
+	auditOutbound(opts, call)
 	if err != nil {
 		return nil, nil, fmt.Errorf("reading response body: %w", err)
 	}
 
 	// --- LOG RESPONSE HERE ---
-	log.Printf("[API RESPONSE] Status: %d; Body: %s", resp.StatusCode,
-		strings.Join(strings.Fields(strings.ReplaceAll(strings.ReplaceAll(string(raw), "\n", " "), "\t", " ")), " "))
+	Logf(opts.Context, "[API RESPONSE] %s %s; Status: %d; Body: %d bytes", opts.Method, logURL(opts.URL), resp.StatusCode, len(raw))
 
 	// 1) strip UTF-8 BOM if present
 	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
@@ -109,7 +259,7 @@ func DoAPIRequest[T any](opts model.APIRequestOptions) (*T, []byte, error) {
 	raw = []byte(strings.ReplaceAll(string(raw), "\u00A0", " "))
 
 	if resp.StatusCode != opts.ExpectedStatus {
-		return nil, raw, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(raw))
+		return nil, raw, &UnexpectedStatusError{StatusCode: resp.StatusCode, Body: raw}
 	}
 
 	if len(raw) == 0 {
@@ -119,7 +269,7 @@ func DoAPIRequest[T any](opts model.APIRequestOptions) (*T, []byte, error) {
 
 	var result T
 	if err := json.Unmarshal(raw, &result); err != nil {
-		return nil, raw, fmt.Errorf("failed to decode response: %w; cleaned body: %q", err, raw)
+		return nil, raw, fmt.Errorf("failed to decode response of %d bytes: %w", len(raw), err)
 	}
 	return &result, raw, nil
 }
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"lbe/config"
+	"lbe/model"
+	"lbe/system"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// AccessToken is a bearer token together with the moment it expires.
+type AccessToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenFetcher acquires a new token from the upstream auth endpoint.
+type TokenFetcher func(ctx context.Context, client *http.Client) (AccessToken, error)
+
+// TokenProvider caches an upstream access token until shortly before it expires,
+// so a request hitting the same upstream several times exchanges credentials once.
+// Concurrent refreshes are collapsed into a single call, and when
+// application.accessToken.shareViaRedis is set the token is shared with other replicas.
+type TokenProvider struct {
+	name  string
+	fetch TokenFetcher
+
+	mu     sync.RWMutex
+	cached AccessToken
+	group  singleflight.Group
+}
+
+func NewTokenProvider(name string, fetch TokenFetcher) *TokenProvider {
+	return &TokenProvider{name: name, fetch: fetch}
+}
+
+// Token returns a token that stays valid for at least the configured refresh margin.
+func (p *TokenProvider) Token(ctx context.Context, client *http.Client) (string, error) {
+	if token, ok := p.local(); ok {
+		return token, nil
+	}
+
+	// the refresh is shared by every waiting caller, one of them going away must not cancel it
+	ch := p.group.DoChan(p.name, func() (any, error) {
+		return p.refresh(context.WithoutCancel(ctx), client)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Invalidate drops the cached token, e.g. after rotating the client secret.
+func (p *TokenProvider) Invalidate(ctx context.Context) {
+	p.invalidate(ctx, "")
+}
+
+// invalidate drops the cached token if it is the rejected one, or whatever token is
+// cached when rejected is empty. A token fetched by another caller after the rejected
+// one was handed out is kept.
+func (p *TokenProvider) invalidate(ctx context.Context, rejected string) {
+	p.mu.Lock()
+	if rejected == "" || p.cached.Token == rejected {
+		p.cached = AccessToken{}
+	}
+	p.mu.Unlock()
+
+	if rdb := system.GetRedis(); rdb != nil && config.GetConfig().Application.AccessToken.ShareViaRedis {
+		if shared, ok := p.loadShared(ctx); ok && rejected != "" && shared.Token != rejected {
+			return
+		}
+		rdb.Del(ctx, p.redisKey())
+	}
+}
+
+// DoAuthorizedRequest makes the request with a bearer token from p. An upstream answering
+// 401 no longer accepts the cached token, so it is dropped and the request retried once
+// with a fresh one.
+func DoAuthorizedRequest[T any](p *TokenProvider, opts model.APIRequestOptions) (*T, []byte, error) {
+	for retried := false; ; retried = true {
+		token, err := p.Token(opts.Context, opts.Client)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.BearerToken = token
+
+		resp, body, err := DoAPIRequest[T](opts)
+		var statusErr *UnexpectedStatusError
+		if !retried && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+			Logf(opts.Context, "%s rejected the access token, retrying with a new one", p.name)
+			p.invalidate(opts.Context, token)
+			continue
+		}
+		return resp, body, err
+	}
+}
+
+func (p *TokenProvider) local() (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.usable(p.cached) {
+		return p.cached.Token, true
+	}
+	return "", false
+}
+
+func (p *TokenProvider) refresh(ctx context.Context, client *http.Client) (string, error) {
+	// another caller may have refreshed while this one was waiting
+	if token, ok := p.local(); ok {
+		return token, nil
+	}
+
+	shared := config.GetConfig().Application.AccessToken.ShareViaRedis
+	if shared {
+		if token, ok := p.loadShared(ctx); ok {
+			p.store(token)
+			return token.Token, nil
+		}
+	}
+
+	token, err := p.fetch(ctx, client)
+	if err != nil {
+		return "", fmt.Errorf("fetching %s access token: %w", p.name, err)
+	}
+	if token.ExpiresAt.IsZero() {
+		token.ExpiresAt = time.Now().Add(config.GetConfig().Application.AccessToken.GetDefaultTtl())
+	}
+
+	p.store(token)
+	if shared {
+		p.saveShared(ctx, token)
+	}
+
+	return token.Token, nil
+}
+
+func (p *TokenProvider) store(token AccessToken) {
+	p.mu.Lock()
+	p.cached = token
+	p.mu.Unlock()
+}
+
+func (p *TokenProvider) usable(token AccessToken) bool {
+	refreshBefore := config.GetConfig().Application.AccessToken.GetRefreshBefore()
+	return token.Token != "" && time.Now().Add(refreshBefore).Before(token.ExpiresAt)
+}
+
+func (p *TokenProvider) redisKey() string {
+	return "access_token:" + p.name
+}
+
+func (p *TokenProvider) loadShared(ctx context.Context) (AccessToken, bool) {
+	var token AccessToken
+
+	rdb := system.GetRedis()
+	if rdb == nil {
+		return token, false
+	}
+
+	val, err := rdb.Get(ctx, p.redisKey()).Result()
+	if err != nil {
+		if err != system.Nil {
+			Logf(ctx, "error loading shared %s access token: %v", p.name, err)
+		}
+		return token, false
+	}
+	if err := json.Unmarshal([]byte(val), &token); err != nil {
+		Logf(ctx, "error decoding shared %s access token: %v", p.name, err)
+		return token, false
+	}
+
+	return token, p.usable(token)
+}
+
+func (p *TokenProvider) saveShared(ctx context.Context, token AccessToken) {
+	rdb := system.GetRedis()
+	if rdb == nil {
+		return
+	}
+
+	ttl := time.Until(token.ExpiresAt) - config.GetConfig().Application.AccessToken.GetRefreshBefore()
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		Logf(ctx, "error encoding %s access token: %v", p.name, err)
+		return
+	}
+	if err := rdb.Set(ctx, p.redisKey(), data, ttl).Err(); err != nil {
+		Logf(ctx, "error sharing %s access token: %v", p.name, err)
+	}
+}
@@ -0,0 +1,130 @@
+package utils_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lbe/model"
+	"lbe/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenProvider(t *testing.T) {
+	tests := []struct {
+		name            string
+		expiresIn       time.Duration
+		calls           int
+		concurrent      bool
+		invalidate      bool
+		expectedFetches int32
+	}{
+		{name: "concurrent callers share one fetch", expiresIn: time.Hour, calls: 20, concurrent: true, expectedFetches: 1},
+		{name: "token about to expire is refreshed", expiresIn: 30 * time.Second, calls: 3, expectedFetches: 3},
+		{name: "missing expiry uses default ttl", calls: 3, expectedFetches: 1},
+		{name: "invalidated token is refetched", expiresIn: time.Hour, calls: 2, invalidate: true, expectedFetches: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			provider := utils.NewTokenProvider("test-"+tt.name, func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+				fetches.Add(1)
+				time.Sleep(10 * time.Millisecond)
+
+				token := utils.AccessToken{Token: "mockToken"}
+				if tt.expiresIn > 0 {
+					token.ExpiresAt = time.Now().Add(tt.expiresIn)
+				}
+				return token, nil
+			})
+
+			call := func() {
+				token, err := provider.Token(context.Background(), nil)
+				assert.NoError(t, err)
+				assert.Equal(t, "mockToken", token)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < tt.calls; i++ {
+				if tt.concurrent {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						call()
+					}()
+					continue
+				}
+
+				call()
+				if tt.invalidate {
+					provider.Invalidate(context.Background())
+				}
+			}
+			wg.Wait()
+
+			assert.Equal(t, tt.expectedFetches, fetches.Load())
+		})
+	}
+}
+
+func TestDoAuthorizedRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		rejectedTokens  int32
+		expectedErr     bool
+		expectedFetches int32
+		expectedCalls   int32
+	}{
+		{name: "accepted token", expectedFetches: 1, expectedCalls: 1},
+		{name: "rejected token is refetched once", rejectedTokens: 1, expectedFetches: 2, expectedCalls: 2},
+		{name: "second rejection is returned", rejectedTokens: 2, expectedErr: true, expectedFetches: 2, expectedCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches, calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				// the first rejectedTokens tokens handed out are refused
+				var n int32
+				fmt.Sscanf(r.Header.Get("Authorization"), "Bearer token-%d", &n)
+				if n <= tt.rejectedTokens {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			provider := utils.NewTokenProvider("test-"+tt.name, func(ctx context.Context, client *http.Client) (utils.AccessToken, error) {
+				return utils.AccessToken{Token: fmt.Sprintf("token-%d", fetches.Add(1)), ExpiresAt: time.Now().Add(time.Hour)}, nil
+			})
+
+			_, _, err := utils.DoAuthorizedRequest[struct{}](provider, model.APIRequestOptions{
+				Method:         http.MethodGet,
+				URL:            server.URL,
+				ExpectedStatus: http.StatusOK,
+				Client:         server.Client(),
+				Context:        context.Background(),
+			})
+
+			if tt.expectedErr {
+				var statusErr *utils.UnexpectedStatusError
+				if assert.ErrorAs(t, err, &statusErr) {
+					assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedFetches, fetches.Load())
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+		})
+	}
+}
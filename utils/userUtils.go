@@ -1,90 +1,286 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"lbe/checkdigit"
 	"lbe/config"
 	"lbe/model"
 	"lbe/system"
+	"math"
+	"strconv"
 	"strings"
 	"time"
+	// the runtime image ships without a zoneinfo database
+	_ "time/tzdata"
 
 	"gorm.io/gorm"
 )
 
+const rlpNoCounter = "RLP_NO"
+
+// rlpNoBaseLength is the width of the sequential part of an RLP_NO. Numbers issued
+// before check digits were enabled consist of this part only.
+const rlpNoBaseLength = 11
+
+const rlpIdSeqToken = "{seq}"
+
+var (
+	ErrInvalidRLPNo          = errors.New("invalid rlp_no")
+	ErrRLPIdCounterExhausted = errors.New("rlp_id counter exhausted")
+)
+
+// GenerateNextRLPUserNumbering allocates the next RLP_NO and the next RLP_ID of the
+// current period. Both values come from counter rows that are incremented inside one
+// transaction, so concurrent registrations queue on the row lock instead of racing
+// on MAX()+1, and a failed insert rolls the counters back without leaving a gap.
 func GenerateNextRLPUserNumbering() (*model.RLPUserNumbering, error) {
-	db := system.GetDb()
-	now := time.Now()
+	layout, err := newRlpIdLayout(time.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	year := int64(now.Year() % 100)
-	month := int64(now.Month())
-	day := int64(now.Day())
+	var newRlp *model.RLPUserNumbering
+	err = system.GetDb().Transaction(func(tx *gorm.DB) error {
+		// counters are always locked in the same order so allocations cannot deadlock
+		rlpNo, err := NextCounterValue(tx, rlpNoCounter, seedRlpNo)
+		if err != nil {
+			return err
+		}
 
-	// Get latest RLP_NO
-	var lastEntry model.RLPUserNumbering
-	err := db.Order("rlp_no DESC").First(&lastEntry).Error
-	var nextRlpNo string
-	conf := config.GetConfig()
-	if err == gorm.ErrRecordNotFound {
-		nextRlpNo = conf.Application.RLPNumberingFormat.RLPNODefault
-	} else if err != nil {
+		formattedRlpNo, err := FormatRLPNo(rlpNo)
+		if err != nil {
+			return err
+		}
+
+		endingNo, err := NextCounterValue(tx, layout.counterName(), layout.seed)
+		if err != nil {
+			return err
+		}
+
+		// refused before the insert, the rollback hands the counter value back
+		rlpId, err := layout.format(endingNo)
+		if err != nil {
+			return err
+		}
+
+		newRlp = &model.RLPUserNumbering{
+			Year:          int64(layout.date.Year() % 100),
+			Month:         int64(layout.date.Month()),
+			Day:           int64(layout.date.Day()),
+			RLP_ID:        rlpId,
+			RLP_NO:        formattedRlpNo,
+			RLPIDEndingNO: int(endingNo),
+		}
+		return tx.Create(newRlp).Error
+	})
+	if err != nil {
 		return nil, err
-	} else {
-		var lastRlpNoInt uint64
-		fmt.Sscanf(lastEntry.RLP_NO, "%d", &lastRlpNoInt)
-		nextRlpNo = fmt.Sprintf("%011d", lastRlpNoInt+1)
 	}
 
-	// Get today's max ending number
-	var todayEntry model.RLPUserNumbering
-	err = db.Where("year = ? AND month = ? AND day = ?", year, month, day).
+	return newRlp, nil
+}
+
+// FormatRLPId renders the RLP_ID with the given counter value for the date t falls
+// on in the configured time zone.
+func FormatRLPId(t time.Time, seq int64) (string, error) {
+	layout, err := newRlpIdLayout(t)
+	if err != nil {
+		return "", err
+	}
+	return layout.format(seq)
+}
+
+// rlpIdLayout is the configured RLP_ID template rendered for one date, with
+// only the counter left open.
+type rlpIdLayout struct {
+	date   time.Time
+	prefix string
+	suffix string
+	width  int
+}
+
+func newRlpIdLayout(t time.Time) (rlpIdLayout, error) {
+	format := config.GetConfig().Application.RLPNumberingFormat
+
+	loc, err := time.LoadLocation(format.GetTimeZone())
+	if err != nil {
+		return rlpIdLayout{}, fmt.Errorf("loading rlp_id time zone: %w", err)
+	}
+
+	template := format.GetRlpIdTemplate()
+	before, after, found := strings.Cut(template, rlpIdSeqToken)
+	if !found || strings.Contains(after, rlpIdSeqToken) {
+		return rlpIdLayout{}, fmt.Errorf("rlp_id template %q must contain %s exactly once", template, rlpIdSeqToken)
+	}
+
+	date := t.In(loc)
+	tokens := strings.NewReplacer(
+		"{yyyy}", date.Format("2006"),
+		"{yy}", date.Format("06"),
+		"{MM}", date.Format("01"),
+		"{dd}", date.Format("02"),
+	)
+
+	return rlpIdLayout{
+		date:   date,
+		prefix: tokens.Replace(before),
+		suffix: tokens.Replace(after),
+		width:  format.GetRlpIdCounterWidth(),
+	}, nil
+}
+
+// counterName names the counter shared by every RLP_ID with the same surrounding text.
+func (l rlpIdLayout) counterName() string {
+	return "RLP_ID:" + l.prefix + rlpIdSeqToken + l.suffix
+}
+
+func (l rlpIdLayout) format(seq int64) (string, error) {
+	if limit := int64(math.Pow10(l.width)) - 1; seq > limit {
+		return "", fmt.Errorf("%w: all %d numbers of %s are issued", ErrRLPIdCounterExhausted, limit, l.counterName())
+	}
+	return fmt.Sprintf("%s%0*d%s", l.prefix, l.width, seq, l.suffix), nil
+}
+
+// seed continues after the highest counter value issued for this layout before its
+// counter row existed.
+func (l rlpIdLayout) seed(tx *gorm.DB) (int64, error) {
+	pattern := escapeLike(l.prefix) + strings.Repeat("_", l.width) + escapeLike(l.suffix)
+
+	var lastEntry model.RLPUserNumbering
+	err := tx.Where("rlp_id LIKE ?", pattern).
 		Order("rlp_id_ending_no DESC").
-		First(&todayEntry).Error
+		First(&lastEntry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(lastEntry.RLPIDEndingNO) + 1, nil
+}
 
-	var nextEndingNo int
-	if err == gorm.ErrRecordNotFound {
-		nextEndingNo = 1
-	} else if err != nil {
-		return nil, err
-	} else {
-		nextEndingNo = todayEntry.RLPIDEndingNO + 1
+// escapeLike quotes the SQL Server LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer("[", "[[]", "%", "[%]", "_", "[_]").Replace(s)
+}
+
+// NextCounterValue increments the named counter and returns its new value. A
+// missing counter is created with the value returned by seed. It must run inside
+// a transaction: the updated row stays locked until commit, which serialises
+// concurrent callers on the same counter.
+func NextCounterValue(tx *gorm.DB, name string, seed func(tx *gorm.DB) (int64, error)) (int64, error) {
+	table := model.RLPNumberingCounter{}.TableName()
+
+	var values []int64
+	err := tx.Raw("UPDATE "+table+" WITH (UPDLOCK, ROWLOCK) SET value = value + 1 OUTPUT inserted.value WHERE name = ?", name).
+		Scan(&values).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 1 {
+		return values[0], nil
 	}
 
-	rlpID := fmt.Sprintf("%02d%02d%02d%05d", year, month, day, nextEndingNo)
+	initial, err := seed(tx)
+	if err != nil {
+		return 0, err
+	}
 
-	newRlp := &model.RLPUserNumbering{
-		Year:          year,
-		Month:         month,
-		Day:           day,
-		RLP_ID:        rlpID,
-		RLP_NO:        nextRlpNo,
-		RLPIDEndingNO: nextEndingNo,
+	// HOLDLOCK keeps the key range locked, so when two callers create the same
+	// counter the second one waits and increments the row the first one inserted
+	err = tx.Raw("MERGE "+table+" WITH (HOLDLOCK) AS t USING (SELECT ? AS name) AS s ON t.name = s.name "+
+		"WHEN MATCHED THEN UPDATE SET value = t.value + 1 "+
+		"WHEN NOT MATCHED THEN INSERT (name, value) VALUES (s.name, ?) "+
+		"OUTPUT inserted.value;", name, initial).
+		Scan(&values).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, fmt.Errorf("counter %s: expected one value, got %d", name, len(values))
 	}
 
-	if err := db.Create(newRlp).Error; err != nil {
-		return nil, err
+	return values[0], nil
+}
+
+// seedRlpNo continues after the highest RLP_NO issued before the counter existed,
+// or starts from the configured default.
+func seedRlpNo(tx *gorm.DB) (int64, error) {
+	var lastEntry model.RLPUserNumbering
+	err := tx.Order("rlp_no DESC").First(&lastEntry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return strconv.ParseInt(config.GetConfig().Application.RLPNumberingFormat.RLPNODefault, 10, 64)
+	}
+	if err != nil {
+		return 0, err
 	}
 
-	return newRlp, nil
+	// the fixed-width sequence leads every RLP_NO, with or without check digit,
+	// so ordering the strings also orders the sequence
+	lastRlpNo, err := RLPNoSequence(lastEntry.RLP_NO)
+	if err != nil {
+		return 0, fmt.Errorf("parsing last rlp_no %q: %w", lastEntry.RLP_NO, err)
+	}
+	return lastRlpNo + 1, nil
 }
 
-func GenerateNextRLPUserNumberingWithRetry() (*model.RLPUserNumbering, error) {
-	var lastErr error
-	conf := config.GetConfig()
-	maxAttempts := conf.Application.RLPNumberingFormat.MaxAttempts
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		newRlp, err := GenerateNextRLPUserNumbering()
-		if err == nil {
-			return newRlp, nil
-		}
+// RLPNoSequence returns the sequence value an RLP_NO was formatted from, leaving
+// out its check digit if it has one.
+func RLPNoSequence(rlpNo string) (int64, error) {
+	return strconv.ParseInt(rlpNo[:min(len(rlpNo), rlpNoBaseLength)], 10, 64)
+}
+
+// FormatRLPNo renders a sequence value as an RLP_NO, followed by the configured check digit.
+func FormatRLPNo(seq int64) (string, error) {
+	alg, err := checkdigit.Parse(config.GetConfig().Application.RLPNumberingFormat.CheckDigit)
+	if err != nil {
+		return "", err
+	}
+
+	base := fmt.Sprintf("%0*d", rlpNoBaseLength, seq)
+	if len(base) != rlpNoBaseLength {
+		return "", fmt.Errorf("rlp_no sequence %d exceeds %d digits", seq, rlpNoBaseLength)
+	}
+	return checkdigit.Append(alg, base)
+}
 
-		if strings.Contains(err.Error(), "duplicate") ||
-			strings.Contains(err.Error(), "UNIQUE") {
-			lastErr = err
-			continue
+// ValidateRLPNo checks an RLP_NO keyed in by a member or at the counter. A number
+// carrying a check digit must match it; numbers issued before check digits were
+// enabled have no check digit and are accepted as they are.
+func ValidateRLPNo(rlpNo string) error {
+	alg, err := checkdigit.Parse(config.GetConfig().Application.RLPNumberingFormat.CheckDigit)
+	if err != nil {
+		return err
+	}
+
+	switch len(rlpNo) {
+	case rlpNoBaseLength:
+		if _, err := strconv.ParseUint(rlpNo, 10, 64); err != nil {
+			return ErrInvalidRLPNo
 		}
+		return nil
+	case rlpNoBaseLength + 1:
+		if !checkdigit.Valid(alg, rlpNo) {
+			return ErrInvalidRLPNo
+		}
+		return nil
+	default:
+		return ErrInvalidRLPNo
+	}
+}
+
+// ReleaseRLPUserNumbering removes an allocated numbering row, used when the
+// registration that consumed it is rolled back.
+func ReleaseRLPUserNumbering(rlpId string) error {
+	return system.GetDb().Where("rlp_id = ?", rlpId).Delete(&model.RLPUserNumbering{}).Error
+}
 
+// GetRLPUserNumberingByRlpNo returns the numbering row of the RLP_NO printed on the member card.
+func GetRLPUserNumberingByRlpNo(rlpNo string) (*model.RLPUserNumbering, error) {
+	var numbering model.RLPUserNumbering
+	if err := system.GetDb().Where("rlp_no = ?", rlpNo).First(&numbering).Error; err != nil {
 		return nil, err
 	}
-
-	return nil, fmt.Errorf("failed to generate RLP number after %d attempts: %v", maxAttempts, lastErr)
+	return &numbering, nil
 }
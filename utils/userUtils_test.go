@@ -0,0 +1,192 @@
+package utils_test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"lbe/model"
+	"lbe/system"
+	"lbe/utils"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const (
+	allocations = 2000
+	workers     = 32
+)
+
+func TestNextCounterValue_Concurrent(t *testing.T) {
+	db := system.GetDb()
+	if db == nil {
+		t.Skip("database not configured")
+	}
+	assert.NoError(t, model.MigrateRLPNumberingCounter(db))
+
+	name := fmt.Sprintf("TEST:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.Where("name = ?", name).Delete(&model.RLPNumberingCounter{})
+	})
+
+	values := allocateConcurrently(t, func() (int64, error) {
+		var value int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			value, err = utils.NextCounterValue(tx, name, func(*gorm.DB) (int64, error) { return 1, nil })
+			return err
+		})
+		return value, err
+	})
+
+	assertConsecutive(t, values, 1)
+}
+
+func TestGenerateNextRLPUserNumbering_Concurrent(t *testing.T) {
+	db := system.GetDb()
+	if db == nil {
+		t.Skip("database not configured")
+	}
+	assert.NoError(t, model.MigrateRLPUserNumbering(db))
+	assert.NoError(t, model.MigrateRLPNumberingCounter(db))
+
+	var mu sync.Mutex
+	var rlpIds []string
+	t.Cleanup(func() {
+		for _, rlpId := range rlpIds {
+			utils.ReleaseRLPUserNumbering(rlpId)
+		}
+	})
+
+	rlpNos := allocateConcurrently(t, func() (int64, error) {
+		numbering, err := utils.GenerateNextRLPUserNumbering()
+		if err != nil {
+			return 0, err
+		}
+		mu.Lock()
+		rlpIds = append(rlpIds, numbering.RLP_ID)
+		mu.Unlock()
+		if err := utils.ValidateRLPNo(numbering.RLP_NO); err != nil {
+			return 0, fmt.Errorf("rlp_no %q: %w", numbering.RLP_NO, err)
+		}
+		return utils.RLPNoSequence(numbering.RLP_NO)
+	})
+
+	if len(rlpNos) > 0 {
+		assertConsecutive(t, rlpNos, rlpNos[0])
+	}
+	assert.Len(t, uniqueStrings(rlpIds), len(rlpIds), "duplicate rlp_id allocated")
+}
+
+func TestFormatRLPId(t *testing.T) {
+	tests := []struct {
+		name        string
+		time        time.Time
+		seq         int64
+		expected    string
+		expectedErr error
+	}{
+		{name: "default layout", time: time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC), seq: 47, expected: "25052300047"},
+		{name: "date follows Asia/Singapore", time: time.Date(2025, 5, 23, 17, 30, 0, 0, time.UTC), seq: 1, expected: "25052400001"},
+		{name: "last number of the day", time: time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC), seq: 99999, expected: "25052399999"},
+		{name: "counter exhausted", time: time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC), seq: 100000, expectedErr: utils.ErrRLPIdCounterExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := utils.FormatRLPId(tt.time, tt.seq)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestRLPNoSequence(t *testing.T) {
+	tests := []struct {
+		name        string
+		rlpNo       string
+		expected    int64
+		expectedErr bool
+	}{
+		{name: "without check digit", rlpNo: "10000000047", expected: 10000000047},
+		{name: "with check digit", rlpNo: "100000000476", expected: 10000000047},
+		{name: "not a number", rlpNo: "1000000004X", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := utils.RLPNoSequence(tt.rlpNo)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+// allocateConcurrently calls allocate from several goroutines and returns the sorted results.
+func allocateConcurrently(t *testing.T, allocate func() (int64, error)) []int64 {
+	t.Helper()
+
+	jobs := make(chan struct{})
+	results := make(chan int64, allocations)
+	errs := make(chan error, allocations)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				value, err := allocate()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				results <- value
+			}
+		}()
+	}
+	for i := 0; i < allocations; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("allocation failed: %v", err)
+	}
+
+	values := make([]int64, 0, allocations)
+	for value := range results {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+func assertConsecutive(t *testing.T, values []int64, first int64) {
+	t.Helper()
+
+	assert.Len(t, values, allocations)
+	for i, value := range values {
+		if !assert.Equal(t, first+int64(i), value, "gap or duplicate at position %d", i) {
+			return
+		}
+	}
+}
+
+func uniqueStrings(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}